@@ -0,0 +1,130 @@
+package batch
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/pasca-l/wifi-qrcode-generator/render"
+)
+
+// file name template used when none is specified
+const DefaultNameTemplate string = "{{.Row}}-{{.SSID}}"
+
+// name of contact sheet in archives
+const contactSheetName string = "contact-sheet.pdf"
+
+// fields available to file name templates
+type nameData struct {
+	Row        int
+	SSID       string
+	Encryption string
+	Fields     map[string]string // all columns of the row, such as {{.Fields.floor}}
+}
+
+func ParseNameTemplate(text string) (*template.Template, error) {
+	if text == "" {
+		text = DefaultNameTemplate
+	}
+	tmpl, err := template.New("name").Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse file name template: %w", err)
+	}
+	return tmpl, nil
+}
+
+type Archive struct {
+	Renderer     render.Renderer
+	Options      render.RenderOptions
+	Names        *template.Template // file names without extension, from ParseNameTemplate
+	ContactSheet bool               // adds a PDF listing all codes
+}
+
+// streams a ZIP archive with a file per entry, written as each entry is rendered
+func (a Archive) Write(w io.Writer, entries []Entry) error {
+	zw := zip.NewWriter(w)
+	used := make(map[string]bool)
+	for _, entry := range entries {
+		name, err := a.fileName(entry, used)
+		if err != nil {
+			return err
+		}
+		f, err := zw.Create(name)
+		if err != nil {
+			return err
+		}
+		err = entryRenderer(a.Renderer, entry).Render(f, entry.Code, a.Options)
+		if err != nil {
+			return fmt.Errorf("row %d: %w", entry.Row, err)
+		}
+	}
+
+	if a.ContactSheet {
+		f, err := zw.Create(contactSheetName)
+		if err != nil {
+			return err
+		}
+		err = WriteContactSheet(f, entries)
+		if err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+// labels output with SSID of the entry, as done for single codes
+func entryRenderer(r render.Renderer, entry Entry) render.Renderer {
+	switch rr := r.(type) {
+	case render.PDFRenderer:
+		rr.Caption = entry.Spec.SSID()
+		return rr
+	case render.SVGRenderer:
+		rr.Title = fmt.Sprintf("Wi-Fi QR code for network %s", entry.Spec.SSID())
+		return rr
+	default:
+		return r
+	}
+}
+
+// executes name template, keeping file names safe and unique within the archive
+func (a Archive) fileName(entry Entry, used map[string]bool) (string, error) {
+	buf := new(strings.Builder)
+	err := a.Names.Execute(buf, nameData{
+		Row:        entry.Row,
+		SSID:       entry.Spec.SSID(),
+		Encryption: string(entry.Spec.Encryption()),
+		Fields:     entry.Fields,
+	})
+	if err != nil {
+		return "", fmt.Errorf("row %d: cannot execute file name template: %w", entry.Row, err)
+	}
+
+	base := sanitizeFileName(buf.String())
+	if base == "" {
+		base = "code-" + strconv.Itoa(entry.Row)
+	}
+	name := base + "." + a.Renderer.Name()
+	for i := 2; used[name] || name == contactSheetName; i++ {
+		name = fmt.Sprintf("%s-%d.%s", base, i, a.Renderer.Name())
+	}
+	used[name] = true
+	return name, nil
+}
+
+// replaces characters other than letters, digits, dots, hyphens and underscores,
+// so that names cannot escape the extraction directory
+func sanitizeFileName(name string) string {
+	sanitized := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, strings.TrimSpace(name))
+	return strings.Trim(sanitized, ".")
+}
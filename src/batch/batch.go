@@ -0,0 +1,243 @@
+package batch
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/pasca-l/wifi-qrcode-generator/qrcode"
+)
+
+type Format string
+
+const (
+	CSVFormat  Format = "csv"  // header row with column names, followed by a row per network
+	JSONFormat Format = "json" // array of objects, with an object per network
+)
+
+// detects format from the given name if non-empty, otherwise from file extension
+func DetectFormat(name string, filename string) (Format, error) {
+	if name == "" {
+		name = strings.TrimPrefix(strings.ToLower(filepath.Ext(filename)), ".")
+	}
+	switch Format(name) {
+	case CSVFormat, JSONFormat:
+		return Format(name), nil
+	default:
+		return "", fmt.Errorf("unexpected batch format: %s", name)
+	}
+}
+
+// network spec of a row, with its generated code
+type Entry struct {
+	Row    int // 1-based, excluding CSV header
+	Spec   qrcode.WifiSpec
+	Fields map[string]string // all columns of the row, available to file name templates
+	Code   qrcode.QRCode
+}
+
+type RowError struct {
+	Row int
+	Err error
+}
+
+func (e RowError) Error() string {
+	return fmt.Sprintf("row %d: %v", e.Row, e.Err)
+}
+
+func (e RowError) Unwrap() error {
+	return e.Err
+}
+
+// errors of every invalid row, so that all of them can be fixed at once
+type Errors []RowError
+
+func (e Errors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, re := range e {
+		messages = append(messages, re.Error())
+	}
+	return strings.Join(messages, "\n")
+}
+
+// upper bound of rows in a batch
+const MaxRows int = 1000
+
+// checks code of a row before it is generated, such as against limits of a server
+// errors stop reading the batch, and nil allows every code
+type Budget func(row int, version qrcode.Version, ecl qrcode.ErrorCorrectionLevel) error
+
+// reads network specs and generates codes, validating every row
+// returns Errors listing invalid rows, RowError of a row exceeding the budget, or other errors for malformed input
+func Read(r io.Reader, format Format, ecl qrcode.ErrorCorrectionLevel, budget Budget) ([]Entry, error) {
+	var rows []map[string]string
+	var errs Errors
+	var err error
+	switch format {
+	case CSVFormat:
+		rows, errs, err = readCSV(r)
+	case JSONFormat:
+		rows, err = readJSON(r)
+	default:
+		err = fmt.Errorf("unexpected batch format: %s", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]Entry, 0, len(rows))
+	for i, fields := range rows {
+		if fields == nil {
+			// already reported by reader
+			continue
+		}
+		entry, err := newEntry(i+1, fields, ecl, budget)
+		var budgetErr budgetError
+		if errors.As(err, &budgetErr) {
+			return nil, RowError{Row: i + 1, Err: budgetErr.err}
+		}
+		if err != nil {
+			errs = append(errs, RowError{Row: i + 1, Err: err})
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	if len(errs) > 0 {
+		slices.SortFunc(errs, func(a, b RowError) int { return a.Row - b.Row })
+		return nil, errs
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("batch has no rows")
+	}
+	return entries, nil
+}
+
+func newEntry(row int, fields map[string]string, ecl qrcode.ErrorCorrectionLevel, budget Budget) (Entry, error) {
+	if fields["ssid"] == "" {
+		return Entry{}, fmt.Errorf("ssid must not be empty")
+	}
+	params := make(url.Values, len(fields))
+	for k, v := range fields {
+		params.Set(k, v)
+	}
+	spec, err := qrcode.NewWifiSpec(params)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	entry, err := NewEntry(row, spec, ecl, budget)
+	if err != nil {
+		return Entry{}, err
+	}
+	entry.Fields = fields
+	return entry, nil
+}
+
+// generates code for the spec, such as for networks imported from other sources
+func NewEntry(row int, spec qrcode.WifiSpec, ecl qrcode.ErrorCorrectionLevel, budget Budget) (Entry, error) {
+	src := spec.Encode()
+	qrCodeSpec, err := qrcode.NewQRCodeSpec(src, ecl)
+	if err != nil {
+		return Entry{}, err
+	}
+	if budget != nil {
+		err = budget(row, qrCodeSpec.Version(), qrCodeSpec.ECL())
+		if err != nil {
+			return Entry{}, budgetError{err: err}
+		}
+	}
+	code, err := qrcode.NewQRCode(src, qrCodeSpec)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	return Entry{Row: row, Spec: spec, Code: code}, nil
+}
+
+// error returned by budget, told apart from invalid rows
+type budgetError struct {
+	err error
+}
+
+func (e budgetError) Error() string {
+	return e.err.Error()
+}
+
+func (e budgetError) Unwrap() error {
+	return e.err
+}
+
+// returns nil for rows with errors, keeping row numbers of the following rows
+func readCSV(r io.Reader) ([]map[string]string, Errors, error) {
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil, fmt.Errorf("batch has no header row")
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	for i, name := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(name))
+	}
+
+	rows := make([]map[string]string, 0)
+	errs := Errors{}
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if len(rows) >= MaxRows {
+			return nil, nil, fmt.Errorf("batch must have at most %d rows", MaxRows)
+		}
+		// rows with wrong number of fields are reported, while reading can continue
+		if errors.Is(err, csv.ErrFieldCount) {
+			errs = append(errs, RowError{Row: len(rows) + 1, Err: fmt.Errorf("expected %d fields, got %d", len(header), len(record))})
+			rows = append(rows, nil)
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		fields := make(map[string]string, len(header))
+		for i, name := range header {
+			fields[name] = record[i]
+		}
+		rows = append(rows, fields)
+	}
+
+	return rows, errs, nil
+}
+
+func readJSON(r io.Reader) ([]map[string]string, error) {
+	var objects []map[string]any
+	err := json.NewDecoder(r).Decode(&objects)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode batch: %w", err)
+	}
+	if len(objects) > MaxRows {
+		return nil, fmt.Errorf("batch must have at most %d rows", MaxRows)
+	}
+
+	rows := make([]map[string]string, 0, len(objects))
+	for _, obj := range objects {
+		fields := make(map[string]string, len(obj))
+		for k, v := range obj {
+			// booleans such as hidden, and numbers such as floors are accepted as is
+			if v != nil {
+				fields[strings.ToLower(k)] = fmt.Sprint(v)
+			}
+		}
+		rows = append(rows, fields)
+	}
+	return rows, nil
+}
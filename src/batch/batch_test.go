@@ -0,0 +1,115 @@
+package batch
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/pasca-l/wifi-qrcode-generator/qrcode"
+	"github.com/pasca-l/wifi-qrcode-generator/render"
+)
+
+func TestRead(t *testing.T) {
+	// allows codes up to version 2
+	budget := func(row int, version qrcode.Version, ecl qrcode.ErrorCorrectionLevel) error {
+		if version > 2 {
+			return fmt.Errorf("version %d exceeds budget", version)
+		}
+		return nil
+	}
+	testcases := []struct {
+		input       string
+		format      Format
+		budget      Budget
+		wantEntries int
+		wantErr     error
+	}{
+		{
+			input:       "ssid,password,encryption,floor\nlobby,secret,WPA,1\nroom 201,,nopass,2\n",
+			format:      CSVFormat,
+			wantEntries: 2,
+			wantErr:     nil,
+		},
+		{
+			input:       `[{"ssid": "lobby", "password": "secret", "encryption": "WPA", "hidden": true}]`,
+			format:      JSONFormat,
+			wantEntries: 1,
+			wantErr:     nil,
+		},
+		{
+			input:   "ssid,password,encryption\nlobby,secret,WPA2\n,secret,WPA\nroom 201,secret\n",
+			format:  CSVFormat,
+			wantErr: errors.New("row 1: cannot convert value 'WPA2' to type Encryption\nrow 2: ssid must not be empty\nrow 3: expected 3 fields, got 2"),
+		},
+		{
+			input:   `{"ssid": "lobby"}`,
+			format:  JSONFormat,
+			wantErr: errors.New("cannot decode batch: json: cannot unmarshal object into Go value of type []map[string]interface {}"),
+		},
+		{
+			input:       "ssid,encryption\nlobby,nopass\n",
+			format:      CSVFormat,
+			budget:      budget,
+			wantEntries: 1,
+			wantErr:     nil,
+		},
+		{
+			input:   "ssid,password,encryption\nlobby,,nopass\nroom 201," + strings.Repeat("x", 20) + ",WPA\nlobby,,WPA2\n",
+			format:  CSVFormat,
+			budget:  budget,
+			wantErr: errors.New("row 2: version 3 exceeds budget"),
+		},
+		{
+			input:   "ssid\n" + strings.Repeat("lobby\n", MaxRows+1),
+			format:  CSVFormat,
+			wantErr: errors.New("batch must have at most 1000 rows"),
+		},
+	}
+
+	for _, tt := range testcases {
+		t.Run("testing Read()", func(t *testing.T) {
+			got, err := Read(strings.NewReader(tt.input), tt.format, qrcode.L, tt.budget)
+			if (err == nil) != (tt.wantErr == nil) || (err != nil && err.Error() != tt.wantErr.Error()) {
+				t.Errorf("Read() error = '%v'; expected '%v'", err, tt.wantErr)
+			}
+			if len(got) != tt.wantEntries {
+				t.Errorf("Read() = %d entries; expected %d", len(got), tt.wantEntries)
+			}
+		})
+	}
+}
+
+func TestArchiveWrite(t *testing.T) {
+	input := "ssid,password,encryption,floor\nlobby,secret,WPA,1\nlobby,secret,WPA,1\n../etc,,nopass,2\n"
+	entries, err := Read(strings.NewReader(input), CSVFormat, qrcode.L, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names, err := ParseNameTemplate("floor{{.Fields.floor}}-{{.SSID}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := new(bytes.Buffer)
+	archive := Archive{Renderer: render.SVGRenderer{}, Options: render.DefaultRenderOptions(), Names: names, ContactSheet: true}
+	err = archive.Write(buf, entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make([]string, 0, len(zr.File))
+	for _, f := range zr.File {
+		got = append(got, f.Name)
+	}
+	want := []string{"floor1-lobby.svg", "floor1-lobby-2.svg", "floor2-.._etc.svg", "contact-sheet.pdf"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Archive.Write() files = %v; expected %v", got, want)
+	}
+}
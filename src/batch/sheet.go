@@ -0,0 +1,67 @@
+package batch
+
+import (
+	"fmt"
+	"image/color"
+	"io"
+
+	"github.com/pasca-l/wifi-qrcode-generator/render"
+	"github.com/pasca-l/wifi-qrcode-generator/utils/pdf"
+)
+
+// contact sheet is laid out as a grid on A4 pages, in mm
+const (
+	sheetColumns    int     = 3
+	sheetRows       int     = 4
+	sheetMargin     float64 = 15
+	sheetCodeSize   float64 = 45
+	sheetQuietZone  int     = 2 // modules around each code, smaller than specified to fit more codes
+	sheetFontSize   float64 = 9 // in points
+	sheetLabelSpace float64 = 4 // between code and label
+)
+
+func mmToPoints(mm float64) float64 {
+	return mm * 72 / 25.4
+}
+
+// writes PDF with codes of all entries labeled by SSID, for reviewing a batch at a glance
+func WriteContactSheet(w io.Writer, entries []Entry) error {
+	doc := pdf.NewDocument()
+	pageWidth, pageHeight := mmToPoints(render.A4.Width), mmToPoints(render.A4.Height)
+	margin := mmToPoints(sheetMargin)
+	cellWidth := (pageWidth - 2*margin) / float64(sheetColumns)
+	cellHeight := (pageHeight - 2*margin) / float64(sheetRows)
+	codeSize := mmToPoints(sheetCodeSize)
+
+	var page *pdf.Page
+	perPage := sheetColumns * sheetRows
+	for i, entry := range entries {
+		if i%perPage == 0 {
+			page = doc.AddPage(pageWidth, pageHeight)
+			page.SetFillColor(color.Black)
+		}
+		column, row := i%perPage%sheetColumns, i%perPage/sheetColumns
+		left := margin + float64(column)*cellWidth + (cellWidth-codeSize)/2
+		top := pageHeight - margin - float64(row)*cellHeight
+
+		pat := render.WithMargin(entry.Code.Pattern, sheetQuietZone)
+		module := codeSize / float64(len(pat))
+		for _, r := range render.ModuleRects(pat) {
+			// PDF has origin at lower left corner
+			page.Rect(left+float64(r.Min.X)*module, top-float64(r.Max.Y)*module, float64(r.Dx())*module, float64(r.Dy())*module)
+		}
+		page.Fill()
+
+		// shrink label to fit within the cell
+		label := fmt.Sprintf("%d. %s", entry.Row, entry.Spec.SSID())
+		size := sheetFontSize
+		if width := pdf.TextWidth(pdf.Sans, size, label); width > cellWidth {
+			size *= cellWidth / width
+		}
+		width := pdf.TextWidth(pdf.Sans, size, label)
+		baseline := top - codeSize - mmToPoints(sheetLabelSpace)
+		page.Text(margin+float64(column)*cellWidth+(cellWidth-width)/2, baseline, pdf.Sans, size, label)
+	}
+
+	return doc.Write(w)
+}
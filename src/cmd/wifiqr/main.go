@@ -0,0 +1,384 @@
+// command line tool generating Wi-Fi QR codes without the web server
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/pasca-l/wifi-qrcode-generator/batch"
+	"github.com/pasca-l/wifi-qrcode-generator/passphrase"
+	"github.com/pasca-l/wifi-qrcode-generator/profile"
+	"github.com/pasca-l/wifi-qrcode-generator/qrcode"
+	"github.com/pasca-l/wifi-qrcode-generator/render"
+	"golang.org/x/term"
+)
+
+const usage string = `usage: wifiqr <command> [flags]
+
+commands:
+  generate    generate QR code for joining a Wi-Fi network
+  batch       generate QR codes for networks listed in CSV or JSON, into a ZIP archive
+  import      generate QR codes from network configs and profiles
+  passphrase  generate WPA passphrase, reporting its entropy
+`
+
+func main() {
+	err := run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "wifiqr:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdin *os.File, stdout *os.File, stderr io.Writer) error {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return flag.ErrHelp
+	}
+
+	switch args[0] {
+	case "generate":
+		return generate(args[1:], stdin, stdout, stderr)
+	case "batch":
+		return generateBatch(args[1:], stdin, stdout, stderr)
+	case "import":
+		return importProfile(args[1:], stdout, stderr)
+	case "passphrase":
+		return generatePassphrase(args[1:], stdout, stderr)
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stderr, usage)
+		return nil
+	default:
+		fmt.Fprint(stderr, usage)
+		return fmt.Errorf("unknown command: %s", args[0])
+	}
+}
+
+func generate(args []string, stdin *os.File, stdout *os.File, stderr io.Writer) error {
+	fs := flag.NewFlagSet("generate", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	ssid := fs.String("ssid", "", "network name (required)")
+	password := fs.String("password", "", "network password, which is kept in shell history (prefer -password-stdin or the prompt)")
+	passwordStdin := fs.Bool("password-stdin", false, "read password from the first line of standard input")
+	encryption := fs.String("encryption", string(qrcode.WPA), "encryption type: WPA, WEP or nopass")
+	hidden := fs.Bool("hidden", false, "network does not broadcast its SSID")
+	psk := fs.Bool("psk", false, "encode PSK derived from passphrase and SSID, instead of passphrase itself")
+	ecl := fs.String("ecl", "L", "error correction level: L, M, Q or H")
+	format := fs.String("format", "", "output format: "+strings.Join(render.Names(), ", ")+" (default from output file extension, or txt for terminals and svg otherwise)")
+	output := fs.String("o", "", "output file (default standard output)")
+	size := fs.Int("size", render.DefaultRenderOptions().Size, "width and height of output in pixels")
+	margin := fs.Int("margin", render.DefaultRenderOptions().Margin, "quiet zone around the code in modules")
+	textMode := fs.String("text-mode", "", "text output mode: half-block, ansi or ascii (default half-block)")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+	if *ssid == "" {
+		return fmt.Errorf("-ssid is required")
+	}
+
+	if qrcode.Encryption(*encryption) != qrcode.NoPass && *password == "" {
+		*password, err = readPassword(stdin, stderr, *passwordStdin)
+		if err != nil {
+			return err
+		}
+	}
+	wifiSpec, err := qrcode.NewWifiSpec(url.Values{
+		"ssid":       {*ssid},
+		"password":   {*password},
+		"encryption": {*encryption},
+		"hidden":     {fmt.Sprint(*hidden)},
+		"psk":        {fmt.Sprint(*psk)},
+	})
+	if err != nil {
+		return err
+	}
+	level, err := qrcode.ToErrorCorrectionLevel(*ecl)
+	if err != nil {
+		return err
+	}
+
+	opts := render.DefaultRenderOptions()
+	opts.Size = *size
+	opts.Margin = *margin
+	return writeCode(wifiSpec, level, outputFormat(*format, *output, stdout), *textMode, opts, *output, stdout)
+}
+
+// renders code of the spec into output file, or standard output if empty
+func writeCode(
+	spec qrcode.WifiSpec, level qrcode.ErrorCorrectionLevel,
+	format string, textMode string, opts render.RenderOptions, output string, stdout *os.File,
+) error {
+	src := spec.Encode()
+	qrCodeSpec, err := qrcode.NewQRCodeSpec(src, level)
+	if err != nil {
+		return err
+	}
+	qrCode, err := qrcode.NewQRCode(src, qrCodeSpec)
+	if err != nil {
+		return err
+	}
+
+	renderer, err := render.GetByName(format)
+	if err != nil {
+		return err
+	}
+	if tr, isText := renderer.(render.TextRenderer); isText {
+		tr.Mode, err = render.ParseTextMode(textMode)
+		if err != nil {
+			return err
+		}
+		tr.DarkTerminal = render.DetectDarkTerminal()
+		renderer = tr
+	}
+
+	if output == "" {
+		return renderer.Render(stdout, qrCode, opts)
+	}
+	f, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	err = renderer.Render(f, qrCode, opts)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// reads password from standard input, prompting without echo if it is a terminal,
+// so that secrets are kept out of shell history
+func readPassword(stdin *os.File, stderr io.Writer, fromStdin bool) (string, error) {
+	if !fromStdin && term.IsTerminal(int(stdin.Fd())) { //nolint:gosec // file descriptors fit in int
+		fmt.Fprint(stderr, "Password: ")
+		password, err := term.ReadPassword(int(stdin.Fd())) //nolint:gosec // file descriptors fit in int
+		fmt.Fprintln(stderr)
+		if err != nil {
+			return "", err
+		}
+		return string(password), nil
+	}
+	if !fromStdin {
+		return "", fmt.Errorf("password is required: use -password-stdin when standard input is not a terminal")
+	}
+
+	line, err := bufio.NewReader(stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// infers renderer name from output file extension, or uses text for terminals
+func outputFormat(format string, output string, stdout *os.File) string {
+	if format != "" {
+		return format
+	}
+	if ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(output), ".")); ext != "" {
+		if ext == "jpg" {
+			return "jpeg"
+		}
+		return ext
+	}
+	if output == "" && term.IsTerminal(int(stdout.Fd())) { //nolint:gosec // file descriptors fit in int
+		return "txt"
+	}
+	return "svg"
+}
+
+func generateBatch(args []string, stdin *os.File, stdout *os.File, stderr io.Writer) error {
+	fs := flag.NewFlagSet("batch", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	input := fs.String("i", "", "CSV or JSON file listing networks, with ssid, password, encryption and hidden columns (default standard input)")
+	inputFormat := fs.String("input-format", "", "input format: csv or json (default from input file extension)")
+	ecl := fs.String("ecl", "L", "error correction level: L, M, Q or H")
+	format := fs.String("format", "png", "output format of each code: "+strings.Join(render.Names(), ", "))
+	name := fs.String("name", batch.DefaultNameTemplate, "file name template without extension, with fields .Row, .SSID, .Encryption and .Fields")
+	contactSheet := fs.Bool("contact-sheet", false, "add a PDF contact sheet listing all codes")
+	output := fs.String("o", "", "output ZIP file (default standard output)")
+	size := fs.Int("size", render.DefaultRenderOptions().Size, "width and height of each code in pixels")
+	margin := fs.Int("margin", render.DefaultRenderOptions().Margin, "quiet zone around each code in modules")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+
+	bf, err := batch.DetectFormat(*inputFormat, *input)
+	if err != nil {
+		return err
+	}
+	level, err := qrcode.ToErrorCorrectionLevel(*ecl)
+	if err != nil {
+		return err
+	}
+	renderer, err := render.GetByName(*format)
+	if err != nil {
+		return err
+	}
+	names, err := batch.ParseNameTemplate(*name)
+	if err != nil {
+		return err
+	}
+
+	in := stdin
+	if *input != "" {
+		in, err = os.Open(*input)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+	}
+	entries, err := batch.Read(in, bf, level, nil)
+	if err != nil {
+		return err
+	}
+
+	opts := render.DefaultRenderOptions()
+	opts.Size = *size
+	opts.Margin = *margin
+	archive := batch.Archive{Renderer: renderer, Options: opts, Names: names, ContactSheet: *contactSheet}
+	return writeArchive(archive, entries, *output, stdout)
+}
+
+// writes ZIP archive into output file, or standard output if empty
+func writeArchive(archive batch.Archive, entries []batch.Entry, output string, stdout *os.File) error {
+	if output == "" {
+		return archive.Write(stdout, entries)
+	}
+	f, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	err = archive.Write(f, entries)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func importProfile(args []string, stdout *os.File, stderr io.Writer) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	input := fs.String("i", "", "NetworkManager keyfile, wpa_supplicant.conf, hostapd.conf, WLAN profile XML or .mobileconfig (required)")
+	inputFormat := fs.String("input-format", "", "profile format: networkmanager, wpa_supplicant, hostapd, wlan or mobileconfig (default from input file name)")
+	ssid := fs.String("ssid", "", "network to import, when profile has multiple networks")
+	ecl := fs.String("ecl", "L", "error correction level: L, M, Q or H")
+	format := fs.String("format", "", "output format: "+strings.Join(render.Names(), ", ")+" (default from output file extension, or txt for terminals and svg otherwise)")
+	name := fs.String("name", batch.DefaultNameTemplate, "file name template without extension, when writing multiple networks into ZIP archive")
+	output := fs.String("o", "", "output file, which is a ZIP archive for multiple networks (default standard output)")
+	size := fs.Int("size", render.DefaultRenderOptions().Size, "width and height of output in pixels")
+	margin := fs.Int("margin", render.DefaultRenderOptions().Margin, "quiet zone around the code in modules")
+	textMode := fs.String("text-mode", "", "text output mode: half-block, ansi or ascii (default half-block)")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+	if *input == "" {
+		return fmt.Errorf("-i is required")
+	}
+
+	pf, err := profile.DetectFormat(*inputFormat, *input)
+	if err != nil {
+		return err
+	}
+	level, err := qrcode.ToErrorCorrectionLevel(*ecl)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(*input)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	specs, err := profile.Import(f, pf)
+	if err != nil {
+		return err
+	}
+	if *ssid != "" {
+		specs = slices.DeleteFunc(specs, func(spec qrcode.WifiSpec) bool { return spec.SSID() != *ssid })
+		if len(specs) == 0 {
+			return fmt.Errorf("network '%s' is not found in profile", *ssid)
+		}
+	}
+
+	opts := render.DefaultRenderOptions()
+	opts.Size = *size
+	opts.Margin = *margin
+	if len(specs) == 1 {
+		return writeCode(specs[0], level, outputFormat(*format, *output, stdout), *textMode, opts, *output, stdout)
+	}
+
+	// format of each code in archive is not inferred from the archive name
+	if *format == "" {
+		*format = "png"
+	}
+	renderer, err := render.GetByName(*format)
+	if err != nil {
+		return err
+	}
+	names, err := batch.ParseNameTemplate(*name)
+	if err != nil {
+		return err
+	}
+	entries := make([]batch.Entry, 0, len(specs))
+	for i, spec := range specs {
+		entry, err := batch.NewEntry(i+1, spec, level, nil)
+		if err != nil {
+			return fmt.Errorf("network '%s': %w", spec.SSID(), err)
+		}
+		entries = append(entries, entry)
+	}
+	return writeArchive(batch.Archive{Renderer: renderer, Options: opts, Names: names}, entries, *output, stdout)
+}
+
+// prints passphrase on standard output, and its entropy on standard error to be kept out of pipes
+func generatePassphrase(args []string, stdout io.Writer, stderr io.Writer) error {
+	fs := flag.NewFlagSet("passphrase", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	mode := fs.String("mode", string(passphrase.PrintableMode), "passphrase mode: diceware, printable or hex (64 digit PSK)")
+	length := fs.Int("length", 0, "words of diceware, or characters of printable (default 6 words or 16 characters)")
+	avoidSpecial := fs.Bool("avoid-special", true, "avoid characters with special meaning in WIFI: codes")
+	avoidLookAlike := fs.Bool("avoid-look-alike", true, "avoid characters read one for another when printed")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+
+	policy := passphrase.Policy{Length: *length, AvoidSpecial: *avoidSpecial, AvoidLookAlike: *avoidLookAlike}
+	policy.Mode, err = passphrase.ParseMode(*mode)
+	if err != nil {
+		return err
+	}
+	value, err := passphrase.Generate(policy)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(stdout, value)
+	fmt.Fprintf(stderr, "entropy: %.1f bits\n", policy.Entropy())
+	return nil
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	dir := t.TempDir()
+	stdin, err := os.Create(filepath.Join(dir, "stdin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = stdin.WriteString("secret password\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = stdin.Seek(0, io.SeekStart)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout, err := os.Create(filepath.Join(dir, "stdout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output := filepath.Join(dir, "guest.svg")
+	err = run([]string{"generate", "-ssid", "guest", "-password-stdin", "-hidden", "-ecl", "M", "-o", output}, stdin, stdout, io.Discard)
+	if err != nil {
+		t.Fatalf("run() error = '%v'; expected nil", err)
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "<svg") {
+		t.Errorf("run() wrote %q; expected SVG document", data[:min(len(data), 64)])
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	testcases := []struct {
+		args    []string
+		wantErr string
+	}{
+		{args: []string{"generate", "-encryption", "nopass"}, wantErr: "-ssid is required"},
+		{args: []string{"generate", "-ssid", "guest", "-encryption", "WPA"}, wantErr: "password is required: use -password-stdin when standard input is not a terminal"},
+		{args: []string{"generate", "-ssid", "guest", "-encryption", "nopass", "-ecl", "X"}, wantErr: "cannot convert value 'X' to type ErrorCorrectionLevel"},
+		{args: []string{"passphrase", "-mode", "base64"}, wantErr: "cannot convert value 'base64' to type Mode"},
+		{args: []string{"passphrase", "-mode", "diceware", "-length", "10"}, wantErr: "words must be between 3 and 9: given 10"},
+		{args: []string{"remove"}, wantErr: "unknown command: remove"},
+	}
+
+	stdin, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stdin.Close()
+
+	for _, tt := range testcases {
+		t.Run("testing run()", func(t *testing.T) {
+			err := run(tt.args, stdin, nil, io.Discard)
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("run() error = '%v'; expected '%s'", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGeneratePassphrase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stdout")
+	stdout, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stdout.Close()
+	stderr := new(strings.Builder)
+	err = run([]string{"passphrase", "-mode", "diceware", "-length", "4"}, nil, stdout, stderr)
+	if err != nil {
+		t.Fatalf("run() error = '%v'; expected nil", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !regexp.MustCompile(`^[a-z]{3,6}(-[a-z]{3,6}){3}\n$`).MatchString(string(data)) {
+		t.Errorf("run() wrote %q; expected 4 words", data)
+	}
+	if stderr.String() != "entropy: 41.4 bits\n" {
+		t.Errorf("run() reported %q; expected entropy of 4 words", stderr.String())
+	}
+}
+
+func TestOutputFormat(t *testing.T) {
+	testcases := []struct {
+		format string
+		output string
+		want   string
+	}{
+		{format: "pdf", output: "code.png", want: "pdf"},
+		{format: "", output: "code.PNG", want: "png"},
+		{format: "", output: "code.jpg", want: "jpeg"},
+		{format: "", output: "code.JPG", want: "jpeg"},
+		{format: "", output: "code", want: "svg"},
+	}
+
+	for _, tt := range testcases {
+		t.Run("testing outputFormat()", func(t *testing.T) {
+			got := outputFormat(tt.format, tt.output, nil)
+			if got != tt.want {
+				t.Errorf("outputFormat(%q, %q) = %q; expected %q", tt.format, tt.output, got, tt.want)
+			}
+		})
+	}
+}
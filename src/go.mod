@@ -1,5 +1,10 @@
 module github.com/pasca-l/wifi-qrcode-generator
 
-go 1.24
+go 1.24.0
 
-require github.com/ajstarks/svgo v0.0.0-20211024235047-1546f124cd8b
+require (
+	github.com/ajstarks/svgo v0.0.0-20211024235047-1546f124cd8b
+	golang.org/x/term v0.36.0
+)
+
+require golang.org/x/sys v0.37.0 // indirect
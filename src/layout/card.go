@@ -0,0 +1,58 @@
+package layout
+
+import (
+	"time"
+
+	"github.com/pasca-l/wifi-qrcode-generator/qrcode"
+	"github.com/pasca-l/wifi-qrcode-generator/render"
+)
+
+type Card struct {
+	Spec         qrcode.WifiSpec
+	MaskPassword bool         // replaces password with asterisks, such as for public posters
+	ValidUntil   time.Time    // omitted if zero value
+	Logo         *render.Logo // omitted if nil
+}
+
+// fields available to text templates
+type cardData struct {
+	SSID       string
+	Password   string
+	Security   string
+	ValidUntil string
+}
+
+const passwordMask string = "********"
+
+func (c Card) data() cardData {
+	password := c.Spec.Password()
+	if c.MaskPassword && password != "" {
+		password = passwordMask
+	}
+	validUntil := ""
+	if !c.ValidUntil.IsZero() {
+		validUntil = c.ValidUntil.Format(time.DateOnly)
+	}
+
+	return cardData{
+		SSID:       c.Spec.SSID(),
+		Password:   password,
+		Security:   securityLabel(c.Spec.Encryption()),
+		ValidUntil: validUntil,
+	}
+}
+
+func securityLabel(enc qrcode.Encryption) string {
+	switch enc {
+	case qrcode.NoPass:
+		return "None"
+	case qrcode.WEP:
+		return "WEP"
+	case qrcode.WPA:
+		return "WPA/WPA2/WPA3"
+	case qrcode.WPA2EAP:
+		return "WPA2-Enterprise"
+	default:
+		return string(enc)
+	}
+}
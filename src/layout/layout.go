@@ -0,0 +1,85 @@
+package layout
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/pasca-l/wifi-qrcode-generator/qrcode"
+	"github.com/pasca-l/wifi-qrcode-generator/utils/pdf"
+)
+
+type Format string
+
+const (
+	SVGFormat Format = "svg"
+	PDFFormat Format = "pdf"
+)
+
+func (f Format) MIMEType() string {
+	switch f {
+	case PDFFormat:
+		return "application/pdf"
+	default:
+		return "image/svg+xml"
+	}
+}
+
+// quiet zone drawn around the code, in modules
+const quietZone int = 4
+
+// line height relative to font size, for texts with multiple lines
+const lineHeight float64 = 1.2
+
+const mmPerPoint float64 = 25.4 / 72
+
+// composes the code with card texts according to the template
+func Render(w io.Writer, t Template, card Card, code qrcode.QRCode, format Format) error {
+	switch format {
+	case SVGFormat:
+		return renderSVG(w, t, card, code)
+	case PDFFormat:
+		doc := pdf.NewDocument()
+		err := AddPDFPage(doc, t, card, code)
+		if err != nil {
+			return err
+		}
+		return doc.Write(w)
+	default:
+		return fmt.Errorf("unexpected format: %s", format)
+	}
+}
+
+// measures text with metrics of the font embedded in PDF, shrinking font size to fit maximum width
+// returns font size in points and width in mm
+func fitText(e Element, line string) (float64, float64) {
+	font := pdf.Sans
+	if e.Bold {
+		font = pdf.SansBold
+	}
+	size := e.FontSize
+	width := pdf.TextWidth(font, size, line) * mmPerPoint
+	if e.Width > 0 && width > e.Width {
+		size *= e.Width / width
+		width = e.Width
+	}
+	return size, width
+}
+
+// offset of text start from its anchor, in mm
+func alignOffset(align Align, width float64) float64 {
+	switch align {
+	case AlignCenter:
+		return -width / 2
+	case AlignRight:
+		return -width
+	default:
+		return 0
+	}
+}
+
+// fits logo into the element box keeping its aspect ratio, returning the centered box in mm
+func fitLogo(e Element, width int, height int) (float64, float64, float64, float64) {
+	scale := min(e.Width/float64(width), e.Height/float64(height))
+	w, h := float64(width)*scale, float64(height)*scale
+	return e.X + (e.Width-w)/2, e.Y + (e.Height-h)/2, w, h
+}
@@ -0,0 +1,160 @@
+package layout
+
+import (
+	"bytes"
+	"errors"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pasca-l/wifi-qrcode-generator/qrcode"
+)
+
+func newTestCard(t *testing.T, mask bool) (Card, qrcode.QRCode) {
+	t.Helper()
+	spec, err := qrcode.NewWifiSpec(url.Values{
+		"ssid":       {"Guest (5G)"},
+		"password":   {"correct horse"},
+		"encryption": {"WPA"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := spec.Encode()
+	codeSpec, err := qrcode.NewQRCodeSpec(src, qrcode.L)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := qrcode.NewQRCode(src, codeSpec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	card := Card{
+		Spec:         spec,
+		MaskPassword: mask,
+		ValidUntil:   time.Date(2026, 10, 31, 0, 0, 0, 0, time.UTC),
+	}
+	return card, code
+}
+
+func TestCardData(t *testing.T) {
+	testcases := []struct {
+		mask bool
+		want cardData
+	}{
+		{
+			mask: false,
+			want: cardData{SSID: "Guest (5G)", Password: "correct horse", Security: "WPA/WPA2/WPA3", ValidUntil: "2026-10-31"},
+		},
+		{
+			mask: true,
+			want: cardData{SSID: "Guest (5G)", Password: "********", Security: "WPA/WPA2/WPA3", ValidUntil: "2026-10-31"},
+		},
+	}
+
+	for _, tt := range testcases {
+		t.Run("testing Card.data()", func(t *testing.T) {
+			card, _ := newTestCard(t, tt.mask)
+			if got := card.data(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Card.data() = %v; expected %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTemplate(t *testing.T) {
+	testcases := []struct {
+		src     string
+		wantErr error
+	}{
+		{
+			src:     `{"name": "x", "page": {"width": 10, "height": 10}, "card": {"width": 10, "height": 10}, "elements": [{"type": "text", "text": "{{.SSID}}", "font_size": 8}]}`,
+			wantErr: nil,
+		},
+		{
+			src:     `{"name": "x", "page": {"width": 10, "height": 10}, "card": {"width": 10, "height": 10}, "elements": [{"type": "circle"}]}`,
+			wantErr: errors.New("element 0 has unexpected type: circle"),
+		},
+		{
+			src:     `{"name": "x", "page": {"width": 10, "height": 10}, "card": {"width": 0, "height": 10}}`,
+			wantErr: errors.New("page and card sizes must be larger than 0"),
+		},
+		{
+			src:     `{"name": "x", "page": {"width": 10, "height": 10}, "card": {"width": 10, "height": 10}, "grid": {"columns": 10, "rows": 20}}`,
+			wantErr: nil,
+		},
+		{
+			src:     `{"name": "x", "page": {"width": 10, "height": 10}, "card": {"width": 10, "height": 10}, "grid": {"columns": 20, "rows": 20}}`,
+			wantErr: errors.New("grid must have at most 200 cards: given 20x20"),
+		},
+		{
+			// product overflows int
+			src:     `{"name": "x", "page": {"width": 10, "height": 10}, "card": {"width": 10, "height": 10}, "grid": {"columns": 4294967296, "rows": 4294967296}}`,
+			wantErr: errors.New("grid must have at most 200 cards: given 4294967296x4294967296"),
+		},
+		{
+			src:     `{"name": "x", "page": {"width": 10, "height": 10}, "card": {"width": 10, "height": 10}, "elements": [` + strings.Repeat(`{"type": "qr", "width": 5}, `, 50) + `{"type": "qr", "width": 5}]}`,
+			wantErr: errors.New("card must have at most 50 elements: given 51"),
+		},
+	}
+
+	for _, tt := range testcases {
+		t.Run("testing ParseTemplate()", func(t *testing.T) {
+			_, err := ParseTemplate(strings.NewReader(tt.src))
+			if (err == nil) != (tt.wantErr == nil) || (err != nil && err.Error() != tt.wantErr.Error()) {
+				t.Errorf("ParseTemplate() error = '%v'; expected '%v'", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTemplatePlacements(t *testing.T) {
+	testcases := []struct {
+		name      string
+		wantCount int
+		wantLast  placement
+	}{
+		{name: "a4-poster", wantCount: 1, wantLast: placement{x: 0, y: 0, flipped: false}},
+		{name: "table-tent", wantCount: 2, wantLast: placement{x: 0, y: 148.5, flipped: false}},
+		{name: "label-3x8", wantCount: 24, wantLast: placement{x: 140, y: 259.5, flipped: false}},
+	}
+
+	for _, tt := range testcases {
+		t.Run("testing Template.placements()", func(t *testing.T) {
+			tmpl, err := Get(tt.name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := tmpl.placements()
+			if len(got) != tt.wantCount || got[len(got)-1] != tt.wantLast {
+				t.Errorf("Template.placements() = %v; expected %d placements ending with %v", got, tt.wantCount, tt.wantLast)
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	card, code := newTestCard(t, true)
+
+	for _, name := range Names() {
+		for _, format := range []Format{SVGFormat, PDFFormat} {
+			t.Run("testing Render() for "+name+" as "+string(format), func(t *testing.T) {
+				tmpl, err := Get(name)
+				if err != nil {
+					t.Fatal(err)
+				}
+				buf := new(bytes.Buffer)
+				err = Render(buf, tmpl, card, code, format)
+				if err != nil {
+					t.Errorf("Render() error = '%v'; expected nil", err)
+				}
+				if strings.Contains(buf.String(), "correct horse") {
+					t.Errorf("Render() output contains masked password")
+				}
+			})
+		}
+	}
+}
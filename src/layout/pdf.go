@@ -0,0 +1,100 @@
+package layout
+
+import (
+	"image/color"
+
+	"github.com/pasca-l/wifi-qrcode-generator/qrcode"
+	"github.com/pasca-l/wifi-qrcode-generator/render"
+	"github.com/pasca-l/wifi-qrcode-generator/utils/pdf"
+)
+
+var black = color.Black
+
+func mmToPoints(mm float64) float64 {
+	return mm / mmPerPoint
+}
+
+// draws the composed card as a new page of the document, so that pages can be collected
+//
+//nolint:cyclop,gocognit
+func AddPDFPage(doc *pdf.Document, t Template, card Card, code qrcode.QRCode) error {
+	data := card.data()
+	pat := render.WithMargin(code.Pattern, quietZone)
+	rects := render.ModuleRects(pat)
+
+	var logo *pdf.Image
+	var logoWidth, logoHeight int
+	if card.Logo != nil {
+		img, err := card.Logo.Decode()
+		if err != nil {
+			return err
+		}
+		logo, err = doc.AddImage(img)
+		if err != nil {
+			return err
+		}
+		logoWidth, logoHeight = img.Bounds().Dx(), img.Bounds().Dy()
+	}
+
+	page := doc.AddPage(mmToPoints(t.Page.Width), mmToPoints(t.Page.Height))
+	cardWidth, cardHeight := mmToPoints(t.Card.Width), mmToPoints(t.Card.Height)
+	for _, p := range t.placements() {
+		// move origin to lower left corner of the card, as PDF has origin at lower left corner
+		x, y := mmToPoints(p.x), page.Height-mmToPoints(p.y)-cardHeight
+		page.SaveState()
+		if p.flipped {
+			// rotate around the card center
+			page.Transform(-1, 0, 0, -1, x+cardWidth, y+cardHeight)
+		} else {
+			page.Transform(1, 0, 0, 1, x, y)
+		}
+
+		for _, e := range t.Elements {
+			switch e.Type {
+			case QRElement:
+				module := mmToPoints(e.Width) / float64(len(pat))
+				top := cardHeight - mmToPoints(e.Y)
+				page.SetFillColor(black)
+				for _, r := range rects {
+					page.Rect(
+						mmToPoints(e.X)+float64(r.Min.X)*module,
+						top-float64(r.Max.Y)*module,
+						float64(r.Dx())*module,
+						float64(r.Dy())*module,
+					)
+				}
+				page.Fill()
+
+			case TextElement:
+				lines, err := executeText(e.Text, data)
+				if err != nil {
+					return err
+				}
+				font := pdf.Sans
+				if e.Bold {
+					font = pdf.SansBold
+				}
+				page.SetFillColor(black)
+				for i, line := range lines {
+					if line == "" {
+						continue
+					}
+					size, width := fitText(e, line)
+					baseline := cardHeight - mmToPoints(e.Y) - float64(i)*e.FontSize*lineHeight
+					page.Text(mmToPoints(e.X+alignOffset(e.Align, width)), baseline, font, size, line)
+				}
+
+			case LogoElement:
+				if logo == nil {
+					continue
+				}
+				lx, ly, lw, lh := fitLogo(e, logoWidth, logoHeight)
+				page.DrawImage(logo, mmToPoints(lx), cardHeight-mmToPoints(ly+lh), mmToPoints(lw), mmToPoints(lh))
+			}
+		}
+
+		page.RestoreState()
+	}
+
+	return nil
+}
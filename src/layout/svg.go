@@ -0,0 +1,81 @@
+package layout
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math"
+
+	svg "github.com/ajstarks/svgo"
+	"github.com/pasca-l/wifi-qrcode-generator/qrcode"
+	"github.com/pasca-l/wifi-qrcode-generator/render"
+)
+
+// user units per mm, as svgo only accepts integer coordinates
+const svgUnitsPerMM float64 = 10
+
+func svgUnits(mm float64) int {
+	return int(math.Round(mm * svgUnitsPerMM))
+}
+
+func renderSVG(w io.Writer, t Template, card Card, code qrcode.QRCode) error {
+	data := card.data()
+	pat := render.WithMargin(code.Pattern, quietZone)
+	path := render.ContourPath(pat)
+
+	s := svg.New(w)
+	s.Startraw(
+		fmt.Sprintf(`width="%gmm" height="%gmm"`, t.Page.Width, t.Page.Height),
+		fmt.Sprintf(`viewBox="0 0 %d %d"`, svgUnits(t.Page.Width), svgUnits(t.Page.Height)),
+	)
+	s.Rect(0, 0, svgUnits(t.Page.Width), svgUnits(t.Page.Height), `fill="#ffffff"`)
+
+	for _, p := range t.placements() {
+		transform := fmt.Sprintf("translate(%d %d)", svgUnits(p.x), svgUnits(p.y))
+		if p.flipped {
+			// rotate around the card center
+			transform = fmt.Sprintf("translate(%d %d) rotate(180)", svgUnits(p.x+t.Card.Width), svgUnits(p.y+t.Card.Height))
+		}
+		s.Gtransform(transform)
+
+		for _, e := range t.Elements {
+			switch e.Type {
+			case QRElement:
+				module := e.Width * svgUnitsPerMM / float64(len(pat))
+				s.Gtransform(fmt.Sprintf("translate(%d %d) scale(%g)", svgUnits(e.X), svgUnits(e.Y), module))
+				s.Path(path, `fill="#000000" shape-rendering="crispEdges"`)
+				s.Gend()
+
+			case TextElement:
+				lines, err := executeText(e.Text, data)
+				if err != nil {
+					return err
+				}
+				for i, line := range lines {
+					if line == "" {
+						continue
+					}
+					size, width := fitText(e, line)
+					style := fmt.Sprintf(`font-family="'DejaVu Sans', Verdana, sans-serif" font-size="%d" fill="#000000"`, svgUnits(size*mmPerPoint))
+					if e.Bold {
+						style += ` font-weight="bold"`
+					}
+					y := e.Y + float64(i)*e.FontSize*lineHeight*mmPerPoint
+					s.Text(svgUnits(e.X+alignOffset(e.Align, width)), svgUnits(y), line, style)
+				}
+
+			case LogoElement:
+				if card.Logo == nil {
+					continue
+				}
+				link := fmt.Sprintf("data:%s;base64,%s", card.Logo.MIMEType, base64.StdEncoding.EncodeToString(card.Logo.Data))
+				s.Image(svgUnits(e.X), svgUnits(e.Y), svgUnits(e.Width), svgUnits(e.Height), link)
+			}
+		}
+
+		s.Gend()
+	}
+	s.End()
+
+	return nil
+}
@@ -0,0 +1,214 @@
+package layout
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"strings"
+	"text/template"
+)
+
+// description of a printable layout, in mm with origin at upper left corner
+// a card is repeated on the page according to the grid, such as label sheets
+type Template struct {
+	Name     string    `json:"name"`
+	Page     Size      `json:"page"`
+	Card     Size      `json:"card"`
+	Grid     Grid      `json:"grid"`
+	Elements []Element `json:"elements"`
+}
+
+type Size struct {
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+type Grid struct {
+	Columns     int     `json:"columns"`      // defaults to 1
+	Rows        int     `json:"rows"`         // defaults to 1
+	Left        float64 `json:"left"`         // offset of the first card from the left page edge
+	Top         float64 `json:"top"`          // offset of the first card from the top page edge
+	ColumnPitch float64 `json:"column_pitch"` // distance between left edges of adjacent cards
+	RowPitch    float64 `json:"row_pitch"`    // distance between top edges of adjacent cards
+	FlippedRows []int   `json:"flipped_rows"` // rows rotated by 180 degrees, such as for folded table tents
+}
+
+type ElementType string
+
+const (
+	QRElement   ElementType = "qr"
+	TextElement ElementType = "text"
+	LogoElement ElementType = "logo"
+)
+
+type Align string
+
+const (
+	AlignLeft   Align = "left"
+	AlignCenter Align = "center"
+	AlignRight  Align = "right"
+)
+
+type Element struct {
+	Type     ElementType `json:"type"`
+	X        float64     `json:"x"`         // left edge, or anchor of aligned text
+	Y        float64     `json:"y"`         // top edge, or baseline of the first text line
+	Width    float64     `json:"width"`     // size of square QR code, or maximum width of text
+	Height   float64     `json:"height"`    // only used for logo
+	Text     string      `json:"text"`      // text/template executed with card fields
+	FontSize float64     `json:"font_size"` // in points
+	Bold     bool        `json:"bold"`
+	Align    Align       `json:"align"`
+}
+
+//go:embed templates/*.json
+var builtinFS embed.FS
+
+// built-in templates, keyed by name
+var builtins = loadBuiltins()
+
+func loadBuiltins() map[string]Template {
+	templates := make(map[string]Template)
+	entries, _ := builtinFS.ReadDir("templates")
+	for _, entry := range entries {
+		f, err := builtinFS.Open(path.Join("templates", entry.Name()))
+		if err != nil {
+			panic(err)
+		}
+		// built-in templates are tested, so that panic would only occur on development
+		t, err := ParseTemplate(f)
+		if err != nil {
+			panic(fmt.Sprintf("invalid built-in template %s: %v", entry.Name(), err))
+		}
+		templates[t.Name] = t
+	}
+	return templates
+}
+
+func Get(name string) (Template, error) {
+	if t, exists := builtins[name]; exists {
+		return t, nil
+	}
+	return Template{}, fmt.Errorf("template with name: %s, does not exist", name)
+}
+
+// returns sorted names of built-in templates
+func Names() []string {
+	names := make([]string, 0, len(builtins))
+	for name := range builtins {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// parses user-defined template from JSON description
+func ParseTemplate(r io.Reader) (Template, error) {
+	var t Template
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	err := dec.Decode(&t)
+	if err != nil {
+		return Template{}, fmt.Errorf("cannot parse template: %w", err)
+	}
+
+	err = t.Validate()
+	if err != nil {
+		return Template{}, err
+	}
+	return t, nil
+}
+
+// upper bounds of user-defined templates, each card rendering its own code and text
+const (
+	maxCards    int = 200 // more than any label sheet
+	maxElements int = 50
+)
+
+//nolint:cyclop
+func (t Template) Validate() error {
+	if t.Page.Width <= 0 || t.Page.Height <= 0 || t.Card.Width <= 0 || t.Card.Height <= 0 {
+		return fmt.Errorf("page and card sizes must be larger than 0")
+	}
+	if t.Grid.Columns < 0 || t.Grid.Rows < 0 {
+		return fmt.Errorf("grid columns and rows must not be negative")
+	}
+	// columns and rows are bounded first, so that their product does not overflow
+	columns, rows := max(t.Grid.Columns, 1), max(t.Grid.Rows, 1)
+	if columns > maxCards || rows > maxCards || columns*rows > maxCards {
+		return fmt.Errorf("grid must have at most %d cards: given %dx%d", maxCards, columns, rows)
+	}
+	if len(t.Elements) > maxElements {
+		return fmt.Errorf("card must have at most %d elements: given %d", maxElements, len(t.Elements))
+	}
+
+	for i, e := range t.Elements {
+		switch e.Type {
+		case QRElement, LogoElement:
+			if e.Width <= 0 || (e.Type == LogoElement && e.Height <= 0) {
+				return fmt.Errorf("element %d of type %s must have size larger than 0", i, e.Type)
+			}
+		case TextElement:
+			if e.FontSize <= 0 {
+				return fmt.Errorf("element %d of type %s must have font size larger than 0", i, e.Type)
+			}
+			_, err := parseText(e.Text)
+			if err != nil {
+				return fmt.Errorf("element %d of type %s: %w", i, e.Type, err)
+			}
+		default:
+			return fmt.Errorf("element %d has unexpected type: %s", i, e.Type)
+		}
+
+		switch e.Align {
+		case "", AlignLeft, AlignCenter, AlignRight:
+		default:
+			return fmt.Errorf("element %d has unexpected align: %s", i, e.Align)
+		}
+	}
+
+	return nil
+}
+
+func parseText(text string) (*template.Template, error) {
+	return template.New("text").Option("missingkey=error").Parse(text)
+}
+
+// executes text template, returning each line of the result
+func executeText(text string, data cardData) ([]string, error) {
+	tmpl, err := parseText(text)
+	if err != nil {
+		return nil, err
+	}
+	buf := new(strings.Builder)
+	err = tmpl.Execute(buf, data)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Split(strings.TrimRight(buf.String(), "\n"), "\n"), nil
+}
+
+// position of a card on the page
+type placement struct {
+	x       float64
+	y       float64
+	flipped bool
+}
+
+func (t Template) placements() []placement {
+	columns, rows := max(t.Grid.Columns, 1), max(t.Grid.Rows, 1)
+	placements := make([]placement, 0, columns*rows)
+	for row := range rows {
+		for col := range columns {
+			placements = append(placements, placement{
+				x:       t.Grid.Left + float64(col)*t.Grid.ColumnPitch,
+				y:       t.Grid.Top + float64(row)*t.Grid.RowPitch,
+				flipped: slices.Contains(t.Grid.FlippedRows, row),
+			})
+		}
+	}
+	return placements
+}
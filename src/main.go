@@ -1,13 +1,26 @@
 package main
 
 import (
+	"context"
 	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/pasca-l/wifi-qrcode-generator/server"
 )
 
 func main() {
-	err := server.Serve()
+	// logs are structured for log collectors, including those of the standard logger
+	slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stderr, nil)))
+	config, err := server.LoadConfig(os.Args[1:], os.Getenv)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	err = server.Serve(ctx, config)
+	stop()
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -0,0 +1,159 @@
+package passphrase
+
+import (
+	"crypto/rand"
+	_ "embed"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+
+	"github.com/pasca-l/wifi-qrcode-generator/qrcode"
+)
+
+type Mode string
+
+const (
+	DicewareMode  Mode = "diceware"  // words drawn from wordlist, joined by hyphens
+	PrintableMode Mode = "printable" // printable ASCII characters, except space
+	HexMode       Mode = "hex"       // 64 hex digits, used as raw PSK instead of passphrase
+)
+
+func ParseMode(s string) (Mode, error) {
+	switch Mode(s) {
+	case DicewareMode, PrintableMode, HexMode:
+		return Mode(s), nil
+	default:
+		return Mode(""), fmt.Errorf("cannot convert value '%s' to type Mode", s)
+	}
+}
+
+// length of WPA passphrases, allowed by IEEE 802.11i
+const (
+	MinLength int = 8
+	MaxLength int = 63
+)
+
+// digits of raw PSK, encoding 256 bits
+const hexLength int = 64
+
+// words of diceware passphrases, bounded to fit WPA passphrase length
+const (
+	minWords int = 3
+	maxWords int = 9
+)
+
+const wordSeparator string = "-"
+
+// words of 3 to 6 lowercase letters, as many as 4 dice rolls select
+//
+//go:embed wordlist.txt
+var wordlistFile string
+
+var wordlist = strings.Fields(wordlistFile)
+
+// characters read one for another when printed, such as on posters
+// referenced: https://github.com/tytso/pwgen
+const lookAlikes string = "B8G6I1l|0OQDS5Z2`'"
+
+type Policy struct {
+	Mode           Mode
+	Length         int  // words of diceware, characters of printable, default of mode if 0, and fixed for hex
+	AvoidSpecial   bool // avoids characters escaped in WIFI: codes, so that passphrases are easier to type
+	AvoidLookAlike bool // avoids characters read one for another, so that printed passphrases can be typed by hand
+}
+
+// policy of passphrases rotated by default
+func DefaultPolicy() Policy {
+	return Policy{Mode: PrintableMode, AvoidSpecial: true, AvoidLookAlike: true}
+}
+
+func (p Policy) length() int {
+	if p.Length != 0 {
+		return p.Length
+	}
+	switch p.Mode {
+	case DicewareMode:
+		return 6
+	case HexMode:
+		return hexLength
+	default:
+		return 16
+	}
+}
+
+// returns characters drawn by printable mode, where hex mode is not affected by policy
+func (p Policy) alphabet() string {
+	var b strings.Builder
+	for c := byte('!'); c <= '~'; c++ {
+		if p.AvoidSpecial && strings.IndexByte(qrcode.SpecialCharacters, c) >= 0 {
+			continue
+		}
+		if p.AvoidLookAlike && strings.IndexByte(lookAlikes, c) >= 0 {
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+func (p Policy) Validate() error {
+	switch p.Mode {
+	case DicewareMode:
+		if p.length() < minWords || p.length() > maxWords {
+			return fmt.Errorf("words must be between %d and %d: given %d", minWords, maxWords, p.length())
+		}
+	case PrintableMode:
+		if p.length() < MinLength || p.length() > MaxLength {
+			return fmt.Errorf("length must be between %d and %d: given %d", MinLength, MaxLength, p.length())
+		}
+	case HexMode:
+		if p.length() != hexLength {
+			return fmt.Errorf("hex PSK must have %d digits: given %d", hexLength, p.length())
+		}
+	default:
+		return fmt.Errorf("cannot convert value '%s' to type Mode", p.Mode)
+	}
+	return nil
+}
+
+// returns entropy of passphrases generated under policy in bits, as every symbol is drawn uniformly
+func (p Policy) Entropy() float64 {
+	switch p.Mode {
+	case DicewareMode:
+		return float64(p.length()) * math.Log2(float64(len(wordlist)))
+	case HexMode:
+		return float64(p.length()) * 4
+	default:
+		return float64(p.length()) * math.Log2(float64(len(p.alphabet())))
+	}
+}
+
+// generates passphrase under policy, drawing symbols uniformly by crypto/rand
+func Generate(p Policy) (string, error) {
+	err := p.Validate()
+	if err != nil {
+		return "", err
+	}
+
+	var symbols []string
+	var separator string
+	switch p.Mode {
+	case DicewareMode:
+		symbols, separator = wordlist, wordSeparator
+	case HexMode:
+		symbols = strings.Split("0123456789abcdef", "")
+	default:
+		symbols = strings.Split(p.alphabet(), "")
+	}
+
+	parts := make([]string, p.length())
+	for i := range parts {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(symbols))))
+		if err != nil {
+			return "", err
+		}
+		parts[i] = symbols[n.Int64()]
+	}
+	return strings.Join(parts, separator), nil
+}
@@ -0,0 +1,101 @@
+package passphrase
+
+import (
+	"errors"
+	"math"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/pasca-l/wifi-qrcode-generator/qrcode"
+)
+
+func TestWordlist(t *testing.T) {
+	// 4 dice rolls select a word
+	if len(wordlist) != 6*6*6*6 {
+		t.Errorf("wordlist has %d words; expected %d", len(wordlist), 6*6*6*6)
+	}
+	seen := make(map[string]bool)
+	for _, word := range wordlist {
+		if !regexp.MustCompile(`^[a-z]{3,6}$`).MatchString(word) {
+			t.Errorf("word '%s' is not of 3 to 6 lowercase letters", word)
+		}
+		if seen[word] {
+			t.Errorf("word '%s' is duplicated", word)
+		}
+		seen[word] = true
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	testcases := []struct {
+		policy  Policy
+		want    *regexp.Regexp
+		wantErr error
+	}{
+		{policy: DefaultPolicy(), want: regexp.MustCompile(`^[!-~]{16}$`), wantErr: nil},
+		{policy: Policy{Mode: PrintableMode, Length: 63}, want: regexp.MustCompile(`^[!-~]{63}$`), wantErr: nil},
+		{policy: Policy{Mode: DicewareMode}, want: regexp.MustCompile(`^[a-z]{3,6}(-[a-z]{3,6}){5}$`), wantErr: nil},
+		{policy: Policy{Mode: DicewareMode, Length: 9}, want: regexp.MustCompile(`^[a-z]{3,6}(-[a-z]{3,6}){8}$`), wantErr: nil},
+		{policy: Policy{Mode: HexMode}, want: regexp.MustCompile(`^[0-9a-f]{64}$`), wantErr: nil},
+		{policy: Policy{Mode: PrintableMode, Length: 7}, wantErr: errors.New("length must be between 8 and 63: given 7")},
+		{policy: Policy{Mode: DicewareMode, Length: 2}, wantErr: errors.New("words must be between 3 and 9: given 2")},
+		{policy: Policy{Mode: HexMode, Length: 32}, wantErr: errors.New("hex PSK must have 64 digits: given 32")},
+		{policy: Policy{Mode: "base64"}, wantErr: errors.New("cannot convert value 'base64' to type Mode")},
+	}
+
+	for _, tt := range testcases {
+		t.Run("testing Generate()", func(t *testing.T) {
+			got, err := Generate(tt.policy)
+			if (err == nil) != (tt.wantErr == nil) || (err != nil && err.Error() != tt.wantErr.Error()) {
+				t.Fatalf("Generate() error = '%v'; expected '%v'", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if !tt.want.MatchString(got) {
+				t.Errorf("Generate() = %s; expected to match %s", got, tt.want)
+			}
+			if len(got) < MinLength || (tt.policy.Mode != HexMode && len(got) > MaxLength) {
+				t.Errorf("Generate() = %s; expected length of WPA passphrase", got)
+			}
+		})
+	}
+}
+
+func TestGenerateAvoid(t *testing.T) {
+	policy := Policy{Mode: PrintableMode, Length: MaxLength, AvoidSpecial: true, AvoidLookAlike: true}
+	// enough passphrases to draw every character of alphabet
+	for range 100 {
+		got, err := Generate(policy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.ContainsAny(got, qrcode.SpecialCharacters) || strings.ContainsAny(got, lookAlikes) {
+			t.Fatalf("Generate() = %s; expected no special or look-alike characters", got)
+		}
+	}
+}
+
+func TestPolicyEntropy(t *testing.T) {
+	testcases := []struct {
+		policy Policy
+		want   float64
+	}{
+		// 94 printable characters
+		{policy: Policy{Mode: PrintableMode, Length: 10}, want: 10 * math.Log2(94)},
+		// 5 special and 18 look-alike characters avoided
+		{policy: DefaultPolicy(), want: 16 * math.Log2(94-5-18)},
+		{policy: Policy{Mode: DicewareMode}, want: 6 * math.Log2(1296)},
+		{policy: Policy{Mode: HexMode}, want: 256},
+	}
+
+	for _, tt := range testcases {
+		t.Run("testing Policy.Entropy()", func(t *testing.T) {
+			got := tt.policy.Entropy()
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Policy.Entropy() = %f; expected %f", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package profile
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/pasca-l/wifi-qrcode-generator/qrcode"
+)
+
+// network in the style of android.net.wifi.WifiConfiguration, where strings are quoted
+// to tell them apart from hex values
+// referenced: https://developer.android.com/reference/android/net/wifi/WifiConfiguration
+type androidNetwork struct {
+	SSID                 string                   `json:"SSID"`
+	HiddenSSID           bool                     `json:"hiddenSSID"`
+	AllowedKeyManagement []string                 `json:"allowedKeyManagement"`
+	PreSharedKey         string                   `json:"preSharedKey,omitempty"`
+	WEPKeys              []string                 `json:"wepKeys,omitempty"`
+	EnterpriseConfig     *androidEnterpriseConfig `json:"enterpriseConfig,omitempty"`
+}
+
+// referenced: https://developer.android.com/reference/android/net/wifi/WifiEnterpriseConfig
+type androidEnterpriseConfig struct {
+	EAPMethod         string `json:"eapMethod"`
+	Phase2Method      string `json:"phase2Method"`
+	Identity          string `json:"identity,omitempty"`
+	AnonymousIdentity string `json:"anonymousIdentity,omitempty"`
+	Password          string `json:"password,omitempty"`
+}
+
+// writes network as JSON, for provisioning tools of Android devices
+func WriteAndroid(w io.Writer, spec qrcode.WifiSpec) error {
+	n := androidNetwork{
+		SSID:       strconv.Quote(spec.SSID()),
+		HiddenSSID: spec.Hidden(),
+	}
+
+	switch spec.Encryption() {
+	case qrcode.NoPass:
+		n.AllowedKeyManagement = []string{"NONE"}
+	case qrcode.WEP:
+		n.AllowedKeyManagement = []string{"NONE"}
+		n.WEPKeys = []string{androidKey(spec.Password(), isWEPHexKey(spec.Password()))}
+	case qrcode.WPA:
+		n.AllowedKeyManagement = []string{"WPA_PSK"}
+		n.PreSharedKey = androidKey(spec.Password(), spec.HexPSK())
+	case qrcode.WPA2EAP:
+		eap := spec.EAP()
+		phase2 := eap.Phase2
+		if phase2 == "" {
+			phase2 = qrcode.NoPhase2
+		}
+		n.AllowedKeyManagement = []string{"WPA_EAP", "IEEE8021X"}
+		n.EnterpriseConfig = &androidEnterpriseConfig{
+			EAPMethod:         string(eap.Method),
+			Phase2Method:      string(phase2),
+			Identity:          eap.Identity,
+			AnonymousIdentity: eap.AnonymousIdentity,
+			Password:          spec.Password(),
+		}
+	default:
+		return fmt.Errorf("unexpected encryption: %s", spec.Encryption())
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(n)
+}
+
+// quotes key unless it is written in hex
+func androidKey(key string, isHex bool) string {
+	if isHex {
+		return key
+	}
+	return strconv.Quote(key)
+}
+
+// WEP keys of 40, 104 and 128 bits in hex
+func isWEPHexKey(key string) bool {
+	switch len(key) {
+	case 10, 26, 32:
+		return isHexString(key)
+	default:
+		return false
+	}
+}
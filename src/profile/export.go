@@ -0,0 +1,71 @@
+package profile
+
+import (
+	"crypto/sha1" //nolint:gosec // only used to derive stable identifiers
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pasca-l/wifi-qrcode-generator/qrcode"
+)
+
+// writes network as a profile to be installed on devices without camera
+// signer is only used for mobileconfig, and leaves the profile unsigned if nil
+func Export(w io.Writer, spec qrcode.WifiSpec, format Format, signer *Signer) error {
+	if signer != nil && format != Mobileconfig {
+		return fmt.Errorf("only mobileconfig profiles can be signed")
+	}
+
+	switch format {
+	case NetworkManager:
+		return WriteNetworkManager(w, spec)
+	case WindowsWLAN:
+		return WriteWLANProfile(w, spec)
+	case Mobileconfig:
+		return WriteMobileconfig(w, spec, signer)
+	case Android:
+		return WriteAndroid(w, spec)
+	default:
+		return fmt.Errorf("profile format cannot be exported: %s", format)
+	}
+}
+
+func (f Format) MIMEType() string {
+	switch f {
+	case WindowsWLAN:
+		return "application/xml"
+	case Mobileconfig:
+		return "application/x-apple-aspen-config"
+	case Android:
+		return "application/json"
+	default:
+		return "text/plain"
+	}
+}
+
+// returns file name of exported profile, named after the network
+func (f Format) FileName(ssid string) string {
+	// path separators are replaced, so that names cannot point to other directories
+	name := strings.NewReplacer("/", "_", "\\", "_").Replace(ssid)
+	switch f {
+	case NetworkManager:
+		return name + ".nmconnection"
+	case WindowsWLAN:
+		return name + ".xml"
+	case Mobileconfig:
+		return name + ".mobileconfig"
+	case Android:
+		return name + ".json"
+	default:
+		return name + ".conf"
+	}
+}
+
+// derives UUID from the given names in the layout of version 5, so that exporting the same
+// network again replaces the installed profile instead of adding another one
+func nameUUID(names ...string) string {
+	sum := sha1.Sum([]byte(strings.Join(names, "\x00"))) //nolint:gosec // not used for security
+	sum[6] = sum[6]&0x0f | 0x50
+	sum[8] = sum[8]&0x3f | 0x80
+	return fmt.Sprintf("%X-%X-%X-%X-%X", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
@@ -0,0 +1,172 @@
+package profile
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"math/big"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/pasca-l/wifi-qrcode-generator/qrcode"
+)
+
+func newTestSpec(t *testing.T, params url.Values) qrcode.WifiSpec {
+	t.Helper()
+	spec, err := qrcode.NewWifiSpec(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return spec
+}
+
+func TestExport(t *testing.T) {
+	wpa := url.Values{"ssid": {"Home; Office"}, "password": {`pass\word`}, "encryption": {"WPA"}, "hidden": {"true"}}
+	wep := url.Values{"ssid": {" Lobby"}, "password": {"0123456789"}, "encryption": {"WEP"}}
+	ttls := url.Values{
+		"ssid": {"Corp"}, "password": {"alice password"}, "encryption": {"WPA2-EAP"},
+		"eap_method": {"TTLS"}, "phase2": {"PAP"}, "identity": {"alice"}, "anonymous_identity": {"anonymous"},
+	}
+	peap := url.Values{
+		"ssid": {"Corp"}, "password": {"alice password"}, "encryption": {"WPA2-EAP"},
+		"eap_method": {"PEAP"}, "phase2": {"MSCHAPV2"}, "identity": {"alice"},
+	}
+	pwd := url.Values{"ssid": {"Corp"}, "encryption": {"WPA2-EAP"}, "eap_method": {"PWD"}}
+
+	testcases := []struct {
+		format  Format
+		params  url.Values
+		want    string // encoded spec imported back from the profile
+		wantErr error
+	}{
+		{format: NetworkManager, params: wpa, want: `WIFI:T:WPA;S:"Home\; Office";P:"pass\\word";H:true;;`},
+		{format: NetworkManager, params: wep, want: `WIFI:T:WEP;S:" Lobby";P:"0123456789";;`},
+		{format: NetworkManager, params: ttls, want: `WIFI:T:WPA2-EAP;S:"Corp";P:"alice password";E:TTLS;A:"anonymous";I:"alice";PH2:PAP;;`},
+		{format: WindowsWLAN, params: wpa, want: `WIFI:T:WPA;S:"Home\; Office";P:"pass\\word";H:true;;`},
+		// credentials are not stored in WLAN profiles
+		{format: WindowsWLAN, params: peap, want: `WIFI:T:WPA2-EAP;S:"Corp";P:"";E:PEAP;PH2:MSCHAPV2;;`},
+		{format: WindowsWLAN, params: ttls, want: `WIFI:T:WPA2-EAP;S:"Corp";P:"";E:TTLS;PH2:PAP;;`},
+		{format: WindowsWLAN, params: pwd, wantErr: errors.New("EAP method PWD cannot be exported to WLAN profile")},
+		{format: Mobileconfig, params: wpa, want: `WIFI:T:WPA;S:"Home\; Office";P:"pass\\word";H:true;;`},
+		{format: Mobileconfig, params: ttls, want: `WIFI:T:WPA2-EAP;S:"Corp";P:"alice password";E:TTLS;A:"anonymous";I:"alice";PH2:PAP;;`},
+		{format: Mobileconfig, params: pwd, wantErr: errors.New("EAP method PWD cannot be exported to mobileconfig")},
+		{format: Hostapd, params: wpa, wantErr: errors.New("profile format cannot be exported: hostapd")},
+	}
+
+	for _, tt := range testcases {
+		t.Run("testing Export() to "+string(tt.format), func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			err := Export(buf, newTestSpec(t, tt.params), tt.format, nil)
+			if (err == nil) != (tt.wantErr == nil) || (err != nil && err.Error() != tt.wantErr.Error()) {
+				t.Fatalf("Export() error = '%v'; expected '%v'", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			specs, err := Import(buf, tt.format)
+			if err != nil {
+				t.Fatalf("Import() error = '%v'; of exported profile:\n%s", err, buf)
+			}
+			if len(specs) != 1 || specs[0].Encode() != tt.want {
+				t.Errorf("Import() of exported profile = %v; expected %s", specs, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteAndroid(t *testing.T) {
+	params := url.Values{
+		"ssid": {"Corp"}, "password": {"alice password"}, "encryption": {"WPA2-EAP"},
+		"eap_method": {"PEAP"}, "identity": {"alice"},
+	}
+	want := `{
+  "SSID": "\"Corp\"",
+  "hiddenSSID": false,
+  "allowedKeyManagement": [
+    "WPA_EAP",
+    "IEEE8021X"
+  ],
+  "enterpriseConfig": {
+    "eapMethod": "PEAP",
+    "phase2Method": "NONE",
+    "identity": "alice",
+    "password": "alice password"
+  }
+}
+`
+
+	buf := new(bytes.Buffer)
+	err := WriteAndroid(buf, newTestSpec(t, params))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != want {
+		t.Errorf("WriteAndroid() = %s; expected %s", got, want)
+	}
+}
+
+func TestSignerSign(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "profile signer"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content := []byte("<plist/>")
+	signed, err := (&Signer{Certificate: cert, Key: key}).Sign(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("testing Signer.Sign()", func(t *testing.T) {
+		var ci contentInfo
+		_, err := asn1.Unmarshal(signed, &ci)
+		if err != nil || !ci.ContentType.Equal(oidSignedData) {
+			t.Fatalf("Sign() is not signed data: %v", err)
+		}
+		var sd signedData
+		_, err = asn1.Unmarshal(ci.Content.Bytes, &sd)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(sd.EncapContentInfo.EContent, content) {
+			t.Errorf("Sign() content = %q; expected %q", sd.EncapContentInfo.EContent, content)
+		}
+
+		// signature covers signed attributes, which include digest of content
+		si := sd.SignerInfos[0]
+		attrs, err := asn1.Marshal(asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: si.SignedAttrs.Bytes})
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = cert.CheckSignature(x509.ECDSAWithSHA256, attrs, si.Signature)
+		if err != nil {
+			t.Errorf("Sign() signature is invalid: %v", err)
+		}
+		digest := sha256.Sum256(content)
+		if !bytes.Contains(si.SignedAttrs.Bytes, digest[:]) {
+			t.Errorf("Sign() signed attributes do not contain digest of content")
+		}
+	})
+}
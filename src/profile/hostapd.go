@@ -0,0 +1,115 @@
+package profile
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pasca-l/wifi-qrcode-generator/qrcode"
+)
+
+// parses hostapd.conf, with a network for the main interface and each bss= section
+// referenced: https://w1.fi/cgit/hostap/plain/hostapd/hostapd.conf
+func ParseHostapd(r io.Reader) ([]qrcode.WifiSpec, error) {
+	blocks := []map[string]string{make(map[string]string)}
+	lines := []map[string]int{make(map[string]int)} // line of each key in blocks, for errors
+	scanner := bufio.NewScanner(r)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		// values are taken as is, as SSIDs and passphrases may have spaces at both ends
+		line := strings.TrimLeft(scanner.Text(), " \t")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, found := splitKeyValue(line)
+		if !found {
+			return nil, fmt.Errorf("line %d: expected key=value", lineNum)
+		}
+		if key == "bss" {
+			blocks = append(blocks, make(map[string]string))
+			lines = append(lines, make(map[string]int))
+		}
+		blocks[len(blocks)-1][key] = value
+		lines[len(lines)-1][key] = lineNum
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	specs := make([]qrcode.WifiSpec, 0, len(blocks))
+	for i, block := range blocks {
+		spec, err := hostapdNetwork(block, lines[i])
+		if err != nil {
+			if i == 0 {
+				return nil, err
+			}
+			return nil, fmt.Errorf("bss %s: %w", block["bss"], err)
+		}
+		specs = append(specs, spec)
+	}
+	return specs, nil
+}
+
+func hostapdNetwork(block map[string]string, lines map[string]int) (qrcode.WifiSpec, error) {
+	ssid := block["ssid"]
+	if ssid2, exists := block["ssid2"]; exists {
+		var err error
+		ssid, err = decodeString(ssid2)
+		if err != nil {
+			return qrcode.WifiSpec{}, fieldError("ssid2", lines["ssid2"], err)
+		}
+	}
+	n := network{
+		ssid:       ssid,
+		encryption: qrcode.NoPass,
+		// 1 sends empty SSID and 2 clears SSID in beacons, both hiding the network
+		hidden: block["ignore_broadcast_ssid"] == "1" || block["ignore_broadcast_ssid"] == "2",
+	}
+
+	if block["ieee8021x"] == "1" {
+		return qrcode.WifiSpec{}, errEAP
+	}
+
+	wpa, _ := strconv.Atoi(block["wpa"])
+	if wpa != 0 {
+		keyMgmt, exists := block["wpa_key_mgmt"]
+		if !exists {
+			keyMgmt = "WPA-PSK"
+		}
+		mgmts := strings.Fields(keyMgmt)
+		if !hasAny(mgmts, "WPA-PSK", "SAE", "FT-PSK", "FT-SAE", "WPA-PSK-SHA256") {
+			if hasAny(mgmts, "WPA-EAP", "FT-EAP", "WPA-EAP-SHA256") {
+				return qrcode.WifiSpec{}, errEAP
+			}
+			return qrcode.WifiSpec{}, fmt.Errorf("unexpected wpa_key_mgmt: %s", keyMgmt)
+		}
+
+		n.encryption = qrcode.WPA
+		n.password = block["wpa_passphrase"]
+		if n.password == "" {
+			n.password = block["sae_password"]
+		}
+		// PSK in hex is encoded unquoted, when passphrase is not kept
+		if n.password == "" {
+			n.password = block["wpa_psk"]
+		}
+		return n.spec()
+	}
+
+	index := block["wep_default_key"]
+	if index == "" {
+		index = "0"
+	}
+	if key, exists := block["wep_key"+index]; exists {
+		n.encryption = qrcode.WEP
+		var err error
+		n.password, err = decodeWEPKey(key)
+		if err != nil {
+			return qrcode.WifiSpec{}, fieldError("wep_key"+index, lines["wep_key"+index], err)
+		}
+	}
+
+	return n.spec()
+}
@@ -0,0 +1,382 @@
+package profile
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pasca-l/wifi-qrcode-generator/qrcode"
+)
+
+// payload type of Wi-Fi settings in configuration profiles
+const wifiPayloadType string = "com.apple.wifi.managed"
+
+// parses every Wi-Fi payload of Apple configuration profile in XML plist
+// referenced: https://developer.apple.com/documentation/devicemanagement/wifi
+func ParseMobileconfig(r io.Reader) ([]qrcode.WifiSpec, error) {
+	br := bufio.NewReader(r)
+	head, _ := br.Peek(8)
+	switch {
+	case bytes.HasPrefix(head, []byte("bplist")):
+		return nil, fmt.Errorf("binary plist is not supported: convert with plutil -convert xml1")
+	case len(head) > 0 && head[0] == 0x30:
+		// DER encoded CMS envelope starts with SEQUENCE tag
+		return nil, fmt.Errorf("signed profile is not supported: export unsigned profile")
+	}
+
+	root, err := decodePlist(br)
+	if err != nil {
+		return nil, err
+	}
+	dict, ok := root.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("profile must be a dict")
+	}
+
+	// payload may be a profile with contents, or a single payload
+	payloads := []any{dict}
+	if content, ok := dict["PayloadContent"].([]any); ok {
+		payloads = content
+	}
+
+	specs := make([]qrcode.WifiSpec, 0)
+	for i, p := range payloads {
+		payload, ok := p.(map[string]any)
+		if !ok || payload["PayloadType"] != wifiPayloadType {
+			continue
+		}
+		spec, err := mobileconfigNetwork(payload)
+		if err != nil {
+			return nil, fmt.Errorf("payload %d: %w", i+1, err)
+		}
+		specs = append(specs, spec)
+	}
+	if len(specs) == 0 {
+		return nil, fmt.Errorf("no %s payloads found", wifiPayloadType)
+	}
+	return specs, nil
+}
+
+func mobileconfigNetwork(payload map[string]any) (qrcode.WifiSpec, error) {
+	ssid, _ := payload["SSID_STR"].(string)
+	if ssid == "" && payload["IsHotspot"] == true {
+		return qrcode.WifiSpec{}, fmt.Errorf("hotspot 2.0 (Passpoint) network without SSID cannot be expressed in WIFI: code")
+	}
+	hidden, _ := payload["HIDDEN_NETWORK"].(bool)
+	password, _ := payload["Password"].(string)
+	n := network{ssid: ssid, password: password, hidden: hidden}
+
+	eap, hasEAP := payload["EAPClientConfiguration"].(map[string]any)
+	encryptionType, _ := payload["EncryptionType"].(string)
+	switch encryptionType {
+	case "None":
+		n.encryption = qrcode.NoPass
+	case "WEP":
+		n.encryption = qrcode.WEP
+	case "WPA", "WPA2", "WPA3", "Any":
+		n.encryption = qrcode.WPA
+		if password == "" && encryptionType == "Any" && !hasEAP {
+			// any security type is accepted, while no password is given
+			n.encryption = qrcode.NoPass
+		}
+	default:
+		return qrcode.WifiSpec{}, fmt.Errorf("encryption type '%s' cannot be expressed in WIFI: code", encryptionType)
+	}
+
+	if hasEAP {
+		if n.encryption == qrcode.WEP {
+			return qrcode.WifiSpec{}, fmt.Errorf("dynamic WEP with 802.1X cannot be expressed in WIFI: code")
+		}
+		n.encryption = qrcode.WPA2EAP
+		var err error
+		n.password, n.eap, err = mobileconfigEAP(eap)
+		if err != nil {
+			return qrcode.WifiSpec{}, err
+		}
+	}
+
+	return n.spec()
+}
+
+// returns user password and EAP settings, using the first of accepted methods
+func mobileconfigEAP(eap map[string]any) (string, qrcode.EAPSpec, error) {
+	types, _ := eap["AcceptEAPTypes"].([]any)
+	if len(types) == 0 {
+		return "", qrcode.EAPSpec{}, fmt.Errorf("AcceptEAPTypes is not set")
+	}
+	eapType, ok := types[0].(int64)
+	if !ok {
+		return "", qrcode.EAPSpec{}, fmt.Errorf("AcceptEAPTypes must be integers")
+	}
+	name, known := eapTypes[strconv.FormatInt(eapType, 10)]
+	if !known {
+		return "", qrcode.EAPSpec{}, fmt.Errorf("EAP method type %d cannot be expressed in WIFI: code", eapType)
+	}
+	method, err := toEAPMethod(name)
+	if err != nil {
+		return "", qrcode.EAPSpec{}, err
+	}
+
+	spec := qrcode.EAPSpec{Method: method}
+	spec.Identity, _ = eap["UserName"].(string)
+	spec.AnonymousIdentity, _ = eap["OuterIdentity"].(string)
+	if method == qrcode.TTLS {
+		inner, _ := eap["TTLSInnerAuthentication"].(string)
+		if inner == "EAP" {
+			return "", qrcode.EAPSpec{}, fmt.Errorf("inner EAP of TTLS cannot be expressed in WIFI: code")
+		}
+		spec.Phase2, err = toPhase2Method(inner)
+		if err != nil {
+			return "", qrcode.EAPSpec{}, err
+		}
+	}
+	password, _ := eap["UserPassword"].(string)
+	return password, spec, nil
+}
+
+// decodes XML plist into map[string]any, []any, string, int64, float64, bool and []byte values
+// referenced: https://www.apple.com/DTDs/PropertyList-1.0.dtd
+func decodePlist(r io.Reader) (any, error) {
+	decoder := xml.NewDecoder(r)
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("plist element is not found")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot decode plist: %w", err)
+		}
+		if start, ok := token.(xml.StartElement); ok && start.Name.Local == "plist" {
+			value, err := decodePlistValue(decoder, nextStart(decoder))
+			if err != nil {
+				return nil, fmt.Errorf("cannot decode plist: %w", err)
+			}
+			return value, nil
+		}
+	}
+}
+
+// returns next start element, or zero value if none until the enclosing element ends
+func nextStart(decoder *xml.Decoder) xml.StartElement {
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return xml.StartElement{}
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			return t
+		case xml.EndElement:
+			return xml.StartElement{}
+		}
+	}
+}
+
+func decodePlistValue(decoder *xml.Decoder, start xml.StartElement) (any, error) {
+	switch start.Name.Local {
+	case "dict":
+		dict := make(map[string]any)
+		for {
+			keyStart := nextStart(decoder)
+			if keyStart.Name.Local == "" {
+				return dict, nil
+			}
+			if keyStart.Name.Local != "key" {
+				return nil, fmt.Errorf("expected key in dict, got %s", keyStart.Name.Local)
+			}
+			var key string
+			err := decoder.DecodeElement(&key, &keyStart)
+			if err != nil {
+				return nil, err
+			}
+			valueStart := nextStart(decoder)
+			if valueStart.Name.Local == "" {
+				return nil, fmt.Errorf("missing value of key %s", key)
+			}
+			dict[key], err = decodePlistValue(decoder, valueStart)
+			if err != nil {
+				return nil, err
+			}
+		}
+	case "array":
+		array := make([]any, 0)
+		for {
+			itemStart := nextStart(decoder)
+			if itemStart.Name.Local == "" {
+				return array, nil
+			}
+			item, err := decodePlistValue(decoder, itemStart)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, item)
+		}
+	case "true", "false":
+		err := decoder.Skip()
+		return start.Name.Local == "true", err
+	}
+
+	var text string
+	err := decoder.DecodeElement(&text, &start)
+	if err != nil {
+		return nil, err
+	}
+	switch start.Name.Local {
+	case "string", "date":
+		return text, nil
+	case "integer":
+		return strconv.ParseInt(strings.TrimSpace(text), 10, 64)
+	case "real":
+		return strconv.ParseFloat(strings.TrimSpace(text), 64)
+	case "data":
+		return base64.StdEncoding.DecodeString(strings.Join(strings.Fields(text), ""))
+	default:
+		return nil, fmt.Errorf("unexpected plist element: %s", start.Name.Local)
+	}
+}
+
+// key-value pair of dict, written in the given order
+type plistEntry struct {
+	Key   string
+	Value any
+}
+
+type plistDict []plistEntry
+
+// writes Apple configuration profile with a single Wi-Fi payload, signed if signer is given
+// referenced: https://developer.apple.com/documentation/devicemanagement/toplevel
+func WriteMobileconfig(w io.Writer, spec qrcode.WifiSpec, signer *Signer) error {
+	payload, err := mobileconfigPayload(spec)
+	if err != nil {
+		return err
+	}
+	profile := plistDict{
+		{"PayloadContent", []any{payload}},
+		{"PayloadDisplayName", fmt.Sprintf("Wi-Fi: %s", spec.SSID())},
+		{"PayloadIdentifier", "com.github.pasca-l.wifi-qrcode-generator." + nameUUID("mobileconfig", spec.SSID())},
+		{"PayloadType", "Configuration"},
+		{"PayloadUUID", nameUUID("mobileconfig", spec.Encode())},
+		{"PayloadVersion", int64(1)},
+	}
+
+	buf := new(bytes.Buffer)
+	buf.WriteString(xml.Header)
+	buf.WriteString(`<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">` + "\n")
+	buf.WriteString(`<plist version="1.0">` + "\n")
+	writePlistValue(buf, profile, 0)
+	buf.WriteString("</plist>\n")
+
+	data := buf.Bytes()
+	if signer != nil {
+		data, err = signer.Sign(data)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+func mobileconfigPayload(spec qrcode.WifiSpec) (plistDict, error) {
+	payload := plistDict{
+		{"AutoJoin", true},
+		{"HIDDEN_NETWORK", spec.Hidden()},
+		{"PayloadDisplayName", "Wi-Fi"},
+		{"PayloadIdentifier", wifiPayloadType + "." + nameUUID("mobileconfig", spec.SSID(), wifiPayloadType)},
+		{"PayloadType", wifiPayloadType},
+		{"PayloadUUID", nameUUID("mobileconfig", spec.Encode(), wifiPayloadType)},
+		{"PayloadVersion", int64(1)},
+		{"SSID_STR", spec.SSID()},
+	}
+
+	switch spec.Encryption() {
+	case qrcode.NoPass:
+		payload = append(payload, plistEntry{"EncryptionType", "None"})
+	case qrcode.WEP:
+		payload = append(payload, plistEntry{"EncryptionType", "WEP"}, plistEntry{"Password", spec.Password()})
+	case qrcode.WPA:
+		// WIFI: code does not tell versions of WPA apart
+		payload = append(payload, plistEntry{"EncryptionType", "Any"}, plistEntry{"Password", spec.Password()})
+	case qrcode.WPA2EAP:
+		eap, err := mobileconfigEAPConfig(spec.EAP(), spec.Password())
+		if err != nil {
+			return nil, err
+		}
+		payload = append(payload, plistEntry{"EncryptionType", "WPA2"}, plistEntry{"EAPClientConfiguration", eap})
+	default:
+		return nil, fmt.Errorf("unexpected encryption: %s", spec.Encryption())
+	}
+	return payload, nil
+}
+
+// EAP methods supported by Apple devices, in numbers assigned by IANA
+var mobileconfigEAPTypes = map[qrcode.EAPMethod]int64{
+	qrcode.PEAP:     25,
+	qrcode.TTLS:     21,
+	qrcode.SIM:      18,
+	qrcode.AKA:      23,
+	qrcode.AKAPrime: 50,
+}
+
+func mobileconfigEAPConfig(eap qrcode.EAPSpec, password string) (plistDict, error) {
+	eapType, ok := mobileconfigEAPTypes[eap.Method]
+	if !ok {
+		return nil, fmt.Errorf("EAP method %s cannot be exported to mobileconfig", eap.Method)
+	}
+
+	config := plistDict{{"AcceptEAPTypes", []any{eapType}}}
+	if eap.Identity != "" {
+		config = append(config, plistEntry{"UserName", eap.Identity})
+	}
+	if password != "" {
+		config = append(config, plistEntry{"UserPassword", password})
+	}
+	if eap.AnonymousIdentity != "" {
+		config = append(config, plistEntry{"OuterIdentity", eap.AnonymousIdentity})
+	}
+
+	switch {
+	case eap.Phase2 == "" || eap.Phase2 == qrcode.NoPhase2:
+	case eap.Method == qrcode.TTLS && eap.Phase2 != qrcode.GTCPhase2:
+		// such as MSCHAPv2, in the spelling of the payload
+		inner := strings.Replace(string(eap.Phase2), "MSCHAPV2", "MSCHAPv2", 1)
+		config = append(config, plistEntry{"TTLSInnerAuthentication", inner})
+	case eap.Method == qrcode.PEAP && (eap.Phase2 == qrcode.MSCHAPV2Phase2 || eap.Phase2 == qrcode.GTCPhase2):
+		// inner method of PEAP is negotiated by devices
+	default:
+		return nil, fmt.Errorf("phase 2 %s of %s cannot be exported to mobileconfig", eap.Phase2, eap.Method)
+	}
+	return config, nil
+}
+
+// writes value of plistDict, []any, string, int64 or bool, indented by tabs
+func writePlistValue(buf *bytes.Buffer, value any, depth int) {
+	indent := strings.Repeat("\t", depth)
+	switch v := value.(type) {
+	case plistDict:
+		buf.WriteString(indent + "<dict>\n")
+		for _, entry := range v {
+			fmt.Fprintf(buf, "%s\t<key>%s</key>\n", indent, escapeXML(entry.Key))
+			writePlistValue(buf, entry.Value, depth+1)
+		}
+		buf.WriteString(indent + "</dict>\n")
+	case []any:
+		buf.WriteString(indent + "<array>\n")
+		for _, item := range v {
+			writePlistValue(buf, item, depth+1)
+		}
+		buf.WriteString(indent + "</array>\n")
+	case string:
+		fmt.Fprintf(buf, "%s<string>%s</string>\n", indent, escapeXML(v))
+	case int64:
+		fmt.Fprintf(buf, "%s<integer>%d</integer>\n", indent, v)
+	case bool:
+		fmt.Fprintf(buf, "%s<%t/>\n", indent, v)
+	}
+}
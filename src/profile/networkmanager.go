@@ -0,0 +1,245 @@
+package profile
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pasca-l/wifi-qrcode-generator/qrcode"
+)
+
+// SSID written as list of bytes, such as "72;111;109;101;" for non-UTF-8 names
+var byteListPattern = regexp.MustCompile(`^(\d{1,3};)+$`)
+
+// parses NetworkManager keyfile of a Wi-Fi connection
+// referenced: https://networkmanager.dev/docs/api/latest/nm-settings-keyfile.html
+func ParseNetworkManager(r io.Reader) (qrcode.WifiSpec, error) {
+	sections, err := readKeyfile(r)
+	if err != nil {
+		return qrcode.WifiSpec{}, err
+	}
+
+	switch connType := sections["connection"]["type"]; connType {
+	case "wifi", "802-11-wireless":
+	default:
+		return qrcode.WifiSpec{}, fmt.Errorf("connection type must be wifi: given '%s'", connType)
+	}
+
+	wifi := sections["wifi"]
+	if wifi == nil {
+		wifi = sections["802-11-wireless"]
+	}
+	security := sections["wifi-security"]
+	if security == nil {
+		security = sections["802-11-wireless-security"]
+	}
+
+	ssid, err := decodeKeyfileSSID(wifi["ssid"])
+	if err != nil {
+		return qrcode.WifiSpec{}, err
+	}
+	n := network{
+		ssid:       ssid,
+		encryption: qrcode.NoPass,
+		hidden:     wifi["hidden"] == "true",
+	}
+
+	switch keyMgmt := security["key-mgmt"]; keyMgmt {
+	case "", "owe":
+		// open networks, including enhanced open which needs no password
+	case "none":
+		// static WEP
+		n.encryption = qrcode.WEP
+		index := security["wep-tx-keyidx"]
+		if index == "" {
+			index = "0"
+		}
+		n.password = security["wep-key"+index]
+	case "wpa-psk", "sae":
+		n.encryption = qrcode.WPA
+		n.password = security["psk"]
+	case "wpa-eap", "ieee8021x":
+		n.encryption = qrcode.WPA2EAP
+		n.password, n.eap, err = networkManagerEAP(sections["802-1x"])
+		if err != nil {
+			return qrcode.WifiSpec{}, err
+		}
+	case "wpa-eap-suite-b-192":
+		// suite B only allows TLS
+		return qrcode.WifiSpec{}, errEAPTLS
+	default:
+		return qrcode.WifiSpec{}, fmt.Errorf("unexpected key-mgmt: %s", keyMgmt)
+	}
+
+	return n.spec()
+}
+
+// returns password and EAP settings from [802-1x] section, using the first of listed methods
+func networkManagerEAP(section map[string]string) (string, qrcode.EAPSpec, error) {
+	methods := strings.Split(strings.TrimSuffix(section["eap"], ";"), ";")
+	method, err := toEAPMethod(methods[0])
+	if err != nil {
+		return "", qrcode.EAPSpec{}, err
+	}
+	phase2 := section["phase2-auth"]
+	if phase2 == "" {
+		phase2 = section["phase2-autheap"]
+	}
+	phase2Method, err := toPhase2Method(phase2)
+	if err != nil {
+		return "", qrcode.EAPSpec{}, err
+	}
+
+	return section["password"], qrcode.EAPSpec{
+		Method:            method,
+		Phase2:            phase2Method,
+		Identity:          section["identity"],
+		AnonymousIdentity: section["anonymous-identity"],
+	}, nil
+}
+
+// reads keys of each section, unescaping values as GKeyFile does
+func readKeyfile(r io.Reader) (map[string]map[string]string, error) {
+	sections := make(map[string]map[string]string)
+	var current map[string]string
+	scanner := bufio.NewScanner(r)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+			continue
+		case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			name := line[1 : len(line)-1]
+			current = make(map[string]string)
+			sections[name] = current
+			continue
+		}
+
+		key, value, found := splitKeyValue(line)
+		if !found || current == nil {
+			return nil, fmt.Errorf("line %d: expected key=value in a section", lineNum)
+		}
+		unescaped, err := unescapeKeyfile(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fieldError(key, lineNum, err)
+		}
+		current[key] = unescaped
+	}
+	return sections, scanner.Err()
+}
+
+// referenced: https://docs.gtk.org/glib/struct.KeyFile.html
+func unescapeKeyfile(value string) (string, error) {
+	buf := new(strings.Builder)
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\\' {
+			buf.WriteByte(value[i])
+			continue
+		}
+		i++
+		if i >= len(value) {
+			return "", fmt.Errorf("unterminated escape")
+		}
+		switch value[i] {
+		case 's':
+			buf.WriteByte(' ')
+		case 'n':
+			buf.WriteByte('\n')
+		case 't':
+			buf.WriteByte('\t')
+		case 'r':
+			buf.WriteByte('\r')
+		case '\\':
+			buf.WriteByte('\\')
+		default:
+			// other escapes, such as list separators, are kept for the caller
+			buf.WriteByte('\\')
+			buf.WriteByte(value[i])
+		}
+	}
+	return buf.String(), nil
+}
+
+func decodeKeyfileSSID(value string) (string, error) {
+	if !byteListPattern.MatchString(value) {
+		return value, nil
+	}
+	items := strings.Split(strings.TrimSuffix(value, ";"), ";")
+	b := make([]byte, 0, len(items))
+	for _, item := range items {
+		v, err := strconv.ParseUint(item, 10, 8)
+		if err != nil {
+			return "", fmt.Errorf("cannot decode ssid byte list: %s", value)
+		}
+		b = append(b, byte(v))
+	}
+	return string(b), nil
+}
+
+// writes NetworkManager keyfile, which must be installed with mode 0600 to be loaded
+func WriteNetworkManager(w io.Writer, spec qrcode.WifiSpec) error {
+	buf := new(strings.Builder)
+	fmt.Fprintf(buf, "[connection]\nid=%s\nuuid=%s\ntype=wifi\n", escapeKeyfile(spec.SSID()), strings.ToLower(nameUUID("networkmanager", spec.SSID())))
+	fmt.Fprintf(buf, "\n[wifi]\nmode=infrastructure\nssid=%s\n", encodeKeyfileSSID(spec.SSID()))
+	if spec.Hidden() {
+		buf.WriteString("hidden=true\n")
+	}
+
+	switch spec.Encryption() {
+	case qrcode.NoPass:
+	case qrcode.WEP:
+		fmt.Fprintf(buf, "\n[wifi-security]\nkey-mgmt=none\nauth-alg=open\nwep-key-type=1\nwep-key0=%s\n", escapeKeyfile(spec.Password()))
+	case qrcode.WPA:
+		fmt.Fprintf(buf, "\n[wifi-security]\nkey-mgmt=wpa-psk\npsk=%s\n", escapeKeyfile(spec.Password()))
+	case qrcode.WPA2EAP:
+		eap := spec.EAP()
+		if eap.Method == qrcode.AKAPrime {
+			return fmt.Errorf("EAP method %s is not supported by NetworkManager", eap.Method)
+		}
+		buf.WriteString("\n[wifi-security]\nkey-mgmt=wpa-eap\n")
+		fmt.Fprintf(buf, "\n[802-1x]\neap=%s;\n", strings.ToLower(string(eap.Method)))
+		if eap.Identity != "" {
+			fmt.Fprintf(buf, "identity=%s\n", escapeKeyfile(eap.Identity))
+		}
+		if eap.AnonymousIdentity != "" {
+			fmt.Fprintf(buf, "anonymous-identity=%s\n", escapeKeyfile(eap.AnonymousIdentity))
+		}
+		if spec.Password() != "" {
+			fmt.Fprintf(buf, "password=%s\n", escapeKeyfile(spec.Password()))
+		}
+		if eap.Phase2 != "" && eap.Phase2 != qrcode.NoPhase2 {
+			fmt.Fprintf(buf, "phase2-auth=%s\n", strings.ToLower(string(eap.Phase2)))
+		}
+	default:
+		return fmt.Errorf("unexpected encryption: %s", spec.Encryption())
+	}
+
+	buf.WriteString("\n[ipv4]\nmethod=auto\n\n[ipv6]\nmethod=auto\n")
+	_, err := io.WriteString(w, buf.String())
+	return err
+}
+
+// escapes string value in the way of GKeyFile
+func escapeKeyfile(value string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, "\n", `\n`, "\t", `\t`, "\r", `\r`).Replace(value)
+	if strings.HasPrefix(escaped, " ") {
+		escaped = `\s` + escaped[1:]
+	}
+	return escaped
+}
+
+// writes SSID as list of bytes if it cannot be written as plain string
+func encodeKeyfileSSID(ssid string) string {
+	if utf8.ValidString(ssid) && !strings.ContainsAny(ssid, ";\\\n\t\r") && !strings.HasPrefix(ssid, " ") {
+		return ssid
+	}
+	buf := new(strings.Builder)
+	for i := range len(ssid) {
+		fmt.Fprintf(buf, "%d;", ssid[i])
+	}
+	return buf.String()
+}
@@ -0,0 +1,245 @@
+package profile
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pasca-l/wifi-qrcode-generator/qrcode"
+)
+
+type Format string
+
+const (
+	NetworkManager Format = "networkmanager" // .nmconnection keyfiles
+	WPASupplicant  Format = "wpa_supplicant" // network={} blocks of wpa_supplicant.conf
+	Hostapd        Format = "hostapd"        // hostapd.conf, with a network per bss
+	WindowsWLAN    Format = "wlan"           // XML exported by netsh wlan export profile
+	Mobileconfig   Format = "mobileconfig"   // Apple configuration profile in XML plist
+	Android        Format = "android"        // JSON in the style of WifiConfiguration, only exported
+)
+
+// detects format from the given name if non-empty, otherwise from file name
+func DetectFormat(name string, filename string) (Format, error) {
+	if name == "" {
+		base := strings.ToLower(filepath.Base(filename))
+		switch {
+		case strings.HasSuffix(base, ".nmconnection"):
+			return NetworkManager, nil
+		case strings.Contains(base, "wpa_supplicant"):
+			return WPASupplicant, nil
+		case strings.Contains(base, "hostapd"):
+			return Hostapd, nil
+		case strings.HasSuffix(base, ".xml"):
+			return WindowsWLAN, nil
+		case strings.HasSuffix(base, ".mobileconfig"):
+			return Mobileconfig, nil
+		default:
+			return "", fmt.Errorf("cannot detect profile format of file: %s", filename)
+		}
+	}
+
+	switch Format(name) {
+	case NetworkManager, WPASupplicant, Hostapd, WindowsWLAN, Mobileconfig, Android:
+		return Format(name), nil
+	default:
+		return "", fmt.Errorf("unexpected profile format: %s", name)
+	}
+}
+
+// reads every network configured in the profile
+func Import(r io.Reader, format Format) ([]qrcode.WifiSpec, error) {
+	switch format {
+	case NetworkManager:
+		spec, err := ParseNetworkManager(r)
+		if err != nil {
+			return nil, err
+		}
+		return []qrcode.WifiSpec{spec}, nil
+	case WPASupplicant:
+		return ParseWPASupplicant(r)
+	case Hostapd:
+		return ParseHostapd(r)
+	case WindowsWLAN:
+		spec, err := ParseWLANProfile(r)
+		if err != nil {
+			return nil, err
+		}
+		return []qrcode.WifiSpec{spec}, nil
+	case Mobileconfig:
+		return ParseMobileconfig(r)
+	case Android:
+		return nil, fmt.Errorf("profile format cannot be imported: %s", format)
+	default:
+		return nil, fmt.Errorf("unexpected profile format: %s", format)
+	}
+}
+
+// access point configs only have RADIUS settings, without credentials of any user
+var errEAP = errors.New("enterprise (EAP) networks of access points have no user credentials to be encoded")
+
+// client certificates cannot be provisioned by codes
+var errEAPTLS = errors.New("EAP-TLS needs client certificate, which cannot be expressed in WIFI: code")
+
+// settings collected from profiles, before converted into WifiSpec
+type network struct {
+	ssid       string
+	password   string
+	encryption qrcode.Encryption
+	hidden     bool
+	eap        qrcode.EAPSpec
+}
+
+func (n network) spec() (qrcode.WifiSpec, error) {
+	if n.ssid == "" {
+		return qrcode.WifiSpec{}, fmt.Errorf("ssid is not set")
+	}
+	// user credentials of enterprise networks are optional, as devices ask for them
+	if n.encryption != qrcode.NoPass && n.encryption != qrcode.WPA2EAP && n.password == "" {
+		return qrcode.WifiSpec{}, fmt.Errorf("password of network '%s' is not stored in profile", n.ssid)
+	}
+	return qrcode.NewWifiSpec(url.Values{
+		"ssid":               {n.ssid},
+		"password":           {n.password},
+		"encryption":         {string(n.encryption)},
+		"hidden":             {strconv.FormatBool(n.hidden)},
+		"eap_method":         {string(n.eap.Method)},
+		"phase2":             {string(n.eap.Phase2)},
+		"identity":           {n.eap.Identity},
+		"anonymous_identity": {n.eap.AnonymousIdentity},
+	})
+}
+
+// maps EAP method names used by configs, case insensitively
+func toEAPMethod(name string) (qrcode.EAPMethod, error) {
+	switch strings.ToUpper(name) {
+	case "PEAP":
+		return qrcode.PEAP, nil
+	case "TTLS":
+		return qrcode.TTLS, nil
+	case "PWD":
+		return qrcode.PWD, nil
+	case "SIM":
+		return qrcode.SIM, nil
+	case "AKA":
+		return qrcode.AKA, nil
+	case "AKA'", "AKA_PRIME":
+		return qrcode.AKAPrime, nil
+	case "TLS":
+		return "", errEAPTLS
+	default:
+		return "", fmt.Errorf("EAP method '%s' cannot be expressed in WIFI: code", name)
+	}
+}
+
+// maps inner authentication names used by configs, case insensitively
+func toPhase2Method(name string) (qrcode.Phase2Method, error) {
+	switch strings.ToUpper(strings.TrimPrefix(name, "EAP-")) {
+	case "":
+		return "", nil
+	case "PAP":
+		return qrcode.PAPPhase2, nil
+	case "MSCHAP":
+		return qrcode.MSCHAPPhase2, nil
+	case "MSCHAPV2":
+		return qrcode.MSCHAPV2Phase2, nil
+	case "GTC":
+		return qrcode.GTCPhase2, nil
+	default:
+		return "", fmt.Errorf("inner authentication '%s' cannot be expressed in WIFI: code", name)
+	}
+}
+
+// decodes string in forms used by wpa_supplicant and hostapd:
+// "quoted" as is, P"printf escaped", or hex encoded bytes
+// errors never include the value, which may be a password
+func decodeString(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, `P"`) && strings.HasSuffix(value, `"`) && len(value) >= 3:
+		return printfDecode(value[2 : len(value)-1])
+	case strings.HasPrefix(value, `"`) && strings.HasSuffix(value, `"`) && len(value) >= 2:
+		return value[1 : len(value)-1], nil
+	default:
+		b, err := hex.DecodeString(value)
+		if err != nil {
+			return "", fmt.Errorf("cannot decode value as quoted or hex string")
+		}
+		return string(b), nil
+	}
+}
+
+// decodes escapes supported by printf_decode of wpa_supplicant and hostapd
+// referenced: https://w1.fi/cgit/hostap/tree/src/utils/common.c
+func printfDecode(s string) (string, error) {
+	buf := new(strings.Builder)
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			buf.WriteByte(s[i])
+			continue
+		}
+		i++
+		if i >= len(s) {
+			return "", fmt.Errorf("unterminated escape")
+		}
+		switch c := s[i]; c {
+		case '\\', '"':
+			buf.WriteByte(c)
+		case 'n':
+			buf.WriteByte('\n')
+		case 'r':
+			buf.WriteByte('\r')
+		case 't':
+			buf.WriteByte('\t')
+		case 'e':
+			buf.WriteByte('\033')
+		case 'x':
+			// up to 2 hex digits
+			end := i + 1
+			for end < len(s) && end < i+3 && isHexDigit(s[end]) {
+				end++
+			}
+			if end == i+1 {
+				return "", fmt.Errorf("incomplete hex escape")
+			}
+			b, _ := strconv.ParseUint(s[i+1:end], 16, 8)
+			buf.WriteByte(byte(b))
+			i = end - 1
+		default:
+			// up to 3 octal digits
+			end := i
+			for end < len(s) && end < i+3 && s[end] >= '0' && s[end] <= '7' {
+				end++
+			}
+			if end == i {
+				return "", fmt.Errorf("unexpected escape")
+			}
+			b, err := strconv.ParseUint(s[i:end], 8, 8)
+			if err != nil {
+				return "", fmt.Errorf("octal escape out of range")
+			}
+			buf.WriteByte(byte(b))
+			i = end - 1
+		}
+	}
+	return buf.String(), nil
+}
+
+func isHexDigit(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+// reports error of the value of key, along with its line but not the value, which may be a password
+func fieldError(key string, line int, err error) error {
+	return fmt.Errorf("%s at line %d: %w", key, line, err)
+}
+
+// splits line into key and value at the first '=', trimming spaces around key
+func splitKeyValue(line string) (string, string, bool) {
+	key, value, found := strings.Cut(line, "=")
+	return strings.TrimSpace(key), value, found
+}
@@ -0,0 +1,278 @@
+package profile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestImport(t *testing.T) {
+	testcases := []struct {
+		name    string
+		format  Format
+		config  string
+		want    []string // encoded specs
+		wantErr error
+	}{
+		{
+			name:   "NetworkManager WPA",
+			format: NetworkManager,
+			config: `[connection]
+id=Office
+type=wifi
+
+[wifi]
+mode=infrastructure
+ssid=Office\sGuest
+hidden=true
+
+[wifi-security]
+key-mgmt=wpa-psk
+psk=correct horse
+`,
+			want: []string{`WIFI:T:WPA;S:"Office Guest";P:"correct horse";H:true;;`},
+		},
+		{
+			name:   "NetworkManager byte list SSID",
+			format: NetworkManager,
+			config: "[connection]\ntype=802-11-wireless\n[802-11-wireless]\nssid=72;111;109;101;\n",
+			want:   []string{`WIFI:T:nopass;S:"Home";P:"";;`},
+		},
+		{
+			name:   "NetworkManager EAP",
+			format: NetworkManager,
+			config: `[connection]
+type=wifi
+[wifi]
+ssid=Corp
+[wifi-security]
+key-mgmt=wpa-eap
+[802-1x]
+eap=peap;
+identity=alice
+anonymous-identity=anonymous
+password=alice password
+phase2-auth=mschapv2
+`,
+			want: []string{`WIFI:T:WPA2-EAP;S:"Corp";P:"alice password";E:PEAP;A:"anonymous";I:"alice";PH2:MSCHAPV2;;`},
+		},
+		{
+			name:    "NetworkManager EAP-TLS",
+			format:  NetworkManager,
+			config:  "[connection]\ntype=wifi\n[wifi]\nssid=Corp\n[wifi-security]\nkey-mgmt=wpa-eap\n[802-1x]\neap=tls;\n",
+			wantErr: errEAPTLS,
+		},
+		{
+			name:   "wpa_supplicant",
+			format: WPASupplicant,
+			config: `ctrl_interface=/var/run/wpa_supplicant
+network={
+	ssid="Lobby"
+	psk="lobby password"
+	key_mgmt=WPA-PSK
+}
+network={
+	ssid=4361666520f09f8db5
+	key_mgmt=NONE
+	scan_ssid=1
+}
+network={
+	ssid=P"Tab\tSSID"
+	key_mgmt=NONE
+	wep_key0="abcde"
+}
+`,
+			want: []string{
+				`WIFI:T:WPA;S:"Lobby";P:"lobby password";;`,
+				`WIFI:T:nopass;S:"Cafe 🍵";P:"";H:true;;`,
+				"WIFI:T:WEP;S:\"Tab\tSSID\";P:\"abcde\";;",
+			},
+		},
+		{
+			name:   "wpa_supplicant EAP",
+			format: WPASupplicant,
+			config: `network={
+	ssid="Corp"
+	key_mgmt=WPA-EAP
+	eap=TTLS
+	identity="bob"
+	password="bob password"
+	phase2="auth=PAP"
+}
+`,
+			want: []string{`WIFI:T:WPA2-EAP;S:"Corp";P:"bob password";E:TTLS;I:"bob";PH2:PAP;;`},
+		},
+		{
+			name:    "hostapd EAP",
+			format:  Hostapd,
+			config:  "ssid=Corp\nieee8021x=1\nwpa=2\nwpa_key_mgmt=WPA-EAP\n",
+			wantErr: errEAP,
+		},
+		{
+			name:   "wpa_supplicant raw PSK",
+			format: WPASupplicant,
+			config: "network={\n\tssid=\"Lobby\"\n\tpsk=" + strings.Repeat("ab", 32) + "\n}\n",
+			want:   []string{`WIFI:T:WPA;S:"Lobby";P:` + strings.Repeat("ab", 32) + `;;`},
+		},
+		{
+			name:   "hostapd",
+			format: Hostapd,
+			config: `interface=wlan0
+ssid=Guest
+wpa=2
+wpa_key_mgmt=WPA-PSK SAE
+wpa_passphrase=guest password
+ignore_broadcast_ssid=1
+
+bss=wlan0_1
+ssid2=P"Staff\x21"
+wpa=2
+wpa_passphrase=staff password
+`,
+			want: []string{
+				`WIFI:T:WPA;S:"Guest";P:"guest password";H:true;;`,
+				`WIFI:T:WPA;S:"Staff!";P:"staff password";;`,
+			},
+		},
+		{
+			name:   "hostapd without passphrase",
+			format: Hostapd,
+			config: "ssid=Guest\nwpa=2\nwpa_psk=" + strings.Repeat("0f", 32) + "\n",
+			want:   []string{`WIFI:T:WPA;S:"Guest";P:` + strings.Repeat("0f", 32) + `;;`},
+		},
+	}
+
+	for _, tt := range testcases {
+		t.Run("testing Import() for "+tt.name, func(t *testing.T) {
+			specs, err := Import(strings.NewReader(tt.config), tt.format)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Import() error = '%v'; expected '%v'", err, tt.wantErr)
+			}
+			got := make([]string, 0, len(specs))
+			for _, spec := range specs {
+				got = append(got, spec.Encode())
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("Import() = %q; expected %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImportFile(t *testing.T) {
+	testcases := []struct {
+		file    string
+		want    []string // encoded specs
+		wantErr error
+	}{
+		{file: "wpa2psk.xml", want: []string{`WIFI:T:WPA;S:"Home";P:"correct horse";H:true;;`}},
+		{file: "peap.xml", want: []string{`WIFI:T:WPA2-EAP;S:"Corp";P:"";E:PEAP;PH2:MSCHAPV2;;`}},
+		{file: "protected.xml", wantErr: errors.New("key is encrypted for the exporting machine: export with key=clear")},
+		{
+			file: "wpa.mobileconfig",
+			want: []string{
+				`WIFI:T:WPA;S:"Home";P:"correct horse";H:true;;`,
+				`WIFI:T:WPA2-EAP;S:"Corp";P:"alice password";E:TTLS;A:"anonymous";I:"alice";PH2:PAP;;`,
+			},
+		},
+		{file: "tls.mobileconfig", wantErr: errors.New("payload 1: " + errEAPTLS.Error())},
+		{file: "binary.mobileconfig", wantErr: errors.New("binary plist is not supported: convert with plutil -convert xml1")},
+	}
+
+	for _, tt := range testcases {
+		t.Run("testing Import() for "+tt.file, func(t *testing.T) {
+			format, err := DetectFormat("", tt.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			f, err := os.Open(filepath.Join("testdata", tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			specs, err := Import(f, format)
+			if (err == nil) != (tt.wantErr == nil) || (err != nil && err.Error() != tt.wantErr.Error()) {
+				t.Fatalf("Import() error = '%v'; expected '%v'", err, tt.wantErr)
+			}
+			got := make([]string, 0, len(specs))
+			for _, spec := range specs {
+				got = append(got, spec.Encode())
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("Import() = %q; expected %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImportError(t *testing.T) {
+	testcases := []struct {
+		name    string
+		format  Format
+		config  string
+		wantErr error
+	}{
+		{
+			name:    "wpa_supplicant",
+			format:  WPASupplicant,
+			config:  "network={\n\tssid=\"Lobby\"\n\tpsk=P\"secret\\q\"\n}\n",
+			wantErr: errors.New("network at line 1: psk at line 3: unexpected escape"),
+		},
+		{
+			name:    "wpa_supplicant WEP",
+			format:  WPASupplicant,
+			config:  "network={\n\tssid=\"Lobby\"\n\tkey_mgmt=NONE\n\twep_key0=secret\n}\n",
+			wantErr: errors.New("network at line 1: wep_key0 at line 4: cannot decode WEP key as quoted or hex string"),
+		},
+		{
+			name:    "hostapd WEP",
+			format:  Hostapd,
+			config:  "ssid=Lobby\nwep_key0=secret\n",
+			wantErr: errors.New("wep_key0 at line 2: cannot decode WEP key as quoted or hex string"),
+		},
+		{
+			name:    "NetworkManager",
+			format:  NetworkManager,
+			config:  "[wifi-security]\npsk=secret\\\n",
+			wantErr: errors.New("psk at line 2: unterminated escape"),
+		},
+	}
+
+	for _, tt := range testcases {
+		t.Run("testing Import() for "+tt.name, func(t *testing.T) {
+			_, err := Import(strings.NewReader(tt.config), tt.format)
+			if (err == nil) != (tt.wantErr == nil) || (err != nil && err.Error() != tt.wantErr.Error()) {
+				t.Errorf("Import() error = '%v'; expected '%v'", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPrintfDecode(t *testing.T) {
+	testcases := []struct {
+		s       string
+		want    string
+		wantErr bool
+	}{
+		{s: `a\"b\\c`, want: `a"b\c`},
+		{s: `\x41\x4`, want: "A\x04"},
+		{s: `\101\0`, want: "A\x00"},
+		{s: `\q`, wantErr: true},
+		{s: `trailing\`, wantErr: true},
+	}
+
+	for _, tt := range testcases {
+		t.Run("testing printfDecode()", func(t *testing.T) {
+			got, err := printfDecode(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("printfDecode(%q) error = '%v'; expected error: %v", tt.s, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("printfDecode(%q) = %q; expected %q", tt.s, got, tt.want)
+			}
+		})
+	}
+}
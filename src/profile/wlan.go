@@ -0,0 +1,319 @@
+package profile
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/pasca-l/wifi-qrcode-generator/qrcode"
+)
+
+// profile exported by netsh wlan export profile, where namespaces are ignored
+// referenced: https://learn.microsoft.com/en-us/windows/win32/nativewifi/wlan-profileschema-elements
+type wlanProfile struct {
+	XMLName    xml.Name `xml:"WLANProfile"`
+	SSIDConfig struct {
+		SSID struct {
+			Hex  string `xml:"hex"`
+			Name string `xml:"name"`
+		} `xml:"SSID"`
+		NonBroadcast bool `xml:"nonBroadcast"`
+	} `xml:"SSIDConfig"`
+	Security struct {
+		Authentication string `xml:"authEncryption>authentication"`
+		Encryption     string `xml:"authEncryption>encryption"`
+		UseOneX        bool   `xml:"authEncryption>useOneX"`
+		KeyType        string `xml:"sharedKey>keyType"`
+		Protected      bool   `xml:"sharedKey>protected"`
+		KeyMaterial    string `xml:"sharedKey>keyMaterial"`
+		OneX           struct {
+			Inner []byte `xml:",innerxml"`
+		} `xml:"OneX"`
+	} `xml:"MSM>security"`
+}
+
+// EAP method types assigned by IANA, used in EapHostConfig
+// referenced: https://www.iana.org/assignments/eap-numbers/eap-numbers.xhtml
+var eapTypes = map[string]string{
+	"6":  "GTC",
+	"13": "TLS",
+	"18": "SIM",
+	"21": "TTLS",
+	"23": "AKA",
+	"25": "PEAP",
+	"26": "MSCHAPV2",
+	"50": "AKA'",
+}
+
+// parses Windows WLAN profile, which has a network per file
+func ParseWLANProfile(r io.Reader) (qrcode.WifiSpec, error) {
+	var p wlanProfile
+	err := xml.NewDecoder(r).Decode(&p)
+	if err != nil {
+		return qrcode.WifiSpec{}, fmt.Errorf("cannot decode WLAN profile: %w", err)
+	}
+
+	n := network{
+		ssid:       p.SSIDConfig.SSID.Name,
+		encryption: qrcode.NoPass,
+		hidden:     p.SSIDConfig.NonBroadcast,
+	}
+	// hex is used for SSIDs which are not valid in XML
+	if p.SSIDConfig.SSID.Hex != "" {
+		b, err := hex.DecodeString(p.SSIDConfig.SSID.Hex)
+		if err != nil {
+			return qrcode.WifiSpec{}, fmt.Errorf("cannot decode ssid: %s", p.SSIDConfig.SSID.Hex)
+		}
+		n.ssid = string(b)
+	}
+
+	sec := p.Security
+	if sec.Protected {
+		return qrcode.WifiSpec{}, fmt.Errorf("key is encrypted for the exporting machine: export with key=clear")
+	}
+
+	switch strings.ToUpper(sec.Authentication) {
+	case "OPEN", "SHARED":
+		if strings.EqualFold(sec.Encryption, "WEP") {
+			if sec.UseOneX {
+				return qrcode.WifiSpec{}, fmt.Errorf("dynamic WEP with 802.1X cannot be expressed in WIFI: code")
+			}
+			n.encryption = qrcode.WEP
+			n.password = sec.KeyMaterial
+		}
+	case "OWE":
+		// enhanced open needs no password
+	case "WPAPSK", "WPA2PSK", "WPA3SAE":
+		n.encryption = qrcode.WPA
+		n.password = sec.KeyMaterial
+	case "WPA", "WPA2", "WPA3", "WPA3ENT":
+		n.encryption = qrcode.WPA2EAP
+		n.eap, err = wlanEAP(sec.OneX.Inner)
+		if err != nil {
+			return qrcode.WifiSpec{}, err
+		}
+	case "WPA3ENT192":
+		// 192-bit mode only allows TLS
+		return qrcode.WifiSpec{}, errEAPTLS
+	default:
+		return qrcode.WifiSpec{}, fmt.Errorf("authentication '%s' cannot be expressed in WIFI: code", sec.Authentication)
+	}
+
+	return n.spec()
+}
+
+// finds EAP method and inner authentication in OneX element, which has method specific schemas
+// user credentials are not part of profiles, so that devices ask for them
+func wlanEAP(oneX []byte) (qrcode.EAPSpec, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(oneX))
+	path := make([]string, 0)
+	outer, inner, ttlsPhase2 := "", "", ""
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return qrcode.EAPSpec{}, fmt.Errorf("cannot decode OneX element: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			path = append(path, t.Name.Local)
+			if len(path) >= 2 && path[len(path)-2] == "Phase2Authentication" && ttlsPhase2 == "" {
+				// such as MSCHAPv2Authentication for TTLS
+				ttlsPhase2 = strings.TrimSuffix(t.Name.Local, "Authentication")
+			}
+		case xml.EndElement:
+			path = path[:len(path)-1]
+		case xml.CharData:
+			if len(path) < 2 || path[len(path)-1] != "Type" {
+				continue
+			}
+			value := strings.TrimSpace(string(t))
+			switch {
+			case path[len(path)-2] == "EapMethod" && outer == "":
+				outer = value
+			case path[len(path)-2] == "Eap" && outer != "" && value != outer:
+				// nested method of tunneled methods, such as MSCHAPv2 for PEAP
+				inner = value
+			}
+		}
+	}
+
+	if outer == "" {
+		return qrcode.EAPSpec{}, fmt.Errorf("EAP method is not found in profile")
+	}
+	method, err := toEAPMethod(eapTypes[outer])
+	if err != nil && eapTypes[outer] == "" {
+		return qrcode.EAPSpec{}, fmt.Errorf("EAP method type %s cannot be expressed in WIFI: code", outer)
+	}
+	if err != nil {
+		return qrcode.EAPSpec{}, err
+	}
+
+	phase2Name := ttlsPhase2
+	if inner != "" {
+		phase2Name = eapTypes[inner]
+	}
+	phase2, err := toPhase2Method(phase2Name)
+	if err != nil {
+		return qrcode.EAPSpec{}, err
+	}
+	return qrcode.EAPSpec{Method: method, Phase2: phase2}, nil
+}
+
+// settings filled into WLAN profile template
+type wlanExport struct {
+	SSID           string
+	HexSSID        string
+	Hidden         bool
+	Authentication string
+	Encryption     string
+	KeyType        string
+	KeyMaterial    string
+	EAPConfig      string
+}
+
+var wlanTemplate = template.Must(template.New("wlan").Funcs(template.FuncMap{"xml": escapeXML}).Parse(`<?xml version="1.0"?>
+<WLANProfile xmlns="http://www.microsoft.com/networking/WLAN/profile/v1">
+	<name>{{xml .SSID}}</name>
+	<SSIDConfig>
+		<SSID>
+			<hex>{{.HexSSID}}</hex>
+			<name>{{xml .SSID}}</name>
+		</SSID>
+		<nonBroadcast>{{.Hidden}}</nonBroadcast>
+	</SSIDConfig>
+	<connectionType>ESS</connectionType>
+	<connectionMode>auto</connectionMode>
+	<MSM>
+		<security>
+			<authEncryption>
+				<authentication>{{.Authentication}}</authentication>
+				<encryption>{{.Encryption}}</encryption>
+				<useOneX>{{ne .EAPConfig ""}}</useOneX>
+			</authEncryption>
+			{{- if .KeyType}}
+			<sharedKey>
+				<keyType>{{.KeyType}}</keyType>
+				<protected>false</protected>
+				<keyMaterial>{{xml .KeyMaterial}}</keyMaterial>
+			</sharedKey>
+			{{- end}}
+			{{- if .EAPConfig}}
+			<OneX xmlns="http://www.microsoft.com/networking/OneX/v1">
+				<authMode>user</authMode>
+				<EAPConfig>{{.EAPConfig}}</EAPConfig>
+			</OneX>
+			{{- end}}
+		</security>
+	</MSM>
+</WLANProfile>
+`))
+
+// writes Windows WLAN profile to be added by netsh wlan add profile
+// user credentials of enterprise networks cannot be stored in profiles, so that Windows asks for them
+func WriteWLANProfile(w io.Writer, spec qrcode.WifiSpec) error {
+	p := wlanExport{
+		SSID:           spec.SSID(),
+		HexSSID:        strings.ToUpper(hex.EncodeToString([]byte(spec.SSID()))),
+		Hidden:         spec.Hidden(),
+		Authentication: "open",
+		Encryption:     "none",
+	}
+
+	switch spec.Encryption() {
+	case qrcode.NoPass:
+	case qrcode.WEP:
+		p.Encryption, p.KeyType, p.KeyMaterial = "WEP", "networkKey", spec.Password()
+	case qrcode.WPA:
+		p.Authentication, p.Encryption, p.KeyType, p.KeyMaterial = "WPA2PSK", "AES", "passPhrase", spec.Password()
+		if spec.HexPSK() {
+			p.KeyType = "networkKey"
+		}
+	case qrcode.WPA2EAP:
+		config, err := wlanEAPConfig(spec.EAP())
+		if err != nil {
+			return err
+		}
+		p.Authentication, p.Encryption, p.EAPConfig = "WPA2", "AES", config
+	default:
+		return fmt.Errorf("unexpected encryption: %s", spec.Encryption())
+	}
+
+	return wlanTemplate.Execute(w, p)
+}
+
+// EapHostConfig of methods built into Windows, where servers are validated by prompting users
+// referenced: https://learn.microsoft.com/en-us/windows/win32/eaphost/eaphostconfigschema-schema
+func wlanEAPConfig(eap qrcode.EAPSpec) (string, error) {
+	const (
+		hostConfigNS = "http://www.microsoft.com/provisioning/EapHostConfig"
+		commonNS     = "http://www.microsoft.com/provisioning/EapCommon"
+		baseNS       = "http://www.microsoft.com/provisioning/BaseEapConnectionPropertiesV1"
+	)
+
+	var outer int
+	config := new(strings.Builder)
+	switch eap.Method {
+	case qrcode.PEAP:
+		outer = 25
+		if eap.Phase2 != "" && eap.Phase2 != qrcode.NoPhase2 && eap.Phase2 != qrcode.MSCHAPV2Phase2 {
+			return "", fmt.Errorf("PEAP of Windows only supports MSCHAPV2 as phase 2: given %s", eap.Phase2)
+		}
+		fmt.Fprintf(config, `<Eap xmlns="%s"><Type>25</Type>`, baseNS)
+		config.WriteString(`<EapType xmlns="http://www.microsoft.com/provisioning/MsPeapConnectionPropertiesV1">`)
+		config.WriteString(`<ServerValidation><DisableUserPromptForServerValidation>false</DisableUserPromptForServerValidation><ServerNames></ServerNames></ServerValidation>`)
+		config.WriteString(`<FastReconnect>true</FastReconnect><InnerEapOptional>false</InnerEapOptional>`)
+		fmt.Fprintf(config, `<Eap xmlns="%s"><Type>26</Type>`, baseNS)
+		config.WriteString(`<EapType xmlns="http://www.microsoft.com/provisioning/MsChapV2ConnectionPropertiesV1"><UseWinLogonCredentials>false</UseWinLogonCredentials></EapType></Eap>`)
+		config.WriteString(`<EnableQuarantineChecks>false</EnableQuarantineChecks><RequireCryptoBinding>false</RequireCryptoBinding>`)
+		if eap.AnonymousIdentity != "" {
+			config.WriteString(`<PeapExtensions><IdentityPrivacy xmlns="http://www.microsoft.com/provisioning/MsPeapConnectionPropertiesV2">`)
+			fmt.Fprintf(config, `<EnableIdentityPrivacy>true</EnableIdentityPrivacy><AnonymousUserName>%s</AnonymousUserName>`, escapeXML(eap.AnonymousIdentity))
+			config.WriteString(`</IdentityPrivacy></PeapExtensions>`)
+		}
+		config.WriteString(`</EapType></Eap>`)
+	case qrcode.TTLS:
+		outer = 21
+		var phase2 string
+		switch eap.Phase2 {
+		case "", qrcode.NoPhase2, qrcode.MSCHAPV2Phase2:
+			phase2 = `<MSCHAPv2Authentication><UseWinlogonCredentials>false</UseWinlogonCredentials></MSCHAPv2Authentication>`
+		case qrcode.MSCHAPPhase2:
+			phase2 = `<MSCHAPAuthentication/>`
+		case qrcode.PAPPhase2:
+			phase2 = `<PAPAuthentication/>`
+		default:
+			return "", fmt.Errorf("TTLS of Windows does not support %s as phase 2", eap.Phase2)
+		}
+		config.WriteString(`<EapTtls xmlns="http://www.microsoft.com/provisioning/EapTtlsConnectionPropertiesV1">`)
+		config.WriteString(`<ServerValidation><ServerNames></ServerNames><DisablePrompt>false</DisablePrompt></ServerValidation>`)
+		fmt.Fprintf(config, `<Phase2Authentication>%s</Phase2Authentication>`, phase2)
+		if eap.AnonymousIdentity != "" {
+			fmt.Fprintf(config, `<Phase1Identity><IdentityPrivacy>true</IdentityPrivacy><AnonymousIdentity>%s</AnonymousIdentity></Phase1Identity>`, escapeXML(eap.AnonymousIdentity))
+		} else {
+			config.WriteString(`<Phase1Identity><IdentityPrivacy>false</IdentityPrivacy></Phase1Identity>`)
+		}
+		config.WriteString(`</EapTtls>`)
+	default:
+		return "", fmt.Errorf("EAP method %s cannot be exported to WLAN profile", eap.Method)
+	}
+
+	return fmt.Sprintf(
+		`<EapHostConfig xmlns="%[1]s"><EapMethod><Type xmlns="%[2]s">%[3]d</Type><VendorId xmlns="%[2]s">0</VendorId><VendorType xmlns="%[2]s">0</VendorType><AuthorId xmlns="%[2]s">0</AuthorId></EapMethod><Config xmlns="%[1]s">%[4]s</Config></EapHostConfig>`,
+		hostConfigNS, commonNS, outer, config.String(),
+	), nil
+}
+
+// escapes text, where characters not allowed in XML are replaced
+func escapeXML(s string) string {
+	buf := new(strings.Builder)
+	_ = xml.EscapeText(buf, []byte(s))
+	return buf.String()
+}
@@ -0,0 +1,204 @@
+package profile
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pasca-l/wifi-qrcode-generator/qrcode"
+)
+
+// parses every network={} block of wpa_supplicant.conf, ignoring global settings
+// referenced: https://w1.fi/cgit/hostap/plain/wpa_supplicant/wpa_supplicant.conf
+func ParseWPASupplicant(r io.Reader) ([]qrcode.WifiSpec, error) {
+	specs := make([]qrcode.WifiSpec, 0)
+	var block map[string]string
+	var lines map[string]int // line of each key in block, for errors
+	blockStart := 0
+	scanner := bufio.NewScanner(r)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+			continue
+		case block == nil && strings.ReplaceAll(line, " ", "") == "network={":
+			block = make(map[string]string)
+			lines = make(map[string]int)
+			blockStart = lineNum
+			continue
+		case block != nil && line == "}":
+			spec, err := wpaSupplicantNetwork(block, lines)
+			if err != nil {
+				return nil, fmt.Errorf("network at line %d: %w", blockStart, err)
+			}
+			specs = append(specs, spec)
+			block = nil
+			continue
+		case block == nil:
+			continue
+		}
+
+		key, value, found := splitKeyValue(line)
+		if !found {
+			return nil, fmt.Errorf("line %d: expected key=value", lineNum)
+		}
+		block[key] = value
+		lines[key] = lineNum
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if block != nil {
+		return nil, fmt.Errorf("network at line %d: missing closing brace", blockStart)
+	}
+	if len(specs) == 0 {
+		return nil, fmt.Errorf("no network blocks found")
+	}
+
+	return specs, nil
+}
+
+func wpaSupplicantNetwork(block map[string]string, lines map[string]int) (qrcode.WifiSpec, error) {
+	ssid, err := decodeString(block["ssid"])
+	if err != nil {
+		return qrcode.WifiSpec{}, fieldError("ssid", lines["ssid"], err)
+	}
+	n := network{
+		ssid:       ssid,
+		encryption: qrcode.NoPass,
+		hidden:     block["scan_ssid"] == "1",
+	}
+
+	keyMgmt, exists := block["key_mgmt"]
+	if !exists {
+		// default of wpa_supplicant
+		keyMgmt = "WPA-PSK WPA-EAP"
+	}
+	mgmts := strings.Fields(keyMgmt)
+	switch {
+	case hasAny(mgmts, "WPA-PSK", "SAE", "FT-PSK", "FT-SAE", "WPA-PSK-SHA256"):
+		n.encryption = qrcode.WPA
+		pskKey := "psk"
+		if block[pskKey] == "" {
+			if hasAny(mgmts, "WPA-EAP", "IEEE8021X") && block["eap"] != "" {
+				return wpaSupplicantEAP(n, block, lines)
+			}
+			// SAE passwords are kept separately from psk
+			pskKey = "sae_password"
+		}
+		psk := block[pskKey]
+		// unquoted PSK in hex is kept as is, rather than decoded as hex string
+		if qrcode.IsHexPSK(psk) {
+			n.password = psk
+		} else if psk != "" {
+			n.password, err = decodeString(psk)
+			if err != nil {
+				return qrcode.WifiSpec{}, fieldError(pskKey, lines[pskKey], err)
+			}
+		}
+	case hasAny(mgmts, "NONE"):
+		index := block["wep_tx_keyidx"]
+		if index == "" {
+			index = "0"
+		}
+		if key, exists := block["wep_key"+index]; exists {
+			n.encryption = qrcode.WEP
+			n.password, err = decodeWEPKey(key)
+			if err != nil {
+				return qrcode.WifiSpec{}, fieldError("wep_key"+index, lines["wep_key"+index], err)
+			}
+		}
+	case hasAny(mgmts, "WPA-EAP", "IEEE8021X", "WPA-EAP-SHA256", "FT-EAP"):
+		return wpaSupplicantEAP(n, block, lines)
+	case hasAny(mgmts, "WPA-EAP-SUITE-B", "WPA-EAP-SUITE-B-192"):
+		// suite B only allows TLS
+		return qrcode.WifiSpec{}, errEAPTLS
+	case hasAny(mgmts, "OWE"):
+		// enhanced open needs no password
+	default:
+		return qrcode.WifiSpec{}, fmt.Errorf("unexpected key_mgmt: %s", keyMgmt)
+	}
+
+	return n.spec()
+}
+
+// uses the first of listed methods, and inner authentication given as auth= or autheap=
+func wpaSupplicantEAP(n network, block map[string]string, lines map[string]int) (qrcode.WifiSpec, error) {
+	n.encryption = qrcode.WPA2EAP
+	methods := strings.Fields(block["eap"])
+	if len(methods) == 0 {
+		// wpa_supplicant allows all methods by default, which cannot be expressed in a code
+		return qrcode.WifiSpec{}, fmt.Errorf("eap method is not set")
+	}
+	var err error
+	n.eap.Method, err = toEAPMethod(methods[0])
+	if err != nil {
+		return qrcode.WifiSpec{}, err
+	}
+
+	phase2 := ""
+	if block["phase2"] != "" {
+		params, err := decodeString(block["phase2"])
+		if err != nil {
+			return qrcode.WifiSpec{}, fieldError("phase2", lines["phase2"], err)
+		}
+		for _, param := range strings.Fields(params) {
+			if name, found := strings.CutPrefix(param, "auth="); found {
+				phase2 = name
+			} else if name, found := strings.CutPrefix(param, "autheap="); found {
+				phase2 = name
+			}
+		}
+	}
+	n.eap.Phase2, err = toPhase2Method(phase2)
+	if err != nil {
+		return qrcode.WifiSpec{}, err
+	}
+
+	for field, key := range map[*string]string{&n.eap.Identity: "identity", &n.eap.AnonymousIdentity: "anonymous_identity", &n.password: "password"} {
+		if block[key] == "" {
+			continue
+		}
+		if strings.HasPrefix(block[key], "hash:") {
+			return qrcode.WifiSpec{}, fmt.Errorf("%s is stored as hash, which cannot be encoded", key)
+		}
+		*field, err = decodeString(block[key])
+		if err != nil {
+			return qrcode.WifiSpec{}, fieldError(key, lines[key], err)
+		}
+	}
+
+	return n.spec()
+}
+
+// keeps hex WEP keys as is, as scanners accept them in hex
+func decodeWEPKey(value string) (string, error) {
+	if strings.HasPrefix(value, `"`) || strings.HasPrefix(value, `P"`) {
+		return decodeString(value)
+	}
+	if !isHexString(value) {
+		return "", fmt.Errorf("cannot decode WEP key as quoted or hex string")
+	}
+	return value, nil
+}
+
+func isHexString(value string) bool {
+	for i := range len(value) {
+		if !isHexDigit(value[i]) {
+			return false
+		}
+	}
+	return value != ""
+}
+
+func hasAny(values []string, targets ...string) bool {
+	for _, v := range values {
+		for _, t := range targets {
+			if v == t {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -51,6 +51,32 @@ func genBlks(spec []BlockSpec) Blocks {
 	return blocks
 }
 
+// position of a codeword in its block, where error correction codewords follow data codewords
+type codewordPosition struct {
+	block int
+	index int
+}
+
+// returns positions of codewords in the order of placement, interleaving data codewords
+// and then error correction codewords of all blocks, where later blocks may hold one data codeword more
+func (blocks Blocks) interleavedOrder() []codewordPosition {
+	order := make([]codewordPosition, 0)
+	for i := range blocks[len(blocks)-1].codewordLength {
+		for b, block := range blocks {
+			if i < block.codewordLength {
+				order = append(order, codewordPosition{block: b, index: i})
+			}
+		}
+	}
+	// error correction codewords have the same length for all blocks
+	for i := range blocks[0].blockLength - blocks[0].codewordLength {
+		for b, block := range blocks {
+			order = append(order, codewordPosition{block: b, index: block.codewordLength + i})
+		}
+	}
+	return order
+}
+
 // block structure used to encode with reed-solomon,
 // where block values are represented in bytes
 // referenced: https://en.wikiversity.org/wiki/Reed%E2%80%93Solomon_codes_for_coders/Additional_information#RS_block_size
@@ -0,0 +1,202 @@
+package qrcode
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/pasca-l/wifi-qrcode-generator/utils"
+	"github.com/pasca-l/wifi-qrcode-generator/utils/math"
+)
+
+// decodes source string of binary mode from pattern without margin,
+// which is used to check generated codes and therefore does not correct errors
+func Decode(p Pattern) (string, error) {
+	ver, err := versionFromSize(len(p))
+	if err != nil {
+		return "", err
+	}
+	ecl, mask, err := p.readFormatInformation()
+	if err != nil {
+		return "", err
+	}
+	roles, err := createRoleMap(ver)
+	if err != nil {
+		return "", err
+	}
+
+	// read data modules back in the order of placement, removing the mask
+	coords := dataCoordinates(roles.reservedPattern())
+	raw := make(utils.Bytes, len(coords)/8)
+	for bitIdx, coord := range coords[:len(raw)*8] {
+		if p[coord.Y][coord.X] != maskPatterns[mask](coord) {
+			raw[bitIdx>>3] |= 1 << (7 - (bitIdx & 0b0111))
+		}
+	}
+
+	msg, err := verifyErrorCorrection(raw, ver, ecl)
+	if err != nil {
+		return "", err
+	}
+	return parseSrc(msg, ver)
+}
+
+func versionFromSize(size int) (Version, error) {
+	ver := Version((size-21)/4 + 1)
+	if size < 21 || (size-21)%4 != 0 || ver > 40 {
+		return 0, fmt.Errorf("unexpected pattern size: %d", size)
+	}
+	return ver, nil
+}
+
+// reads first copy of format information, choosing the closest of all possible ones
+func (p Pattern) readFormatInformation() (ErrorCorrectionLevel, Mask, error) {
+	read := make(utils.Bits, 15)
+	for i := range 6 {
+		read[i] = utils.Bit(p[8][i])
+	}
+	read[6] = utils.Bit(p[8][7])
+	read[7] = utils.Bit(p[8][8])
+	read[8] = utils.Bit(p[7][8])
+	for i := 9; i < 15; i++ {
+		read[i] = utils.Bit(p[14-i][8])
+	}
+
+	bch := math.BCH{}
+	bestDist := len(read) + 1
+	var bestECL ErrorCorrectionLevel
+	var bestMask Mask
+	for _, ecl := range []ErrorCorrectionLevel{L, M, Q, H} {
+		for mask := range Mask(len(maskPatterns)) {
+			eclBytes, err := utils.NewBytes(int(ecl))
+			if err != nil {
+				return 0, 0, err
+			}
+			maskBytes, err := utils.NewBytes(int(mask))
+			if err != nil {
+				return 0, 0, err
+			}
+			encoded, err := bch.EncodeFormatInfo(eclBytes.ToBits(2), maskBytes.ToBits(3))
+			if err != nil {
+				return 0, 0, err
+			}
+
+			dist := 0
+			for i := range read {
+				if read[i] != encoded[i] {
+					dist++
+				}
+			}
+			if dist < bestDist {
+				bestDist, bestECL, bestMask = dist, ecl, mask
+			}
+		}
+	}
+
+	// format information is able to correct up to 3 errors
+	if bestDist > 3 {
+		return 0, 0, fmt.Errorf("unexpected format information: %s", read.ToBitString())
+	}
+	return bestECL, bestMask, nil
+}
+
+// splits interleaved codewords into blocks, and returns data codewords
+// if their error correction codewords match the ones encoded again
+func verifyErrorCorrection(raw utils.Bytes, ver Version, ecl ErrorCorrectionLevel) (utils.Bytes, error) {
+	blocks, exists := blockStructure[ver][ecl]
+	if !exists {
+		return utils.Bytes{}, fmt.Errorf("unexpected block structure for version: %d, ecl: %s", ver, ecl.ToString())
+	}
+
+	data := make([]utils.Bytes, len(blocks))
+	ecc := make([]utils.Bytes, len(blocks))
+	idx := 0
+	next := func() (utils.Byte, error) {
+		if idx >= len(raw) {
+			return 0, fmt.Errorf("codewords are shorter than block structure")
+		}
+		idx++
+		return raw[idx-1], nil
+	}
+	// data codewords are interleaved first, where later blocks may be longer by one
+	for i := range slices.MaxFunc(blocks, func(a, b Block) int { return a.codewordLength - b.codewordLength }).codewordLength {
+		for b, block := range blocks {
+			if i >= block.codewordLength {
+				continue
+			}
+			c, err := next()
+			if err != nil {
+				return utils.Bytes{}, err
+			}
+			data[b] = append(data[b], c)
+		}
+	}
+	// error correction codewords have the same length for all blocks
+	for range blocks[0].blockLength - blocks[0].codewordLength {
+		for b := range blocks {
+			c, err := next()
+			if err != nil {
+				return utils.Bytes{}, err
+			}
+			ecc[b] = append(ecc[b], c)
+		}
+	}
+
+	rs := math.ReedSolomon{}
+	msg := make(utils.Bytes, 0)
+	for b, block := range blocks {
+		encoded, err := rs.Encode(data[b], block.blockLength-block.codewordLength)
+		if err != nil {
+			return utils.Bytes{}, err
+		}
+		if !slices.Equal(encoded[block.codewordLength:], ecc[b]) {
+			return utils.Bytes{}, fmt.Errorf("error correction codewords of block %d do not match", b)
+		}
+		msg = append(msg, data[b]...)
+	}
+	return msg, nil
+}
+
+// parses source string of a single binary mode segment
+func parseSrc(msg utils.Bytes, ver Version) (string, error) {
+	bits := msg.ToBits(len(msg) * 8)
+	read := func(n int) (int, error) {
+		if n > len(bits) {
+			return 0, fmt.Errorf("data codewords are shorter than expected")
+		}
+		v := 0
+		for _, b := range bits[:n] {
+			v <<= 1
+			if b {
+				v |= 1
+			}
+		}
+		bits = bits[n:]
+		return v, nil
+	}
+
+	ind, err := read(4)
+	if err != nil {
+		return "", err
+	}
+	if EncodeModeIndicator(ind) != BinaryInd {
+		return "", fmt.Errorf("unexpected mode indicator: %04b", ind)
+	}
+	lengthField, err := getLengthField(ver, BinaryMode)
+	if err != nil {
+		return "", err
+	}
+	length, err := read(lengthField)
+	if err != nil {
+		return "", err
+	}
+
+	src := make([]byte, length)
+	for i := range src {
+		c, err := read(8)
+		if err != nil {
+			return "", err
+		}
+		src[i] = byte(c)
+	}
+	return string(src), nil
+}
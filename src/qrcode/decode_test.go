@@ -0,0 +1,88 @@
+package qrcode
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	testcases := []struct {
+		src     string
+		ecl     ErrorCorrectionLevel
+		version Version // smallest version fitting source if 0
+	}{
+		{src: "Hello World!", ecl: L},
+		{src: "Hello World!", ecl: H},
+		{src: `WIFI:T:WPA;S:"guest";P:"correct horse";;`, ecl: L},
+		{src: `WIFI:T:nopass;S:"cafe";;`, ecl: M},
+		// multiple blocks of equal length
+		{src: `WIFI:T:nopass;S:"cafe";;`, ecl: H, version: 3},
+		{src: `WIFI:T:WPA;S:"guest";P:"correct horse";;`, ecl: L, version: 6},
+		// multiple blocks, where later blocks hold one more data codeword
+		{src: `WIFI:T:WPA;S:"guest";P:"correct horse";;`, ecl: Q, version: 5},
+		{src: `WIFI:T:WPA;S:"guest";P:"correct horse";;`, ecl: H, version: 7},
+		{src: `WIFI:T:WPA;S:"guest";P:"correct horse";;`, ecl: L, version: 11},
+		{src: `WIFI:T:WPA;S:"guest";P:"correct horse";;`, ecl: H, version: 11},
+		{src: `WIFI:T:WPA;S:"guest";P:"correct horse";;`, ecl: H, version: 18},
+	}
+
+	for _, tt := range testcases {
+		t.Run("testing Decode()", func(t *testing.T) {
+			spec, err := NewQRCodeSpec(tt.src, tt.ecl)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.version != 0 {
+				spec.version = tt.version
+			}
+			code, err := NewQRCode(tt.src, spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if code.Version != spec.version {
+				t.Fatalf("NewQRCode() version = %d; expected %d", code.Version, spec.version)
+			}
+			got, err := Decode(code.Pattern)
+			if err != nil {
+				t.Fatalf("Decode() error = '%v'", err)
+			}
+			if got != tt.src {
+				t.Errorf("Decode() = %s; expected %s", got, tt.src)
+			}
+		})
+	}
+}
+
+func TestDecodeError(t *testing.T) {
+	spec, err := NewQRCodeSpec("Hello World!", L)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := NewQRCode("Hello World!", spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// flip a data module in the lower right corner
+	broken := NewPattern(len(code.Pattern))
+	for y := range broken {
+		copy(broken[y], code.Pattern[y])
+	}
+	broken[len(broken)-1][len(broken)-1] = !broken[len(broken)-1][len(broken)-1]
+
+	testcases := []struct {
+		pattern Pattern
+		wantErr error
+	}{
+		{pattern: NewPattern(22), wantErr: fmt.Errorf("unexpected pattern size: 22")},
+		{pattern: broken, wantErr: fmt.Errorf("error correction codewords of block 0 do not match")},
+	}
+
+	for _, tt := range testcases {
+		t.Run("testing Decode()", func(t *testing.T) {
+			_, err := Decode(tt.pattern)
+			if err == nil || err.Error() != tt.wantErr.Error() {
+				t.Errorf("Decode() error = '%v'; expected '%v'", err, tt.wantErr)
+			}
+		})
+	}
+}
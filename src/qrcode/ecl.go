@@ -1,5 +1,7 @@
 package qrcode
 
+import "fmt"
+
 type ErrorCorrectionLevel int
 
 const (
@@ -22,3 +24,20 @@ func (ecl ErrorCorrectionLevel) ToString() string {
 	}
 	return ""
 }
+
+func ToErrorCorrectionLevel(param string) (ErrorCorrectionLevel, error) {
+	switch param {
+	case "L":
+		return L, nil
+	case "M":
+		return M, nil
+	case "Q":
+		return Q, nil
+	case "H":
+		return H, nil
+	default:
+		return L, fmt.Errorf(
+			"cannot convert value '%s' to type ErrorCorrectionLevel", param,
+		)
+	}
+}
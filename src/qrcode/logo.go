@@ -0,0 +1,127 @@
+package qrcode
+
+import (
+	"fmt"
+	"image"
+	"math"
+)
+
+// error correction levels in the order of recovery capacity
+var eclStrength = []ErrorCorrectionLevel{L, M, Q, H}
+
+// plans a spec with a centered square area cleared for a logo, with its side
+// covering the given ratio of the code width
+// raises error correction level or version until the codewords covered by the
+// area stay within the recovery capacity of every block
+func NewQRCodeSpecWithLogo(src string, ecl ErrorCorrectionLevel, ratio float64) (QRCodeSpec, error) {
+	if ratio <= 0 || ratio >= 1 {
+		return QRCodeSpec{}, fmt.Errorf("logo ratio must be between 0 and 1: given %v", ratio)
+	}
+	mode := getEncodeMode(src)
+
+	minVersions := make(map[ErrorCorrectionLevel]Version)
+	for _, e := range eclStrength {
+		ver, err := getVersion(e, src)
+		if err == nil {
+			minVersions[e] = ver
+		}
+	}
+
+	// prefer smaller codes, then lower error correction levels for the same size
+	for v := Version(1); v <= 40; v++ {
+		for _, e := range eclStrength[eclIndex(ecl):] {
+			if minVer, exists := minVersions[e]; !exists || v < minVer {
+				continue
+			}
+			side := knockoutSide(v, ratio)
+			recoverable, err := isKnockoutRecoverable(v, e, side)
+			if err != nil {
+				return QRCodeSpec{}, err
+			}
+			if recoverable {
+				return QRCodeSpec{mode: mode, version: v, ecl: e, knockout: side}, nil
+			}
+		}
+	}
+
+	return QRCodeSpec{}, fmt.Errorf("logo covering %.0f%% of code width cannot be recovered by error correction", ratio*100)
+}
+
+func eclIndex(ecl ErrorCorrectionLevel) int {
+	for i, e := range eclStrength {
+		if e == ecl {
+			return i
+		}
+	}
+	return 0
+}
+
+// side of the cleared area in modules, keeping the same parity as code size to be centered
+func knockoutSide(ver Version, ratio float64) int {
+	size := calcSizeFromVersion(ver)
+	side := int(math.Ceil(float64(size) * ratio))
+	if side%2 != size%2 {
+		side++
+	}
+	return side
+}
+
+func knockoutArea(ver Version, side int) image.Rectangle {
+	start := (calcSizeFromVersion(ver) - side) / 2
+	return image.Rect(start, start, start+side, start+side)
+}
+
+// checks that the number of codewords covered by the area in each block does not exceed
+// half of its error correction codewords, where function modules in the area are kept
+func isKnockoutRecoverable(ver Version, ecl ErrorCorrectionLevel, side int) (bool, error) {
+	roles, err := createRoleMap(ver)
+	if err != nil {
+		return false, err
+	}
+	blocks, exists := blockStructure[ver][ecl]
+	if !exists {
+		return false, fmt.Errorf("unexpected block structure for version: %d, ecl: %s", ver, ecl.ToString())
+	}
+	// codewords are placed interleaved, as encoded by ApplyErrorCorrection
+	order := blocks.interleavedOrder()
+
+	area := knockoutArea(ver, side)
+	covered := make(map[int]bool) // covered codeword indices in the order of placement
+	for bitIdx, coord := range dataCoordinates(roles.reservedPattern()) {
+		// remainder bits after the last codeword are not used
+		if bitIdx >= len(order)*8 {
+			break
+		}
+		if coord.In(area) {
+			covered[bitIdx/8] = true
+		}
+	}
+
+	damaged := make([]int, len(blocks))
+	for idx := range covered {
+		damaged[order[idx].block]++
+	}
+	for i, block := range blocks {
+		if damaged[i] > (block.blockLength-block.codewordLength)/2 {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+func (c Coordinate) In(area image.Rectangle) bool {
+	return image.Pt(c.X, c.Y).In(area)
+}
+
+// clears data modules in the knockout area, for a logo to be drawn over
+// function modules are kept, as readers need them to locate and sample the code
+func (p Pattern) clearKnockout(area image.Rectangle, roles RoleMap) {
+	for y := area.Min.Y; y < area.Max.Y; y++ {
+		for x := area.Min.X; x < area.Max.X; x++ {
+			if roles[y][x] == DataRole {
+				p[y][x] = false
+			}
+		}
+	}
+}
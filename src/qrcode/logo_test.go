@@ -0,0 +1,129 @@
+package qrcode
+
+import (
+	"image"
+	"strings"
+	"testing"
+)
+
+func TestNewQRCodeSpecWithLogo(t *testing.T) {
+	src := "WIFI:T:WPA;S:guest;P:password123;;"
+	testcases := []struct {
+		ratio    float64
+		version  Version
+		ecl      ErrorCorrectionLevel
+		knockout int
+		wantErr  bool
+	}{
+		{ratio: 0.1, version: 3, ecl: L, knockout: 3},
+		{ratio: 0.2, version: 3, ecl: M, knockout: 7},
+		{ratio: 0.3, version: 4, ecl: Q, knockout: 11},
+		// interleaving spreads covered codewords over blocks of larger versions
+		{ratio: 0.5, version: 23, ecl: H, knockout: 55},
+		{ratio: 0.6, wantErr: true},
+		{ratio: 0, wantErr: true},
+	}
+
+	for _, tt := range testcases {
+		t.Run("testing NewQRCodeSpecWithLogo()", func(t *testing.T) {
+			got, err := NewQRCodeSpecWithLogo(src, L, tt.ratio)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewQRCodeSpecWithLogo() error = '%v'; expected error: %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.version != tt.version || got.ecl != tt.ecl || got.knockout != tt.knockout {
+				t.Errorf("NewQRCodeSpecWithLogo() = version %d, ecl %s, knockout %d; expected version %d, ecl %s, knockout %d",
+					got.version, got.ecl.ToString(), got.knockout, tt.version, tt.ecl.ToString(), tt.knockout,
+				)
+			}
+		})
+	}
+}
+
+func TestNewQRCodeWithKnockout(t *testing.T) {
+	src := "WIFI:T:WPA;S:guest;P:password123;;"
+	spec, err := NewQRCodeSpecWithLogo(src, L, 0.2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := NewQRCode(src, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := image.Rect(11, 11, 18, 18)
+	if code.Knockout != want {
+		t.Fatalf("QRCode.Knockout = %v; expected %v", code.Knockout, want)
+	}
+	for y := want.Min.Y; y < want.Max.Y; y++ {
+		for x := want.Min.X; x < want.Max.X; x++ {
+			if code.Pattern[y][x] {
+				t.Errorf("module at (%d, %d) in knockout area is dark", x, y)
+			}
+		}
+	}
+}
+
+func TestNewQRCodeWithKnockoutAlignment(t *testing.T) {
+	// needs version 7, which has an alignment pattern at the center
+	src := "WIFI:T:WPA;S:" + strings.Repeat("x", 32) + ";P:" + strings.Repeat("y", 63) + ";;"
+	spec, err := NewQRCodeSpecWithLogo(src, L, 0.2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spec.version != 7 {
+		t.Fatalf("NewQRCodeSpecWithLogo() = version %d; expected version 7", spec.version)
+	}
+	code, err := NewQRCode(src, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := image.Rect(18, 18, 27, 27)
+	if code.Knockout != want {
+		t.Fatalf("QRCode.Knockout = %v; expected %v", code.Knockout, want)
+	}
+	for y := want.Min.Y; y < want.Max.Y; y++ {
+		for x := want.Min.X; x < want.Max.X; x++ {
+			// alignment pattern of 5 modules is centered at (22, 22)
+			d := max(x-22, 22-x, y-22, 22-y)
+			switch {
+			case d > 2 && code.Pattern[y][x]:
+				t.Errorf("data module at (%d, %d) in knockout area is dark", x, y)
+			case d <= 2 && code.Pattern[y][x] != (d != 1):
+				t.Errorf("alignment module at (%d, %d) in knockout area is not kept", x, y)
+			}
+		}
+	}
+}
+
+func TestBlocksInterleavedOrder(t *testing.T) {
+	// version 5-Q has blocks of 15, 15, 16 and 16 data codewords, and 18 error correction codewords each
+	order := blockStructure[5][Q].interleavedOrder()
+	if len(order) != 134 {
+		t.Fatalf("Blocks.interleavedOrder() has %d codewords; expected 134", len(order))
+	}
+
+	testcases := []struct {
+		idx  int
+		want codewordPosition
+	}{
+		{idx: 0, want: codewordPosition{block: 0, index: 0}},
+		{idx: 3, want: codewordPosition{block: 3, index: 0}},
+		{idx: 60, want: codewordPosition{block: 2, index: 15}},
+		{idx: 61, want: codewordPosition{block: 3, index: 15}},
+		{idx: 62, want: codewordPosition{block: 0, index: 15}},
+		{idx: 65, want: codewordPosition{block: 3, index: 16}},
+		{idx: 133, want: codewordPosition{block: 3, index: 33}},
+	}
+
+	for _, tt := range testcases {
+		t.Run("testing Blocks.interleavedOrder()", func(t *testing.T) {
+			if order[tt.idx] != tt.want {
+				t.Errorf("Blocks.interleavedOrder()[%d] = %+v; expected %+v", tt.idx, order[tt.idx], tt.want)
+			}
+		})
+	}
+}
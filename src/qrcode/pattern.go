@@ -3,6 +3,7 @@ package qrcode
 import (
 	"fmt"
 	"slices"
+	"time"
 
 	"github.com/pasca-l/wifi-qrcode-generator/utils"
 	"github.com/pasca-l/wifi-qrcode-generator/utils/math"
@@ -77,39 +78,47 @@ func (p Pattern) FillPattern() Pattern {
 	return p
 }
 
-func GeneratePattern(msg utils.Bytes, spec QRCodeSpec) (Pattern, error) {
+// returns pattern along with the role of each module, and the mask applied to it
+func GeneratePattern(msg utils.Bytes, spec QRCodeSpec) (Pattern, RoleMap, Mask, error) {
+	return generatePattern(msg, spec, &Timings{})
+}
+
+// generates pattern, recording time spent on mask selection into timings
+func generatePattern(msg utils.Bytes, spec QRCodeSpec, timings *Timings) (Pattern, RoleMap, Mask, error) {
 	dim := calcSizeFromVersion(spec.version)
 	pat := NewPattern(dim)
-	reserved := NewPattern(dim)
 
 	err := pat.addFunctionPattern(spec.version)
 	if err != nil {
-		return nil, err
+		return nil, nil, 0, err
 	}
 	err = pat.addFormatInformation(spec.ecl, Mask(0))
 	if err != nil {
-		return nil, err
+		return nil, nil, 0, err
 	}
 	err = pat.addVersionInformation(spec.version)
 	if err != nil {
-		return nil, err
+		return nil, nil, 0, err
 	}
-	err = reserved.createReservedPatternMask(spec.version)
+	roles, err := createRoleMap(spec.version)
 	if err != nil {
-		return nil, err
+		return nil, nil, 0, err
 	}
+	reserved := roles.reservedPattern()
 	err = pat.applyData(msg, reserved)
 	if err != nil {
-		return nil, err
+		return nil, nil, 0, err
 	}
+	start := time.Now()
 	mask := pat.findBestMask(reserved)
+	timings.MaskSelection = time.Since(start)
 	pat.applyMask(mask, reserved)
 	err = pat.addFormatInformation(spec.ecl, mask)
 	if err != nil {
-		return nil, err
+		return nil, nil, 0, err
 	}
 
-	return pat, nil
+	return pat, roles, mask, nil
 }
 
 func calcSizeFromVersion(ver Version) int {
@@ -315,82 +324,23 @@ func (p Pattern) addVersionInformation(ver Version) error {
 	return nil
 }
 
-func (p Pattern) createReservedPatternMask(ver Version) error {
-	// reserved areas for finder patterns with separator
-	finderPattern := NewPattern(8).FillPattern()
-	p, err := p.DrawPattern(finderPattern, Coordinate{0, 0})
-	if err != nil {
-		return err
-	}
-	p, err = p.DrawPattern(finderPattern, Coordinate{0, len(p) - 8})
-	if err != nil {
-		return err
-	}
-	p, err = p.DrawPattern(finderPattern, Coordinate{len(p) - 8, 0})
-	if err != nil {
-		return err
-	}
-
-	// reserved areas for timing patterns
-	for i := 8; i < len(p)-8; i++ {
-		p[6][i] = true
-		p[i][6] = true
-	}
-
-	// reserved areas for alignment patterns
-	alignmentPattern := NewPattern(5).FillPattern()
-	coords, err := calcAlignmentPatternCoords(ver)
-	if err != nil {
-		return err
-	}
-	for _, coord := range coords {
-		p, err = p.DrawPattern(alignmentPattern, coord)
-		if err != nil {
-			return err
-		}
-	}
-
-	// reserved areas for format information
-	for i := range 6 {
-		p[8][i] = true
-	}
-	p[8][7] = true
-	p[8][8] = true
-	p[7][8] = true
-	for i := 9; i < 15; i++ {
-		p[14-i][8] = true
-	}
-	for i := range 8 {
-		p[len(p)-1-i][8] = true
-	}
-	for i := 8; i < 15; i++ {
-		p[8][len(p)-15+i] = true
-	}
-	p[8][len(p)-8] = true
-
-	// reserved areas for version information
-	if ver >= 7 {
-		for i := range 18 {
-			a := len(p) - 11 + i%3
-			b := i / 3
-			p[a][b] = true
-			p[b][a] = true
+func (p Pattern) applyData(msg utils.Bytes, reserved Pattern) error {
+	msgBytes := msg.ToNativeBytes()
+	for bitIdx, coord := range dataCoordinates(reserved) {
+		// ensure message is not out of bounds
+		if bitIdx >= len(msgBytes)*8 {
+			break
 		}
+		p[coord.Y][coord.X] = (msgBytes[bitIdx>>3]>>(7-(bitIdx&0b0111)))&1 != 0
 	}
 
 	return nil
 }
 
-func (p Pattern) applyData(msg utils.Bytes, reserved Pattern) (err error) {
-	defer func() {
-		if r := recover(); r != nil {
-			err = fmt.Errorf("recovered panic: %v", r)
-		}
-	}()
-
-	size := len(p)
-	bitIdx := 0
-	msgBytes := msg.ToNativeBytes()
+// returns coordinates of modules not reserved, in the order of data placement
+func dataCoordinates(reserved Pattern) []Coordinate {
+	size := len(reserved)
+	coords := make([]Coordinate, 0, size*size)
 
 	// traverse the grid in a zigzag pattern
 	for col := size - 1; col > 0; col -= 2 {
@@ -411,17 +361,12 @@ func (p Pattern) applyData(msg utils.Bytes, reserved Pattern) (err error) {
 				if reserved[y][x] {
 					continue
 				}
-
-				// apply message bits
-				if bitIdx < len(msgBytes)*8 { // ensure message is not out of bounds
-					p[y][x] = (msgBytes[bitIdx>>3]>>(7-(bitIdx&0b0111)))&1 != 0
-					bitIdx++
-				}
+				coords = append(coords, Coordinate{X: x, Y: y})
 			}
 		}
 	}
 
-	return nil
+	return coords
 }
 
 func (p Pattern) applyMask(mask Mask, reserved Pattern) {
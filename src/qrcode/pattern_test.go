@@ -139,3 +139,32 @@ func TestAddFunctionalPattern(t *testing.T) {
 		})
 	}
 }
+
+func TestCreateRoleMap(t *testing.T) {
+	testcases := []struct {
+		ver   Version
+		coord Coordinate
+		want  ModuleRole
+	}{
+		{ver: 1, coord: Coordinate{X: 3, Y: 3}, want: FinderRole},
+		{ver: 1, coord: Coordinate{X: 7, Y: 2}, want: SeparatorRole},
+		{ver: 1, coord: Coordinate{X: 10, Y: 6}, want: TimingRole},
+		{ver: 1, coord: Coordinate{X: 8, Y: 13}, want: FormatRole},
+		{ver: 1, coord: Coordinate{X: 10, Y: 10}, want: DataRole},
+		{ver: 2, coord: Coordinate{X: 18, Y: 18}, want: AlignmentRole},
+		{ver: 7, coord: Coordinate{X: 34, Y: 0}, want: VersionRole},
+		{ver: 7, coord: Coordinate{X: 22, Y: 6}, want: AlignmentRole},
+	}
+
+	for _, tt := range testcases {
+		t.Run("testing createRoleMap()", func(t *testing.T) {
+			roles, err := createRoleMap(tt.ver)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := roles[tt.coord.Y][tt.coord.X]; got != tt.want {
+				t.Errorf("createRoleMap(%v) at %+v = %v; expected %v", tt.ver, tt.coord, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package qrcode
+
+import (
+	"crypto/pbkdf2"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+)
+
+// digits of PSK in hex, encoding 256 bits
+const hexPSKLength int = 64
+
+// derives WPA PSK from passphrase and SSID as PBKDF2-HMAC-SHA1 with 4096 iterations
+// referenced: IEEE 802.11i-2004, Annex H.4
+func DerivePSK(passphrase string, ssid string) ([]byte, error) {
+	return pbkdf2.Key(sha1.New, passphrase, []byte(ssid), 4096, 32)
+}
+
+// reports whether password is PSK of 64 hex digits, which cannot be a passphrase of up to 63 characters
+func IsHexPSK(password string) bool {
+	if len(password) != hexPSKLength {
+		return false
+	}
+	_, err := hex.DecodeString(password)
+	return err == nil
+}
+
+// returns password of WPA network, deriving PSK from passphrase if requested,
+// along with whether it is a PSK to be encoded unquoted
+func toWPAPassword(password string, ssid string, derive bool) (string, bool, error) {
+	if IsHexPSK(password) {
+		return password, true, nil
+	}
+	if len(password) >= hexPSKLength {
+		return "", false, fmt.Errorf("WPA password must be a passphrase of up to 63 characters, or a PSK of 64 hex digits")
+	}
+	if !derive {
+		return password, false, nil
+	}
+
+	if len(password) < 8 {
+		return "", false, fmt.Errorf("passphrase must be 8 to 63 characters to derive PSK: given %d", len(password))
+	}
+	for _, c := range password {
+		if c < ' ' || c > '~' {
+			return "", false, fmt.Errorf("passphrase must be printable ASCII to derive PSK")
+		}
+	}
+	psk, err := DerivePSK(password, ssid)
+	if err != nil {
+		return "", false, err
+	}
+	return hex.EncodeToString(psk), true, nil
+}
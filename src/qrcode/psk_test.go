@@ -0,0 +1,93 @@
+package qrcode
+
+import (
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestDerivePSK(t *testing.T) {
+	// referenced: IEEE 802.11i-2004, Annex H.4.2
+	testcases := []struct {
+		passphrase string
+		ssid       string
+		want       string
+	}{
+		{passphrase: "password", ssid: "IEEE", want: "f42c6fc52df0ebef9ebb4b90b38a5f902e83fe1b135a70e23aed762e9710a12e"},
+		{passphrase: "ThisIsAPassword", ssid: "ThisIsASSID", want: "0dc0d6eb90555ed6419756b9a15ec3e3209b63df707dd508d14581f8982721af"},
+		{passphrase: strings.Repeat("a", 32), ssid: strings.Repeat("Z", 32), want: "becb93866bb8c3832cb777c2f559807c8c59afcb6eae734885001300a981cc62"},
+	}
+
+	for _, tt := range testcases {
+		t.Run("testing DerivePSK()", func(t *testing.T) {
+			got, err := DerivePSK(tt.passphrase, tt.ssid)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if hex.EncodeToString(got) != tt.want {
+				t.Errorf("DerivePSK(%q, %q) = %x; expected %s", tt.passphrase, tt.ssid, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWifiSpecEncodePSK(t *testing.T) {
+	psk := "f42c6fc52df0ebef9ebb4b90b38a5f902e83fe1b135a70e23aed762e9710a12e"
+
+	testcases := []struct {
+		params  url.Values
+		want    string
+		wantErr error
+	}{
+		{
+			params: url.Values{"ssid": {"IEEE"}, "password": {"password"}, "encryption": {"WPA"}},
+			want:   `WIFI:T:WPA;S:"IEEE";P:"password";;`,
+		},
+		{
+			params: url.Values{"ssid": {"IEEE"}, "password": {"password"}, "encryption": {"WPA"}, "psk": {"true"}},
+			want:   `WIFI:T:WPA;S:"IEEE";P:` + psk + `;;`,
+		},
+		// supplied PSK is recognised, as passphrases are up to 63 characters
+		{
+			params: url.Values{"ssid": {"IEEE"}, "password": {psk}, "encryption": {"WPA"}},
+			want:   `WIFI:T:WPA;S:"IEEE";P:` + psk + `;;`,
+		},
+		{
+			params: url.Values{"ssid": {"IEEE"}, "password": {strings.ToUpper(psk)}, "encryption": {"WPA"}, "psk": {"true"}},
+			want:   `WIFI:T:WPA;S:"IEEE";P:` + strings.ToUpper(psk) + `;;`,
+		},
+		{
+			params:  url.Values{"ssid": {"IEEE"}, "password": {strings.Repeat("g", 64)}, "encryption": {"WPA"}},
+			wantErr: errors.New("WPA password must be a passphrase of up to 63 characters, or a PSK of 64 hex digits"),
+		},
+		{
+			params:  url.Values{"ssid": {"IEEE"}, "password": {"short"}, "encryption": {"WPA"}, "psk": {"true"}},
+			wantErr: errors.New("passphrase must be 8 to 63 characters to derive PSK: given 5"),
+		},
+		{
+			params:  url.Values{"ssid": {"IEEE"}, "password": {"パスワードです"}, "encryption": {"WPA"}, "psk": {"true"}},
+			wantErr: errors.New("passphrase must be printable ASCII to derive PSK"),
+		},
+		{
+			params:  url.Values{"ssid": {"IEEE"}, "password": {"password"}, "encryption": {"WEP"}, "psk": {"true"}},
+			wantErr: errors.New("PSK is only derived for WPA: given WEP"),
+		},
+	}
+
+	for _, tt := range testcases {
+		t.Run("testing WifiSpec.Encode()", func(t *testing.T) {
+			spec, err := NewWifiSpec(tt.params)
+			if (err == nil) != (tt.wantErr == nil) || (err != nil && err.Error() != tt.wantErr.Error()) {
+				t.Fatalf("NewWifiSpec() error = '%v'; expected '%v'", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := spec.Encode(); got != tt.want {
+				t.Errorf("WifiSpec.Encode() = %s; expected %s", got, tt.want)
+			}
+		})
+	}
+}
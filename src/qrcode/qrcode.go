@@ -2,38 +2,72 @@ package qrcode
 
 import (
 	"fmt"
+	"image"
+	"time"
 
 	"github.com/pasca-l/wifi-qrcode-generator/utils"
 	"github.com/pasca-l/wifi-qrcode-generator/utils/math"
 )
 
 type QRCode struct {
-	Pattern Pattern
+	Pattern  Pattern
+	Roles    RoleMap
+	Knockout image.Rectangle // area cleared for a logo in module coordinates, empty if none
+	Version  Version
+	ECL      ErrorCorrectionLevel
+	Mask     Mask // mask pattern chosen by penalty score
+	Timings  Timings
+}
+
+// time spent on each stage of generation
+type Timings struct {
+	Encode          time.Duration
+	ErrorCorrection time.Duration
+	MaskSelection   time.Duration
 }
 
 type QRCodeSpec struct {
-	mode    EncodeMode
-	version Version
-	ecl     ErrorCorrectionLevel
+	mode     EncodeMode
+	version  Version
+	ecl      ErrorCorrectionLevel
+	knockout int // side of area cleared for a logo in modules, 0 if none
 }
 
 func NewQRCode(src string, spec QRCodeSpec) (QRCode, error) {
+	var timings Timings
+	start := time.Now()
 	msg, err := spec.EncodeSrc(src)
 	if err != nil {
 		return QRCode{}, err
 	}
+	timings.Encode = time.Since(start)
+
+	start = time.Now()
 	encoded, err := spec.ApplyErrorCorrection(msg)
 	if err != nil {
 		return QRCode{}, err
 	}
+	timings.ErrorCorrection = time.Since(start)
 
-	pattern, err := GeneratePattern(encoded, spec)
+	pattern, roles, mask, err := generatePattern(encoded, spec, &timings)
 	if err != nil {
 		return QRCode{}, err
 	}
 
+	var knockout image.Rectangle
+	if spec.knockout > 0 {
+		knockout = knockoutArea(spec.version, spec.knockout)
+		pattern.clearKnockout(knockout, roles)
+	}
+
 	return QRCode{
-		Pattern: pattern,
+		Pattern:  pattern,
+		Roles:    roles,
+		Knockout: knockout,
+		Version:  spec.version,
+		ECL:      spec.ecl,
+		Mask:     mask,
+		Timings:  timings,
 	}, nil
 }
 
@@ -52,6 +86,14 @@ func NewQRCodeSpec(src string, ecl ErrorCorrectionLevel) (QRCodeSpec, error) {
 	}, nil
 }
 
+func (spec QRCodeSpec) Version() Version {
+	return spec.version
+}
+
+func (spec QRCodeSpec) ECL() ErrorCorrectionLevel {
+	return spec.ecl
+}
+
 func (spec QRCodeSpec) EncodeSrc(src string) (utils.Bytes, error) {
 	msg := utils.Bits{}
 
@@ -97,26 +139,34 @@ func (spec QRCodeSpec) EncodeSrc(src string) (utils.Bytes, error) {
 	return msgBytes, nil
 }
 
+// encodes data codewords block by block, and interleaves codewords of all blocks
 func (spec QRCodeSpec) ApplyErrorCorrection(msg utils.Bytes) (utils.Bytes, error) {
 	blocks, exists := blockStructure[spec.version][spec.ecl]
 	if !exists {
 		return utils.Bytes{}, fmt.Errorf("unexpected block structure for version: %d, ecl: %s", spec.version, spec.ecl.ToString())
 	}
-
-	rs := math.ReedSolomon{}
-	result := make(utils.Bytes, 0)
+	dataLength := 0
 	for _, block := range blocks {
-		subMsg := msg
-		if len(msg) > block.blockLength {
-			subMsg = msg[:block.blockLength]
-			msg = msg[block.blockLength:] // update message to its remaining part
-		}
+		dataLength += block.codewordLength
+	}
+	if len(msg) != dataLength {
+		return utils.Bytes{}, fmt.Errorf("message must have %d codewords: given %d", dataLength, len(msg))
+	}
 
-		encoded, err := rs.Encode(subMsg, block.blockLength-block.codewordLength)
+	rs := math.ReedSolomon{}
+	encoded := make([]utils.Bytes, len(blocks))
+	for b, block := range blocks {
+		var err error
+		encoded[b], err = rs.Encode(msg[:block.codewordLength], block.blockLength-block.codewordLength)
 		if err != nil {
 			return utils.Bytes{}, err
 		}
-		result = append(result, encoded...)
+		msg = msg[block.codewordLength:] // update message to its remaining part
+	}
+
+	result := make(utils.Bytes, 0)
+	for _, pos := range blocks.interleavedOrder() {
+		result = append(result, encoded[pos.block][pos.index])
 	}
 
 	return result, nil
@@ -0,0 +1,116 @@
+package qrcode
+
+import "fmt"
+
+// function of each module, so that renderers can style them differently
+type ModuleRole int
+
+const (
+	DataRole      ModuleRole = iota // data and error correction codewords
+	FinderRole                      // 7x7 finder patterns at three corners
+	SeparatorRole                   // light border around finder patterns
+	TimingRole                      // alternating lines between finder patterns
+	AlignmentRole                   // 5x5 alignment patterns
+	FormatRole                      // format information, including the dark module
+	VersionRole                     // version information, for versions >= 7
+)
+
+type RoleMap [][]ModuleRole
+
+func NewRoleMap(size int) RoleMap {
+	roles := make(RoleMap, size)
+	for i := range roles {
+		roles[i] = make([]ModuleRole, size)
+	}
+	return roles
+}
+
+func (m RoleMap) fill(role ModuleRole, coord Coordinate, size int) error {
+	if coord.Y+size > len(m) || coord.X+size > len(m) {
+		return fmt.Errorf("invalid area to fill with size: %d at coordinate: %+v", size, coord)
+	}
+	for y := coord.Y; y < coord.Y+size; y++ {
+		for x := coord.X; x < coord.X+size; x++ {
+			m[y][x] = role
+		}
+	}
+	return nil
+}
+
+func createRoleMap(ver Version) (RoleMap, error) {
+	m := NewRoleMap(calcSizeFromVersion(ver))
+	size := len(m)
+
+	// finder patterns with separator
+	for _, coord := range []Coordinate{{0, 0}, {0, size - 8}, {size - 8, 0}} {
+		err := m.fill(SeparatorRole, coord, 8)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, coord := range []Coordinate{{0, 0}, {0, size - 7}, {size - 7, 0}} {
+		err := m.fill(FinderRole, coord, 7)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// timing patterns
+	for i := 8; i < size-8; i++ {
+		m[6][i] = TimingRole
+		m[i][6] = TimingRole
+	}
+
+	// alignment patterns, which may overlap timing patterns
+	coords, err := calcAlignmentPatternCoords(ver)
+	if err != nil {
+		return nil, err
+	}
+	for _, coord := range coords {
+		err = m.fill(AlignmentRole, coord, 5)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// format information
+	for i := range 6 {
+		m[8][i] = FormatRole
+	}
+	m[8][7] = FormatRole
+	m[8][8] = FormatRole
+	m[7][8] = FormatRole
+	for i := 9; i < 15; i++ {
+		m[14-i][8] = FormatRole
+	}
+	for i := range 8 {
+		m[size-1-i][8] = FormatRole
+	}
+	for i := 8; i < 15; i++ {
+		m[8][size-15+i] = FormatRole
+	}
+	m[8][size-8] = FormatRole
+
+	// version information
+	if ver >= 7 {
+		for i := range 18 {
+			a := size - 11 + i%3
+			b := i / 3
+			m[a][b] = VersionRole
+			m[b][a] = VersionRole
+		}
+	}
+
+	return m, nil
+}
+
+// returns pattern marking modules that cannot hold data
+func (m RoleMap) reservedPattern() Pattern {
+	reserved := NewPattern(len(m))
+	for y, row := range m {
+		for x, role := range row {
+			reserved[y][x] = role != DataRole
+		}
+	}
+	return reserved
+}
@@ -3,32 +3,115 @@ package qrcode
 import (
 	"fmt"
 	"net/url"
+	"strings"
 )
 
 type WifiSpec struct {
 	ssid       string
 	password   string
 	encryption Encryption
+	hidden     bool    // network does not broadcast its SSID
+	hexPSK     bool    // password is PSK of 64 hex digits, encoded unquoted
+	eap        EAPSpec // only used for WPA2-EAP
+}
+
+// credentials of enterprise networks, where password is the user password
+type EAPSpec struct {
+	Method            EAPMethod
+	Phase2            Phase2Method // inner authentication of tunneled methods, optional
+	Identity          string       // optional, asked by the device if empty
+	AnonymousIdentity string       // outer identity sent before the tunnel is established, optional
 }
 
 type Encryption string
 
 const (
-	NoPass Encryption = "nopass"
-	WEP    Encryption = "WEP"
-	WPA    Encryption = "WPA"
+	NoPass  Encryption = "nopass"
+	WEP     Encryption = "WEP"
+	WPA     Encryption = "WPA"
+	WPA2EAP Encryption = "WPA2-EAP"
+)
+
+// EAP methods that can be provisioned by codes, as client certificates for TLS cannot be
+type EAPMethod string
+
+const (
+	PEAP     EAPMethod = "PEAP"
+	TTLS     EAPMethod = "TTLS"
+	PWD      EAPMethod = "PWD"
+	SIM      EAPMethod = "SIM"
+	AKA      EAPMethod = "AKA"
+	AKAPrime EAPMethod = "AKA_PRIME"
 )
 
+type Phase2Method string
+
+const (
+	NoPhase2       Phase2Method = "NONE"
+	PAPPhase2      Phase2Method = "PAP"
+	MSCHAPPhase2   Phase2Method = "MSCHAP"
+	MSCHAPV2Phase2 Phase2Method = "MSCHAPV2"
+	GTCPhase2      Phase2Method = "GTC"
+)
+
+// characters with special meaning in WIFI: codes, which readers expect to be escaped by backslash
+// referenced: https://github.com/zxing/zxing/wiki/Barcode-Contents#wi-fi-network-config-android-ios-11
+const SpecialCharacters string = `\;,:"`
+
 func NewWifiSpec(params url.Values) (WifiSpec, error) {
 	enc, err := toEncryption(params.Get("encryption"))
 	if err != nil {
 		return WifiSpec{}, err
 	}
 
-	return WifiSpec{
+	spec := WifiSpec{
 		ssid:       params.Get("ssid"),
 		password:   params.Get("password"),
 		encryption: enc,
+		hidden:     params.Get("hidden") == "true",
+	}
+	// PSK is derived on request, so that passphrases are kept out of codes
+	derive := params.Get("psk") == "true"
+	if enc == WPA {
+		spec.password, spec.hexPSK, err = toWPAPassword(spec.password, spec.ssid, derive)
+		if err != nil {
+			return WifiSpec{}, err
+		}
+	} else if derive {
+		return WifiSpec{}, fmt.Errorf("PSK is only derived for WPA: given %s", enc)
+	}
+	if enc == WPA2EAP {
+		spec.eap, err = toEAPSpec(params)
+		if err != nil {
+			return WifiSpec{}, err
+		}
+	}
+	return spec, nil
+}
+
+func toEAPSpec(params url.Values) (EAPSpec, error) {
+	method := EAPMethod(params.Get("eap_method"))
+	switch method {
+	case PEAP, TTLS, PWD, SIM, AKA, AKAPrime:
+	default:
+		return EAPSpec{}, fmt.Errorf(
+			"cannot convert value '%s' to type EAPMethod", method,
+		)
+	}
+	phase2 := Phase2Method(params.Get("phase2"))
+	switch phase2 {
+	case "", NoPhase2, PAPPhase2, MSCHAPPhase2, MSCHAPV2Phase2, GTCPhase2:
+	default:
+		return EAPSpec{}, fmt.Errorf(
+			"cannot convert value '%s' to type Phase2Method", phase2,
+		)
+	}
+
+	return EAPSpec{
+		Method:            method,
+		Phase2:            phase2,
+		Identity:          params.Get("identity"),
+		AnonymousIdentity: params.Get("anonymous_identity"),
 	}, nil
 }
 
@@ -40,6 +123,8 @@ func toEncryption(param string) (Encryption, error) {
 		return WEP, nil
 	case string(WPA):
 		return WPA, nil
+	case string(WPA2EAP):
+		return WPA2EAP, nil
 	default:
 		return Encryption(""), fmt.Errorf(
 			"cannot convert value '%s' to type Encryption", param,
@@ -47,10 +132,73 @@ func toEncryption(param string) (Encryption, error) {
 	}
 }
 
+func (s WifiSpec) SSID() string {
+	return s.ssid
+}
+
+func (s WifiSpec) Password() string {
+	return s.password
+}
+
+func (s WifiSpec) Encryption() Encryption {
+	return s.encryption
+}
+
+func (s WifiSpec) Hidden() bool {
+	return s.hidden
+}
+
+func (s WifiSpec) HexPSK() bool {
+	return s.hexPSK
+}
+
+func (s WifiSpec) EAP() EAPSpec {
+	return s.eap
+}
+
 func (s WifiSpec) Encode() string {
 	// referenced: https://github.com/zxing/zxing/wiki/Barcode-Contents#wi-fi-network-config-android-ios-11
+	hidden := ""
+	if s.hidden {
+		hidden = "H:true;"
+	}
+	// quotes tell passphrases apart from PSK in hex
+	password := fmt.Sprintf("\"%s\"", escape(s.password))
+	if s.hexPSK {
+		password = s.password
+	}
 	return fmt.Sprintf(
-		"WIFI:T:%s;S:\"%s\";P:\"%s\";;",
-		s.encryption, s.ssid, s.password,
+		"WIFI:T:%s;S:\"%s\";P:%s;%s%s;",
+		s.encryption, escape(s.ssid), password, hidden, s.encodeEAP(),
 	)
 }
+
+// referenced: https://source.android.com/docs/core/connect/wifi-easy-connect
+func (s WifiSpec) encodeEAP() string {
+	if s.encryption != WPA2EAP {
+		return ""
+	}
+	fields := fmt.Sprintf("E:%s;", s.eap.Method)
+	if s.eap.AnonymousIdentity != "" {
+		fields += fmt.Sprintf("A:\"%s\";", escape(s.eap.AnonymousIdentity))
+	}
+	if s.eap.Identity != "" {
+		fields += fmt.Sprintf("I:\"%s\";", escape(s.eap.Identity))
+	}
+	if s.eap.Phase2 != "" {
+		fields += fmt.Sprintf("PH2:%s;", s.eap.Phase2)
+	}
+	return fields
+}
+
+// escapes special characters by backslash, so that values cannot end their fields early
+func escape(value string) string {
+	escaped := new(strings.Builder)
+	for _, c := range value {
+		if strings.ContainsRune(SpecialCharacters, c) {
+			escaped.WriteByte('\\')
+		}
+		escaped.WriteRune(c)
+	}
+	return escaped.String()
+}
@@ -0,0 +1,41 @@
+package qrcode
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestWifiSpecEncode(t *testing.T) {
+	testcases := []struct {
+		params url.Values
+		want   string
+	}{
+		{
+			params: url.Values{"ssid": {"guest"}, "password": {"correct horse"}, "encryption": {"WPA"}},
+			want:   `WIFI:T:WPA;S:"guest";P:"correct horse";;`,
+		},
+		{
+			params: url.Values{"ssid": {`"Cafe"; Lobby`}, "password": {`a\b;c,d:e"f`}, "encryption": {"WPA"}, "hidden": {"true"}},
+			want:   `WIFI:T:WPA;S:"\"Cafe\"\; Lobby";P:"a\\b\;c\,d\:e\"f";H:true;;`,
+		},
+		{
+			params: url.Values{
+				"ssid": {"Corp"}, "password": {"p;w"}, "encryption": {"WPA2-EAP"},
+				"eap_method": {"TTLS"}, "phase2": {"PAP"}, "identity": {`CORP\alice`}, "anonymous_identity": {"anon:ymous"},
+			},
+			want: `WIFI:T:WPA2-EAP;S:"Corp";P:"p\;w";E:TTLS;A:"anon\:ymous";I:"CORP\\alice";PH2:PAP;;`,
+		},
+	}
+
+	for _, tt := range testcases {
+		t.Run("testing WifiSpec.Encode()", func(t *testing.T) {
+			spec, err := NewWifiSpec(tt.params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := spec.Encode(); got != tt.want {
+				t.Errorf("WifiSpec.Encode() = %s; expected %s", got, tt.want)
+			}
+		})
+	}
+}
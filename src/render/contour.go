@@ -0,0 +1,108 @@
+package render
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pasca-l/wifi-qrcode-generator/qrcode"
+)
+
+// corner of a module, where (x, y) is the upper left corner of module (x, y)
+type point struct {
+	x, y int
+}
+
+// traces outlines of dark regions as closed polygons, in module coordinates
+// outer outlines run clockwise and holes counterclockwise, so that holes are
+// left unfilled with non-zero winding rule
+func traceContours(pat qrcode.Pattern) [][]point {
+	isDark := func(x, y int) bool {
+		return y >= 0 && y < len(pat) && x >= 0 && x < len(pat[y]) && pat[y][x]
+	}
+
+	// collect edges between dark and light modules, keeping dark modules on the right
+	next := make(map[point][]point)
+	starts := make([]point, 0)
+	addEdge := func(from, to point) {
+		next[from] = append(next[from], to)
+		starts = append(starts, from)
+	}
+	for y, row := range pat {
+		for x, cell := range row {
+			if !cell {
+				continue
+			}
+			if !isDark(x, y-1) {
+				addEdge(point{x, y}, point{x + 1, y})
+			}
+			if !isDark(x+1, y) {
+				addEdge(point{x + 1, y}, point{x + 1, y + 1})
+			}
+			if !isDark(x, y+1) {
+				addEdge(point{x + 1, y + 1}, point{x, y + 1})
+			}
+			if !isDark(x-1, y) {
+				addEdge(point{x, y + 1}, point{x, y})
+			}
+		}
+	}
+
+	// chain edges into closed polygons, as every corner has equal incoming and outgoing edges
+	contours := make([][]point, 0)
+	for _, start := range starts {
+		for len(next[start]) > 0 {
+			contour := []point{start}
+			current := start
+			for {
+				to := next[current][0]
+				next[current] = next[current][1:]
+				if to == start {
+					break
+				}
+				contour = append(contour, to)
+				current = to
+			}
+			contours = append(contours, simplifyContour(contour))
+		}
+	}
+
+	return contours
+}
+
+// removes corners lying on a straight line between their neighbors
+func simplifyContour(contour []point) []point {
+	simplified := make([]point, 0, len(contour))
+	for i, p := range contour {
+		prev := contour[(i+len(contour)-1)%len(contour)]
+		next := contour[(i+1)%len(contour)]
+		if (prev.x == p.x && p.x == next.x) || (prev.y == p.y && p.y == next.y) {
+			continue
+		}
+		simplified = append(simplified, p)
+	}
+	return simplified
+}
+
+// converts contours into SVG path data, using relative horizontal and vertical lines
+func contourPathData(contours [][]point) string {
+	d := new(strings.Builder)
+	for _, contour := range contours {
+		d.WriteString("M" + strconv.Itoa(contour[0].x) + " " + strconv.Itoa(contour[0].y))
+		for i := 1; i < len(contour); i++ {
+			prev, p := contour[i-1], contour[i]
+			if p.y == prev.y {
+				d.WriteString("h" + strconv.Itoa(p.x-prev.x))
+			} else {
+				d.WriteString("v" + strconv.Itoa(p.y-prev.y))
+			}
+		}
+		d.WriteString("z")
+	}
+	return d.String()
+}
+
+// returns SVG path data outlining dark modules, in module coordinates
+// so that the code can be composed into other documents
+func ContourPath(pat qrcode.Pattern) string {
+	return contourPathData(traceContours(pat))
+}
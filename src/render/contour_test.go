@@ -0,0 +1,97 @@
+package render
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/pasca-l/wifi-qrcode-generator/qrcode"
+)
+
+// computes winding number of contours around the center of module (x, y)
+func windingNumber(contours [][]point, x, y int) int {
+	cx, cy := float64(x)+0.5, float64(y)+0.5
+	winding := 0
+	for _, contour := range contours {
+		for i, p := range contour {
+			next := contour[(i+1)%len(contour)]
+			// count vertical edges crossed by a ray towards right
+			if p.x != next.x || float64(p.x) < cx {
+				continue
+			}
+			if float64(min(p.y, next.y)) < cy && cy < float64(max(p.y, next.y)) {
+				if next.y > p.y {
+					winding++
+				} else {
+					winding--
+				}
+			}
+		}
+	}
+	return winding
+}
+
+func TestTraceContours(t *testing.T) {
+	src := "WIFI:T:WPA;S:\"ssid\";P:\"password\";;"
+	spec, err := qrcode.NewQRCodeSpec(src, qrcode.L)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := qrcode.NewQRCode(src, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testcases := []struct {
+		pat qrcode.Pattern
+	}{
+		{
+			// ring with a hole, and modules touching diagonally
+			pat: qrcode.Pattern{
+				{true, true, true, false},
+				{true, false, true, false},
+				{true, true, true, false},
+				{false, false, false, true},
+			},
+		},
+		{
+			pat: WithMargin(code.Pattern, 4),
+		},
+	}
+
+	for _, tt := range testcases {
+		t.Run("testing traceContours()", func(t *testing.T) {
+			contours := traceContours(tt.pat)
+			for y, row := range tt.pat {
+				for x, cell := range row {
+					if got := windingNumber(contours, x, y) != 0; got != cell {
+						t.Errorf("traceContours() fills module (%d, %d) = %v; expected %v", x, y, got, cell)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestContourPath(t *testing.T) {
+	testcases := []struct {
+		pat  qrcode.Pattern
+		want string
+	}{
+		{
+			pat: qrcode.Pattern{
+				{true, true, true},
+				{true, false, true},
+				{true, true, true},
+			},
+			want: "M0 0h3v3h-3zM2 1h-1v1h1z",
+		},
+	}
+
+	for _, tt := range testcases {
+		t.Run("testing ContourPath()", func(t *testing.T) {
+			if got := ContourPath(tt.pat); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ContourPath() = %v; expected %v", got, tt.want)
+			}
+		})
+	}
+}
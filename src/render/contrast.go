@@ -0,0 +1,91 @@
+package render
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"slices"
+)
+
+// minimum contrast ratio between dark and light modules for scanners to read codes
+const minContrastRatio float64 = 3
+
+// contrast ratio below which some scanners may fail, such as in dim light
+const recommendedContrastRatio float64 = 4.5
+
+// checks that every color drawing modules contrasts enough with the background,
+// returning error for unreadable combinations and warnings for risky ones
+// referenced: https://www.w3.org/TR/WCAG21/#dfn-contrast-ratio
+func CheckContrast(opts RenderOptions) ([]string, error) {
+	if opts.Foreground == nil || opts.Background == nil {
+		return nil, fmt.Errorf("foreground and background colors must be set")
+	}
+
+	colors := map[string]color.Color{"foreground": opts.Foreground}
+	if opts.Gradient != nil {
+		// gradient replaces foreground, and interpolated colors lie between both ends
+		colors = map[string]color.Color{"gradient start": opts.Gradient.From, "gradient end": opts.Gradient.To}
+	}
+	if opts.Style.EyeFrameColor != nil {
+		colors["eye frame"] = opts.Style.EyeFrameColor
+	}
+	if opts.Style.EyeBallColor != nil {
+		colors["eye ball"] = opts.Style.EyeBallColor
+	}
+
+	warnings := make([]string, 0)
+	bg := relativeLuminance(opts.Background)
+	for _, name := range sortedKeys(colors) {
+		fg := relativeLuminance(colors[name])
+		if fg > bg && !opts.Inverted {
+			return nil, fmt.Errorf("%s is lighter than background: set inverted for light-on-dark codes", name)
+		}
+		if fg < bg && opts.Inverted {
+			return nil, fmt.Errorf("%s is darker than background, while inverted is set", name)
+		}
+
+		ratio := contrastRatio(fg, bg)
+		if ratio < minContrastRatio {
+			return nil, fmt.Errorf("contrast ratio of %s to background is too low: %.2f, expected at least %.1f", name, ratio, minContrastRatio)
+		}
+		if ratio < recommendedContrastRatio {
+			warnings = append(warnings, fmt.Sprintf("contrast ratio of %s to background is low: %.2f, recommended at least %.1f", name, ratio, recommendedContrastRatio))
+		}
+	}
+
+	if opts.Inverted {
+		warnings = append(warnings, "inverted codes cannot be read by some scanners")
+	}
+	if _, _, _, a := opts.Background.RGBA(); a < 0xffff {
+		warnings = append(warnings, "contrast of translucent background depends on the surface below")
+	}
+
+	return warnings, nil
+}
+
+// luminance of sRGB color, ranging from 0 for black to 1 for white
+func relativeLuminance(c color.Color) float64 {
+	nc := color.NRGBAModel.Convert(c).(color.NRGBA)
+	linear := func(v uint8) float64 {
+		s := float64(v) / 0xff
+		if s <= 0.04045 {
+			return s / 12.92
+		}
+		return math.Pow((s+0.055)/1.055, 2.4)
+	}
+	return 0.2126*linear(nc.R) + 0.7152*linear(nc.G) + 0.0722*linear(nc.B)
+}
+
+// ratio ranging from 1 for same luminance to 21 for black and white
+func contrastRatio(l1 float64, l2 float64) float64 {
+	return (max(l1, l2) + 0.05) / (min(l1, l2) + 0.05)
+}
+
+func sortedKeys(m map[string]color.Color) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,60 @@
+package render
+
+import (
+	"errors"
+	"image/color"
+	"testing"
+)
+
+func TestCheckContrast(t *testing.T) {
+	navy, ivory := color.RGBA{0x0b, 0x1d, 0x3a, 0xff}, color.RGBA{0xe8, 0xee, 0xf7, 0xff}
+	testcases := []struct {
+		theme        Theme
+		wantWarnings int
+		wantErr      error
+	}{
+		{theme: Theme{Foreground: color.Black, Background: color.White}, wantWarnings: 0, wantErr: nil},
+		{
+			theme:        Theme{Foreground: color.RGBA{0x60, 0x60, 0x60, 0xff}, Background: color.White},
+			wantWarnings: 0,
+			wantErr:      nil,
+		},
+		{
+			theme:        Theme{Foreground: color.RGBA{0x80, 0x80, 0x80, 0xff}, Background: color.White},
+			wantWarnings: 1,
+			wantErr:      nil,
+		},
+		{
+			theme:        Theme{Foreground: color.RGBA{0xb0, 0xb0, 0xb0, 0xff}, Background: color.White},
+			wantWarnings: 0,
+			wantErr:      errors.New("contrast ratio of foreground to background is too low: 2.17, expected at least 3.0"),
+		},
+		{
+			theme:        Theme{Foreground: ivory, Background: navy},
+			wantWarnings: 0,
+			wantErr:      errors.New("foreground is lighter than background: set inverted for light-on-dark codes"),
+		},
+		{theme: Theme{Foreground: ivory, Background: navy, Inverted: true}, wantWarnings: 1, wantErr: nil},
+		{
+			theme: Theme{
+				Foreground: color.Black,
+				Background: color.White,
+				Gradient:   &Gradient{Kind: LinearGradient, From: color.Black, To: color.RGBA{0xff, 0xff, 0x00, 0xff}},
+			},
+			wantWarnings: 0,
+			wantErr:      errors.New("contrast ratio of gradient end to background is too low: 1.07, expected at least 3.0"),
+		},
+	}
+
+	for _, tt := range testcases {
+		t.Run("testing CheckContrast()", func(t *testing.T) {
+			warnings, err := CheckContrast(tt.theme.Apply(DefaultRenderOptions()))
+			if (err == nil) != (tt.wantErr == nil) || (err != nil && err.Error() != tt.wantErr.Error()) {
+				t.Errorf("CheckContrast() error = '%v'; expected '%v'", err, tt.wantErr)
+			}
+			if len(warnings) != tt.wantWarnings {
+				t.Errorf("CheckContrast() warnings = %v; expected %d warnings", warnings, tt.wantWarnings)
+			}
+		})
+	}
+}
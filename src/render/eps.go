@@ -0,0 +1,100 @@
+package render
+
+import (
+	"bufio"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/pasca-l/wifi-qrcode-generator/qrcode"
+)
+
+type EPSRenderer struct{}
+
+func (EPSRenderer) Name() string {
+	return "eps"
+}
+
+func (EPSRenderer) MIMEType() string {
+	return "application/postscript"
+}
+
+func (EPSRenderer) Render(w io.Writer, code qrcode.QRCode, opts RenderOptions) error {
+	err := opts.Validate()
+	if err != nil {
+		return err
+	}
+	if opts.Logo != nil {
+		return fmt.Errorf("logo is not supported for EPS output")
+	}
+
+	pat := WithMargin(code.Pattern, opts.Margin)
+	size := float64(opts.Size) * pointsPerInch / float64(opts.DPI)
+	module := size / float64(len(pat))
+
+	bw := bufio.NewWriter(w)
+	bw.WriteString("%!PS-Adobe-3.0 EPSF-3.0\n")
+	fmt.Fprintf(bw, "%%%%BoundingBox: 0 0 %d %d\n", int(math.Ceil(size)), int(math.Ceil(size)))
+	bw.WriteString("%%Creator: wifi-qrcode-generator\n")
+	if opts.Gradient != nil {
+		bw.WriteString("%%LanguageLevel: 3\n")
+	}
+	bw.WriteString("%%EndComments\n")
+
+	red, green, blue := psColor(opts.Background)
+	fmt.Fprintf(bw, "%.4f %.4f %.4f setrgbcolor\n", red, green, blue)
+	fmt.Fprintf(bw, "0 0 %.4f %.4f rectfill\n", size, size)
+
+	if opts.Gradient != nil {
+		writeEPSGradient(bw, pat, *opts.Gradient, opts.Margin, module, size)
+	} else {
+		red, green, blue = psColor(opts.Foreground)
+		fmt.Fprintf(bw, "%.4f %.4f %.4f setrgbcolor\n", red, green, blue)
+		for _, r := range mergeRuns(findRuns(pat)) {
+			// PostScript has origin at lower left corner
+			fmt.Fprintf(bw, "%.4f %.4f %.4f %.4f rectfill\n",
+				float64(r.x)*module, size-float64(r.y+r.height)*module, float64(r.width)*module, float64(r.height)*module,
+			)
+		}
+	}
+	bw.WriteString("showpage\n")
+	bw.WriteString("%%EOF\n")
+
+	return bw.Flush()
+}
+
+// clips shading by modules, as shfill of LanguageLevel 3 paints the whole clipping area
+func writeEPSGradient(bw *bufio.Writer, pat qrcode.Pattern, g Gradient, margin int, module float64, size float64) {
+	bw.WriteString("gsave newpath\n")
+	for _, r := range mergeRuns(findRuns(pat)) {
+		fmt.Fprintf(bw, "%.4f %.4f moveto %.4f 0 rlineto 0 %.4f rlineto %.4f 0 rlineto closepath\n",
+			float64(r.x)*module, size-float64(r.y+r.height)*module, float64(r.width)*module, float64(r.height)*module, -float64(r.width)*module,
+		)
+	}
+	bw.WriteString("clip newpath\n")
+
+	// same geometry as PDF shading, which is based on PostScript
+	codeSize := float64(len(pat)-2*margin) * module
+	sh := pdfShading(g, float64(margin)*module, size-float64(margin)*module, codeSize)
+	shadingType := 2
+	if sh.Radial {
+		shadingType = 3
+	}
+	coords := make([]string, 0, len(sh.Coords))
+	for _, c := range sh.Coords {
+		coords = append(coords, fmt.Sprintf("%.4f", c))
+	}
+	r0, g0, b0 := psColor(g.From)
+	r1, g1, b1 := psColor(g.To)
+	fmt.Fprintf(bw, "<< /ShadingType %d /ColorSpace /DeviceRGB /Coords [%s] /Extend [true true]\n", shadingType, strings.Join(coords, " "))
+	fmt.Fprintf(bw, "/Function << /FunctionType 2 /Domain [0 1] /C0 [%.4f %.4f %.4f] /C1 [%.4f %.4f %.4f] /N 1 >> >> shfill\n", r0, g0, b0, r1, g1, b1)
+	bw.WriteString("grestore\n")
+}
+
+// converts color into PostScript color components, ranging from 0 to 1
+func psColor(c color.Color) (float64, float64, float64) {
+	r, g, b, _ := c.RGBA()
+	return float64(r) / 0xffff, float64(g) / 0xffff, float64(b) / 0xffff
+}
@@ -0,0 +1,164 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/jpeg" // register decoder for logos
+	_ "image/png"  // register decoder for logos
+	"io"
+	"strings"
+
+	"github.com/pasca-l/wifi-qrcode-generator/qrcode"
+)
+
+// image placed over the knockout area of a code, or on layout templates
+type Logo struct {
+	Data     []byte
+	MIMEType string // image/svg+xml, image/png or image/jpeg
+}
+
+func ReadLogo(r io.Reader, mimeType string) (*Logo, error) {
+	switch mimeType {
+	case "image/svg+xml", "image/png", "image/jpeg":
+	default:
+		return nil, fmt.Errorf("unexpected logo type: %s", mimeType)
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return &Logo{Data: data, MIMEType: mimeType}, nil
+}
+
+func (l Logo) IsSVG() bool {
+	return strings.HasPrefix(l.MIMEType, "image/svg")
+}
+
+// pixels of raster logos, bounding memory of decoded uploads which may declare sizes far beyond their data
+const maxLogoPixels int = 4096 * 4096
+
+// decodes raster logo, as SVG logos can only be embedded into SVG output
+func (l Logo) Decode() (image.Image, error) {
+	if l.IsSVG() {
+		return nil, fmt.Errorf("SVG logo can only be used for SVG output")
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(l.Data))
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode logo: %w", err)
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width > maxLogoPixels/config.Height {
+		return nil, fmt.Errorf("logo must have at most %d pixels: given %dx%d", maxLogoPixels, config.Width, config.Height)
+	}
+	img, _, err := image.Decode(bytes.NewReader(l.Data))
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode logo: %w", err)
+	}
+	return img, nil
+}
+
+// fits logo of the given size into the area keeping its aspect ratio, returning the centered box
+func fitLogo(x, y, width, height float64, logoWidth, logoHeight int) (float64, float64, float64, float64) {
+	if logoWidth <= 0 || logoHeight <= 0 {
+		return x, y, width, height
+	}
+	scale := min(width/float64(logoWidth), height/float64(logoHeight))
+	w, h := float64(logoWidth)*scale, float64(logoHeight)*scale
+	return x + (width-w)/2, y + (height-h)/2, w, h
+}
+
+// space between logo and modules around the knockout area, in modules
+const logoPadding float64 = 0.5
+
+// checks that the code has a knockout area for the logo to be drawn over,
+// as covering data modules without budgeting error correction breaks the code
+func checkLogo(code qrcode.QRCode, opts RenderOptions) error {
+	if opts.Logo != nil && code.Knockout.Empty() {
+		return fmt.Errorf("code has no knockout area for logo: create spec with qrcode.NewQRCodeSpecWithLogo")
+	}
+	return nil
+}
+
+// box for the logo in module coordinates including margin, inside the knockout area
+func logoBox(code qrcode.QRCode, margin int) (float64, float64, float64, float64) {
+	area := code.Knockout.Add(image.Pt(margin, margin))
+	return float64(area.Min.X) + logoPadding, float64(area.Min.Y) + logoPadding,
+		float64(area.Dx()) - 2*logoPadding, float64(area.Dy()) - 2*logoPadding
+}
+
+// returns dark and light function modules in the knockout area including margin, which are drawn
+// over the logo, as readers locate and sample the code by them such as the alignment pattern at the center
+func knockoutFunctionModules(code qrcode.QRCode, margin int) (qrcode.Pattern, qrcode.Pattern) {
+	size := len(code.Pattern) + 2*margin
+	dark, light := qrcode.NewPattern(size), qrcode.NewPattern(size)
+	if len(code.Roles) != len(code.Pattern) {
+		return dark, light
+	}
+	area := code.Knockout.Intersect(image.Rect(0, 0, len(code.Pattern), len(code.Pattern)))
+	for y := area.Min.Y; y < area.Max.Y; y++ {
+		for x := area.Min.X; x < area.Max.X; x++ {
+			if code.Roles[y][x] == qrcode.DataRole {
+				continue
+			}
+			if code.Pattern[y][x] {
+				dark[y+margin][x+margin] = true
+			} else {
+				light[y+margin][x+margin] = true
+			}
+		}
+	}
+	return dark, light
+}
+
+func isEmpty(pat qrcode.Pattern) bool {
+	for _, row := range pat {
+		for _, cell := range row {
+			if cell {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// draws logo over the image, averaging source pixels covered by each destination pixel
+func drawLogo(dst draw.Image, logo image.Image, x, y, width, height float64) {
+	b := logo.Bounds()
+	x, y, width, height = fitLogo(x, y, width, height, b.Dx(), b.Dy())
+	area := image.Rect(int(x+0.5), int(y+0.5), int(x+width+0.5), int(y+height+0.5))
+	if area.Empty() {
+		return
+	}
+
+	scaled := image.NewNRGBA(area)
+	sx := float64(b.Dx()) / float64(area.Dx()) // source pixels per destination pixel
+	sy := float64(b.Dy()) / float64(area.Dy())
+	for py := area.Min.Y; py < area.Max.Y; py++ {
+		y0 := b.Min.Y + int(float64(py-area.Min.Y)*sy)
+		y1 := max(b.Min.Y+int(float64(py-area.Min.Y+1)*sy), y0+1)
+		for px := area.Min.X; px < area.Max.X; px++ {
+			x0 := b.Min.X + int(float64(px-area.Min.X)*sx)
+			x1 := max(b.Min.X+int(float64(px-area.Min.X+1)*sx), x0+1)
+
+			// average in premultiplied alpha, so that transparent pixels do not darken edges
+			var r, g, bl, a, n uint64
+			for ly := y0; ly < min(y1, b.Max.Y); ly++ {
+				for lx := x0; lx < min(x1, b.Max.X); lx++ {
+					cr, cg, cb, ca := logo.At(lx, ly).RGBA()
+					r, g, bl, a, n = r+uint64(cr), g+uint64(cg), bl+uint64(cb), a+uint64(ca), n+1
+				}
+			}
+			if n == 0 {
+				continue
+			}
+			scaled.Set(px, py, color.RGBA64{
+				R: uint16(r / n), G: uint16(g / n), B: uint16(bl / n), A: uint16(a / n), //nolint:gosec // averages of uint16
+			})
+		}
+	}
+
+	draw.Draw(dst, area, scaled, area.Min, draw.Over)
+}
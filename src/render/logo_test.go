@@ -0,0 +1,158 @@
+package render
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/pasca-l/wifi-qrcode-generator/qrcode"
+)
+
+func TestRenderWithLogo(t *testing.T) {
+	src := "WIFI:T:WPA;S:\"ssid\";P:\"password\";;"
+	logoImg := image.NewNRGBA(image.Rect(0, 0, 4, 2))
+	for y := range 2 {
+		for x := range 4 {
+			logoImg.Set(x, y, color.NRGBA{R: 0xff, A: 0xff})
+		}
+	}
+	data := new(bytes.Buffer)
+	err := png.Encode(data, logoImg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logo := &Logo{Data: data.Bytes(), MIMEType: "image/png"}
+
+	spec, err := qrcode.NewQRCodeSpec(src, qrcode.L)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, err := qrcode.NewQRCode(src, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := DefaultRenderOptions()
+	opts.Logo = logo
+	err = PNGRenderer{}.Render(new(bytes.Buffer), plain, opts)
+	if err == nil {
+		t.Errorf("Renderer.Render() error = nil; expected error for code without knockout area")
+	}
+
+	spec, err = qrcode.NewQRCodeSpecWithLogo(src, qrcode.L, 0.2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := qrcode.NewQRCode(src, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := rasterize(code, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// logo is centered in the code, including margin
+	center := opts.Size / 2
+	r, g, b, _ := img.At(center, center).RGBA()
+	if r != 0xffff || g != 0 || b != 0 {
+		t.Errorf("color at center = (%d, %d, %d); expected logo color", r, g, b)
+	}
+
+	for _, r := range []Renderer{SVGRenderer{}, PDFRenderer{}} {
+		err = r.Render(new(bytes.Buffer), code, opts)
+		if err != nil {
+			t.Errorf("%s Renderer.Render() error = '%v'; expected nil", r.Name(), err)
+		}
+	}
+}
+
+func TestRenderWithLogoAlignment(t *testing.T) {
+	// needs version 7, which has an alignment pattern at the center
+	src := "WIFI:T:WPA;S:" + strings.Repeat("x", 32) + ";P:" + strings.Repeat("y", 63) + ";;"
+	logoImg := image.NewNRGBA(image.Rect(0, 0, 1, 1))
+	logoImg.Set(0, 0, color.NRGBA{R: 0xff, A: 0xff})
+	data := new(bytes.Buffer)
+	err := png.Encode(data, logoImg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spec, err := qrcode.NewQRCodeSpecWithLogo(src, qrcode.L, 0.2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := qrcode.NewQRCode(src, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := DefaultRenderOptions()
+	opts.Logo = &Logo{Data: data.Bytes(), MIMEType: "image/png"}
+	opts.Size = (len(code.Pattern) + 2*opts.Margin) * 10 // 10 pixels per module
+	img, err := rasterize(code, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// alignment pattern is drawn over the logo, with a dark module at the center and a light ring around it
+	center := opts.Size / 2
+	testcases := []struct {
+		x, y int
+		want color.Color
+	}{
+		{x: center, y: center, want: opts.Foreground},
+		{x: center + 10, y: center, want: opts.Background},
+		{x: center + 20, y: center, want: opts.Foreground},
+		{x: center + 30, y: center + 30, want: color.NRGBA{R: 0xff, A: 0xff}},
+	}
+	for _, tt := range testcases {
+		t.Run("testing rasterize()", func(t *testing.T) {
+			r, g, b, _ := img.At(tt.x, tt.y).RGBA()
+			wr, wg, wb, _ := tt.want.RGBA()
+			if r != wr || g != wg || b != wb {
+				t.Errorf("color at (%d, %d) = (%d, %d, %d); expected (%d, %d, %d)", tt.x, tt.y, r, g, b, wr, wg, wb)
+			}
+		})
+	}
+
+	for _, r := range []Renderer{SVGRenderer{}, PDFRenderer{}} {
+		err = r.Render(new(bytes.Buffer), code, opts)
+		if err != nil {
+			t.Errorf("%s Renderer.Render() error = '%v'; expected nil", r.Name(), err)
+		}
+	}
+}
+
+func TestLogoDecode(t *testing.T) {
+	data := new(bytes.Buffer)
+	err := png.Encode(data, image.NewNRGBA(image.Rect(0, 0, 4, 2)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// declare a huge size in IHDR chunk, which follows the signature, its length and its type
+	huge := bytes.Clone(data.Bytes())
+	binary.BigEndian.PutUint32(huge[16:], 100000)
+	binary.BigEndian.PutUint32(huge[20:], 100000)
+	binary.BigEndian.PutUint32(huge[29:], crc32.ChecksumIEEE(huge[12:29]))
+
+	testcases := []struct {
+		data    []byte
+		wantErr error
+	}{
+		{data: data.Bytes(), wantErr: nil},
+		{data: huge, wantErr: errors.New("logo must have at most 16777216 pixels: given 100000x100000")},
+	}
+
+	for _, tt := range testcases {
+		t.Run("testing Logo.Decode()", func(t *testing.T) {
+			_, err := Logo{Data: tt.data, MIMEType: "image/png"}.Decode()
+			if (err == nil) != (tt.wantErr == nil) || (err != nil && err.Error() != tt.wantErr.Error()) {
+				t.Errorf("Logo.Decode() error = '%v'; expected '%v'", err, tt.wantErr)
+			}
+		})
+	}
+}
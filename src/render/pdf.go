@@ -0,0 +1,253 @@
+package render
+
+import (
+	"fmt"
+	"image/color"
+	"io"
+	"strings"
+
+	"github.com/pasca-l/wifi-qrcode-generator/qrcode"
+	"github.com/pasca-l/wifi-qrcode-generator/utils/pdf"
+)
+
+// number of points per inch, which is the user space unit of PDF and EPS
+const pointsPerInch float64 = 72
+
+const mmPerInch float64 = 25.4
+
+func mmToPoints(mm float64) float64 {
+	return mm * pointsPerInch / mmPerInch
+}
+
+type PageSize struct {
+	Name   string
+	Width  float64 // in mm
+	Height float64 // in mm
+}
+
+var (
+	A4     = PageSize{Name: "A4", Width: 210, Height: 297}
+	Letter = PageSize{Name: "Letter", Width: 215.9, Height: 279.4}
+)
+
+// parses page size name case insensitively, where empty name fits the page to the code
+func ParsePageSize(name string) (PageSize, error) {
+	switch strings.ToLower(name) {
+	case "":
+		return PageSize{}, nil
+	case "a4":
+		return A4, nil
+	case "letter":
+		return Letter, nil
+	default:
+		return PageSize{}, fmt.Errorf("unexpected page size: %s", name)
+	}
+}
+
+// length of crop marks, and their minimum distance from the trimmed edge
+const cropMarkLength float64 = 5   // in mm
+const cropMarkOffset float64 = 3   // in mm
+const cropMarkWidth float64 = 0.25 // in points
+
+type PDFRenderer struct {
+	ModuleSize float64  // physical size of a module in mm, derived from size and DPI if 0
+	PageSize   PageSize // page size after trimming, fitted to the code if zero value
+	Bleed      float64  // extension of background beyond the trimmed edge, in mm
+	CropMarks  bool     // draws marks outside the bleed, indicating where to trim
+	Caption    string   // text printed below the code, such as the SSID
+}
+
+// upper bounds of print options in mm, beyond any page or poster printed
+const (
+	MaxModuleSize float64 = 50
+	MaxBleed      float64 = 20
+)
+
+// checks print options, where comparisons also reject NaN
+func (pr PDFRenderer) Validate() error {
+	if !(pr.ModuleSize >= 0 && pr.ModuleSize <= MaxModuleSize) {
+		return fmt.Errorf("module size must be between 0 and %vmm: given %v", MaxModuleSize, pr.ModuleSize)
+	}
+	if !(pr.Bleed >= 0 && pr.Bleed <= MaxBleed) {
+		return fmt.Errorf("bleed must be between 0 and %vmm: given %v", MaxBleed, pr.Bleed)
+	}
+	return nil
+}
+
+func (PDFRenderer) Name() string {
+	return "pdf"
+}
+
+func (PDFRenderer) MIMEType() string {
+	return "application/pdf"
+}
+
+func (pr PDFRenderer) Render(w io.Writer, code qrcode.QRCode, opts RenderOptions) error {
+	err := opts.Validate()
+	if err != nil {
+		return err
+	}
+	err = pr.Validate()
+	if err != nil {
+		return err
+	}
+	err = checkLogo(code, opts)
+	if err != nil {
+		return err
+	}
+
+	doc := pdf.NewDocument()
+	err = pr.drawPage(doc, code, opts)
+	if err != nil {
+		return err
+	}
+	return doc.Write(w)
+}
+
+// draws code as a new page of the document, so that pages can be collected
+func (pr PDFRenderer) drawPage(doc *pdf.Document, code qrcode.QRCode, opts RenderOptions) error {
+	pat := WithMargin(code.Pattern, opts.Margin)
+	module := float64(opts.Size) * pointsPerInch / float64(opts.DPI) / float64(len(pat))
+	if pr.ModuleSize > 0 {
+		module = mmToPoints(pr.ModuleSize)
+	}
+	codeSize := module * float64(len(pat))
+
+	// caption is placed below the code, in the quiet zone if there is enough space
+	captionSize := min(max(codeSize/16, 8), 24)
+	captionHeight := 0.0
+	if pr.Caption != "" {
+		captionHeight = 2 * captionSize
+	}
+
+	trimWidth, trimHeight := codeSize, codeSize+captionHeight
+	if pr.PageSize != (PageSize{}) {
+		trimWidth, trimHeight = mmToPoints(pr.PageSize.Width), mmToPoints(pr.PageSize.Height)
+	}
+	if codeSize > trimWidth || codeSize+captionHeight > trimHeight {
+		return fmt.Errorf("code with size: %.1fmm does not fit page: %s", codeSize*mmPerInch/pointsPerInch, pr.PageSize.Name)
+	}
+
+	bleed := mmToPoints(pr.Bleed)
+	slug := 0.0 // area outside bleed for printer marks
+	if pr.CropMarks {
+		slug = mmToPoints(max(cropMarkOffset, pr.Bleed)+cropMarkLength) - bleed
+	}
+	edge := bleed + slug // distance from media edge to trimmed edge
+
+	page := doc.AddPage(trimWidth+2*edge, trimHeight+2*edge)
+	trim := pdf.Box{X: edge, Y: edge, Width: trimWidth, Height: trimHeight}
+	page.TrimBox = &trim
+	page.BleedBox = &pdf.Box{X: slug, Y: slug, Width: trimWidth + 2*bleed, Height: trimHeight + 2*bleed}
+
+	page.SetFillColor(opts.Background)
+	page.Rect(page.BleedBox.X, page.BleedBox.Y, page.BleedBox.Width, page.BleedBox.Height)
+	page.Fill()
+
+	// center the code together with the caption, in the trimmed area
+	left := trim.X + (trimWidth-codeSize)/2
+	bottom := trim.Y + (trimHeight-codeSize-captionHeight)/2 + captionHeight
+	// fills modules marked in pattern including margin, with background if light, otherwise with foreground
+	drawModules := func(p qrcode.Pattern, light bool) {
+		gradient := opts.Gradient != nil && !light
+		switch {
+		case light:
+			page.SetFillColor(opts.Background)
+		case gradient:
+			page.SaveState()
+		default:
+			page.SetFillColor(opts.Foreground)
+		}
+		for _, r := range mergeRuns(findRuns(p)) {
+			// PDF has origin at lower left corner
+			page.Rect(
+				left+float64(r.x)*module,
+				bottom+codeSize-float64(r.y+r.height)*module,
+				float64(r.width)*module,
+				float64(r.height)*module,
+			)
+		}
+		if gradient {
+			// modules clip the shading, which covers the code area excluding margin
+			page.Clip()
+			page.Shade(pdfShading(*opts.Gradient, left+float64(opts.Margin)*module, bottom+codeSize-float64(opts.Margin)*module, float64(len(code.Pattern))*module))
+			page.RestoreState()
+		} else {
+			page.Fill()
+		}
+	}
+	drawModules(pat, false)
+
+	if opts.Logo != nil {
+		img, err := opts.Logo.Decode()
+		if err != nil {
+			return err
+		}
+		logo, err := doc.AddImage(img)
+		if err != nil {
+			return err
+		}
+		x, y, w, h := logoBox(code, opts.Margin)
+		x, y, w, h = fitLogo(x, y, w, h, img.Bounds().Dx(), img.Bounds().Dy())
+		page.DrawImage(logo, left+x*module, bottom+codeSize-(y+h)*module, w*module, h*module)
+
+		dark, light := knockoutFunctionModules(code, opts.Margin)
+		if !isEmpty(light) {
+			drawModules(light, true)
+		}
+		if !isEmpty(dark) {
+			drawModules(dark, false)
+		}
+	}
+
+	if pr.Caption != "" {
+		// shrink caption to fit within the trimmed width
+		width := pdf.TextWidth(pdf.Sans, captionSize, pr.Caption)
+		if width > trimWidth {
+			captionSize *= trimWidth / width
+			width = trimWidth
+		}
+		page.Text(trim.X+(trimWidth-width)/2, bottom-1.5*captionSize, pdf.Sans, captionSize, pr.Caption)
+	}
+
+	if pr.CropMarks {
+		drawCropMarks(page, trim, mmToPoints(max(cropMarkOffset, pr.Bleed)), mmToPoints(cropMarkLength))
+	}
+
+	return nil
+}
+
+// converts gradient into shading over the code area, given its upper left corner and size in points
+func pdfShading(g Gradient, left float64, top float64, size float64) pdf.Shading {
+	if g.Kind == RadialGradient {
+		cx, cy := left+size/2, top-size/2
+		return pdf.Shading{Radial: true, Coords: []float64{cx, cy, 0, cx, cy, size * radialRadius}, From: g.From, To: g.To}
+	}
+	x1, y1, x2, y2 := g.endpoints()
+	return pdf.Shading{
+		Coords: []float64{left + x1*size, top - y1*size, left + x2*size, top - y2*size},
+		From:   g.From,
+		To:     g.To,
+	}
+}
+
+// draws marks at each corner of the trimmed area, starting outside the given offset
+func drawCropMarks(page *pdf.Page, trim pdf.Box, offset float64, length float64) {
+	page.SetStrokeColor(color.Black) // registration color, printed on all separations
+	page.SetLineWidth(cropMarkWidth)
+	for _, x := range []float64{trim.X, trim.X + trim.Width} {
+		for _, y := range []float64{trim.Y, trim.Y + trim.Height} {
+			// direction pointing outwards from the trimmed area
+			dx, dy := 1.0, 1.0
+			if x == trim.X {
+				dx = -1
+			}
+			if y == trim.Y {
+				dy = -1
+			}
+			page.Line(x+dx*offset, y, x+dx*(offset+length), y)
+			page.Line(x, y+dy*offset, x, y+dy*(offset+length))
+		}
+	}
+	page.Stroke()
+}
@@ -0,0 +1,231 @@
+package render
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	"github.com/pasca-l/wifi-qrcode-generator/qrcode"
+)
+
+type PNGRenderer struct{}
+
+func (PNGRenderer) Name() string {
+	return "png"
+}
+
+func (PNGRenderer) MIMEType() string {
+	return "image/png"
+}
+
+func (PNGRenderer) Render(w io.Writer, code qrcode.QRCode, opts RenderOptions) error {
+	img, err := rasterize(code, opts)
+	if err != nil {
+		return err
+	}
+
+	buf := new(bytes.Buffer)
+	err = png.Encode(buf, img)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(withPNGResolution(buf.Bytes(), opts.DPI))
+	return err
+}
+
+type JPEGRenderer struct{}
+
+func (JPEGRenderer) Name() string {
+	return "jpeg"
+}
+
+func (JPEGRenderer) MIMEType() string {
+	return "image/jpeg"
+}
+
+func (JPEGRenderer) Render(w io.Writer, code qrcode.QRCode, opts RenderOptions) error {
+	img, err := rasterize(code, opts)
+	if err != nil {
+		return err
+	}
+
+	buf := new(bytes.Buffer)
+	err = jpeg.Encode(buf, img, &jpeg.Options{Quality: 95})
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(withJPEGResolution(buf.Bytes(), opts.DPI))
+	return err
+}
+
+type GIFRenderer struct{}
+
+func (GIFRenderer) Name() string {
+	return "gif"
+}
+
+func (GIFRenderer) MIMEType() string {
+	return "image/gif"
+}
+
+func (GIFRenderer) Render(w io.Writer, code qrcode.QRCode, opts RenderOptions) error {
+	img, err := rasterize(code, opts)
+	if err != nil {
+		return err
+	}
+	return gif.Encode(w, img, nil)
+}
+
+// draws pattern into image, scaling modules to fit the given size
+func rasterize(code qrcode.QRCode, opts RenderOptions) (image.Image, error) {
+	err := opts.Validate()
+	if err != nil {
+		return nil, err
+	}
+	err = checkLogo(code, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var img draw.Image
+	if opts.Style.isPlain() && opts.Gradient == nil {
+		img = rasterizePlain(code, opts)
+	} else {
+		img = rasterizeStyled(code, opts)
+	}
+
+	if opts.Logo != nil {
+		logo, err := opts.Logo.Decode()
+		if err != nil {
+			return nil, err
+		}
+		// logo colors cannot be represented by the two color palette
+		if _, paletted := img.(*image.Paletted); paletted {
+			rgba := image.NewNRGBA(img.Bounds())
+			draw.Draw(rgba, rgba.Bounds(), img, image.Point{}, draw.Src)
+			img = rgba
+		}
+		scale := float64(opts.Size) / float64(len(code.Pattern)+2*opts.Margin) // pixels per module
+		x, y, w, h := logoBox(code, opts.Margin)
+		drawLogo(img, logo, x*scale, y*scale, w*scale, h*scale)
+
+		dark, light := knockoutFunctionModules(code, opts.Margin)
+		margin, codeSize := float64(opts.Margin), float64(len(code.Pattern))
+		for py := range opts.Size {
+			my := py * len(dark) / opts.Size
+			for px := range opts.Size {
+				mx := px * len(dark) / opts.Size
+				switch {
+				case dark[my][mx]:
+					img.Set(px, py, opts.foregroundAt((float64(mx)+0.5-margin)/codeSize, (float64(my)+0.5-margin)/codeSize))
+				case light[my][mx]:
+					img.Set(px, py, opts.Background)
+				}
+			}
+		}
+	}
+
+	return img, nil
+}
+
+func rasterizePlain(code qrcode.QRCode, opts RenderOptions) draw.Image {
+
+	pat := WithMargin(code.Pattern, opts.Margin)
+	palette := color.Palette{opts.Background, opts.Foreground}
+	img := image.NewPaletted(image.Rect(0, 0, opts.Size, opts.Size), palette)
+	for py := range opts.Size {
+		y := py * len(pat) / opts.Size
+		for px := range opts.Size {
+			x := px * len(pat) / opts.Size
+			if pat[y][x] {
+				img.SetColorIndex(px, py, 1)
+			}
+		}
+	}
+
+	return img
+}
+
+// number of samples per pixel along each axis, to smooth curved edges
+const supersampling int = 3
+
+// draws styled shapes into image, blending colors by coverage of each pixel
+// also used for plain square modules filled with gradient
+func rasterizeStyled(code qrcode.QRCode, opts RenderOptions) draw.Image {
+	shapes := newStyledShapes(code, opts.Style, opts.Margin)
+	scale := float64(len(shapes.modules)) / float64(opts.Size) // modules per pixel
+	img := image.NewNRGBA(image.Rect(0, 0, opts.Size, opts.Size))
+	bg := color.NRGBAModel.Convert(opts.Background).(color.NRGBA)
+	margin, codeSize := float64(opts.Margin), float64(len(code.Pattern))
+
+	for py := range opts.Size {
+		for px := range opts.Size {
+			var r, g, b, a int
+			for sy := range supersampling {
+				for sx := range supersampling {
+					mx := (float64(px) + (float64(sx)+0.5)/float64(supersampling)) * scale
+					my := (float64(py) + (float64(sy)+0.5)/float64(supersampling)) * scale
+					c := bg
+					fg := opts.foregroundAt((mx-margin)/codeSize, (my-margin)/codeSize)
+					if mc := shapes.colorAt(mx, my, opts.Style, fg); mc != nil {
+						c = color.NRGBAModel.Convert(mc).(color.NRGBA)
+					}
+					r, g, b, a = r+int(c.R), g+int(c.G), b+int(c.B), a+int(c.A)
+				}
+			}
+			n := supersampling * supersampling
+			img.SetNRGBA(px, py, color.NRGBA{R: uint8(r / n), G: uint8(g / n), B: uint8(b / n), A: uint8(a / n)}) //nolint:gosec // averages of uint8
+		}
+	}
+
+	return img
+}
+
+// inserts pHYs chunk after IHDR chunk, as image/png does not write resolution
+// referenced: https://www.w3.org/TR/png/#11pHYs
+func withPNGResolution(data []byte, dpi int) []byte {
+	const ihdrEnd = 8 + 4 + 4 + 13 + 4 // signature, length, type, data, crc
+	if len(data) < ihdrEnd {
+		return data
+	}
+
+	ppm := uint32(float64(dpi)/0.0254 + 0.5) // pixels per meter
+	chunk := make([]byte, 0, 21)
+	chunk = binary.BigEndian.AppendUint32(chunk, 9)
+	chunk = append(chunk, "pHYs"...)
+	chunk = binary.BigEndian.AppendUint32(chunk, ppm)
+	chunk = binary.BigEndian.AppendUint32(chunk, ppm)
+	chunk = append(chunk, 1) // unit is meter
+	chunk = binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+
+	result := make([]byte, 0, len(data)+len(chunk))
+	result = append(result, data[:ihdrEnd]...)
+	result = append(result, chunk...)
+	return append(result, data[ihdrEnd:]...)
+}
+
+// inserts JFIF APP0 segment after SOI marker, as image/jpeg does not write resolution
+// referenced: https://www.w3.org/Graphics/JPEG/jfif3.pdf
+func withJPEGResolution(data []byte, dpi int) []byte {
+	if len(data) < 2 {
+		return data
+	}
+
+	density := uint16(min(dpi, 0xffff)) //nolint:gosec // bounded above
+	segment := []byte{0xff, 0xe0, 0, 16, 'J', 'F', 'I', 'F', 0, 1, 1, 1}
+	segment = binary.BigEndian.AppendUint16(segment, density)
+	segment = binary.BigEndian.AppendUint16(segment, density)
+	segment = append(segment, 0, 0) // no thumbnail
+
+	result := make([]byte, 0, len(data)+len(segment))
+	result = append(result, data[:2]...)
+	result = append(result, segment...)
+	return append(result, data[2:]...)
+}
@@ -0,0 +1,191 @@
+package render
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"slices"
+
+	"github.com/pasca-l/wifi-qrcode-generator/qrcode"
+)
+
+type Renderer interface {
+	Name() string     // short name, also used as file extension
+	MIMEType() string // media type of rendered output
+	Render(w io.Writer, code qrcode.QRCode, opts RenderOptions) error
+}
+
+type RenderOptions struct {
+	Size       int // width and height of output, in pixels
+	Margin     int // quiet zone around the code, in modules
+	Foreground color.Color
+	Background color.Color
+	DPI        int // resolution used for physical sizes, in dots per inch
+	Style      Style
+	Logo       *Logo     // drawn over the knockout area of the code, if set
+	Gradient   *Gradient // fills dark modules instead of foreground, if set
+	Inverted   bool      // foreground is lighter than background, as for light-on-dark codes
+}
+
+func DefaultRenderOptions() RenderOptions {
+	return RenderOptions{
+		Size:       300,
+		Margin:     4, // minimum quiet zone required by the specification
+		Foreground: color.Black,
+		Background: color.White,
+		DPI:        72,
+	}
+}
+
+// upper bounds of size in pixels and margin in modules, well beyond any printed code and the quiet zone required by the specification
+const (
+	MaxSize   = 4096
+	MaxMargin = 64
+)
+
+func (opts RenderOptions) Validate() error {
+	if opts.Size < 1 || opts.Size > MaxSize {
+		return fmt.Errorf("size must be between 1 and %d: given %d", MaxSize, opts.Size)
+	}
+	if opts.Margin < 0 || opts.Margin > MaxMargin {
+		return fmt.Errorf("margin must be between 0 and %d: given %d", MaxMargin, opts.Margin)
+	}
+	if opts.DPI < 1 {
+		return fmt.Errorf("dpi must be larger than 0: given %d", opts.DPI)
+	}
+	if opts.Gradient != nil {
+		err := opts.Gradient.Validate()
+		if err != nil {
+			return err
+		}
+	}
+	err := opts.Style.Validate()
+	if err != nil {
+		return err
+	}
+	_, err = CheckContrast(opts)
+	return err
+}
+
+// registry of available renderers, keyed by MIME type
+var registry = newRegistry(
+	SVGRenderer{},
+	PNGRenderer{},
+	JPEGRenderer{},
+	GIFRenderer{},
+	PDFRenderer{},
+	EPSRenderer{},
+	TextRenderer{},
+)
+
+func newRegistry(renderers ...Renderer) map[string]Renderer {
+	reg := make(map[string]Renderer, len(renderers))
+	for _, r := range renderers {
+		reg[r.MIMEType()] = r
+	}
+	return reg
+}
+
+// registers renderer, replacing any renderer with the same MIME type
+func Register(r Renderer) {
+	registry[r.MIMEType()] = r
+}
+
+func Get(mimeType string) (Renderer, error) {
+	if r, exists := registry[mimeType]; exists {
+		return r, nil
+	}
+	return nil, fmt.Errorf("renderer for MIME type: %s, does not exist", mimeType)
+}
+
+func GetByName(name string) (Renderer, error) {
+	for _, r := range registry {
+		if r.Name() == name {
+			return r, nil
+		}
+	}
+	return nil, fmt.Errorf("renderer with name: %s, does not exist", name)
+}
+
+// returns sorted names of registered renderers
+func Names() []string {
+	names := make([]string, 0, len(registry))
+	for _, r := range registry {
+		names = append(names, r.Name())
+	}
+	slices.Sort(names)
+	return names
+}
+
+// returns pattern surrounded by the quiet zone
+func WithMargin(pat qrcode.Pattern, margin int) qrcode.Pattern {
+	padded := qrcode.NewPattern(len(pat) + 2*margin)
+	for y, row := range pat {
+		for x, cell := range row {
+			padded[y+margin][x+margin] = cell
+		}
+	}
+	return padded
+}
+
+// horizontal run of dark modules
+type run struct {
+	x, y, length int
+}
+
+// merges adjacent dark modules in each row into runs
+func findRuns(pat qrcode.Pattern) []run {
+	runs := make([]run, 0)
+	for y, row := range pat {
+		for x := 0; x < len(row); x++ {
+			if !row[x] {
+				continue
+			}
+			start := x
+			for x < len(row) && row[x] {
+				x++
+			}
+			runs = append(runs, run{x: start, y: y, length: x - start})
+		}
+	}
+	return runs
+}
+
+// rectangle of dark modules
+type rect struct {
+	x, y, width, height int
+}
+
+// merges runs with same position and length in consecutive rows into rectangles
+func mergeRuns(runs []run) []rect {
+	rects := make([]rect, 0, len(runs))
+	open := make(map[[2]int]int) // run position and length, to index of rect
+	for _, r := range runs {
+		key := [2]int{r.x, r.length}
+		if i, exists := open[key]; exists && rects[i].y+rects[i].height == r.y {
+			rects[i].height++
+			continue
+		}
+		open[key] = len(rects)
+		rects = append(rects, rect{x: r.x, y: r.y, width: r.length, height: 1})
+	}
+	return rects
+}
+
+// returns dark modules of pattern merged into rectangles, in module coordinates
+// so that the code can be composed into other documents
+func ModuleRects(pat qrcode.Pattern) []image.Rectangle {
+	rects := mergeRuns(findRuns(pat))
+	result := make([]image.Rectangle, 0, len(rects))
+	for _, r := range rects {
+		result = append(result, image.Rect(r.x, r.y, r.x+r.width, r.y+r.height))
+	}
+	return result
+}
+
+// converts color into hex notation, ignoring alpha
+func hexColor(c color.Color) string {
+	r, g, b, _ := c.RGBA()
+	return fmt.Sprintf("#%02x%02x%02x", r>>8, g>>8, b>>8)
+}
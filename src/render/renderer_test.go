@@ -0,0 +1,192 @@
+package render
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/pasca-l/wifi-qrcode-generator/qrcode"
+)
+
+func TestGetByName(t *testing.T) {
+	testcases := []struct {
+		name     string
+		wantMIME string
+		wantErr  error
+	}{
+		{
+			name:     "svg",
+			wantMIME: "image/svg+xml",
+			wantErr:  nil,
+		},
+		{
+			name:     "png",
+			wantMIME: "image/png",
+			wantErr:  nil,
+		},
+		{
+			name:     "bmp",
+			wantMIME: "",
+			wantErr:  errors.New("renderer with name: bmp, does not exist"),
+		},
+	}
+
+	for _, tt := range testcases {
+		t.Run("testing GetByName()", func(t *testing.T) {
+			got, err := GetByName(tt.name)
+			if err != nil && err.Error() != tt.wantErr.Error() {
+				t.Errorf("GetByName() error = '%v'; expected '%v'", err, tt.wantErr)
+			}
+			if got != nil && got.MIMEType() != tt.wantMIME {
+				t.Errorf("GetByName(%v).MIMEType() = %v; expected %v", tt.name, got.MIMEType(), tt.wantMIME)
+			}
+		})
+	}
+}
+
+func TestFindRuns(t *testing.T) {
+	testcases := []struct {
+		pat  qrcode.Pattern
+		want []run
+	}{
+		{
+			pat: qrcode.Pattern{
+				{true, true, false, true},
+				{false, false, false, false},
+				{false, true, true, true},
+			},
+			want: []run{
+				{x: 0, y: 0, length: 2},
+				{x: 3, y: 0, length: 1},
+				{x: 1, y: 2, length: 3},
+			},
+		},
+	}
+
+	for _, tt := range testcases {
+		t.Run("testing findRuns()", func(t *testing.T) {
+			if got := findRuns(tt.pat); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findRuns() = %v; expected %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeRuns(t *testing.T) {
+	testcases := []struct {
+		runs []run
+		want []rect
+	}{
+		{
+			runs: []run{
+				{x: 0, y: 0, length: 2},
+				{x: 3, y: 0, length: 1},
+				{x: 0, y: 1, length: 2},
+				{x: 3, y: 1, length: 2},
+				{x: 0, y: 3, length: 2},
+			},
+			want: []rect{
+				{x: 0, y: 0, width: 2, height: 2},
+				{x: 3, y: 0, width: 1, height: 1},
+				{x: 3, y: 1, width: 2, height: 1},
+				{x: 0, y: 3, width: 2, height: 1},
+			},
+		},
+	}
+
+	for _, tt := range testcases {
+		t.Run("testing mergeRuns()", func(t *testing.T) {
+			if got := mergeRuns(tt.runs); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mergeRuns() = %v; expected %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderersRender(t *testing.T) {
+	src := "WIFI:T:WPA;S:\"ssid\";P:\"password\";;"
+	spec, err := qrcode.NewQRCodeSpec(src, qrcode.L)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := qrcode.NewQRCode(src, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range Names() {
+		t.Run("testing Renderer.Render() for "+name, func(t *testing.T) {
+			r, err := GetByName(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			buf := new(bytes.Buffer)
+			err = r.Render(buf, code, DefaultRenderOptions())
+			if err != nil {
+				t.Errorf("Renderer.Render() error = '%v'; expected nil", err)
+			}
+			if buf.Len() == 0 {
+				t.Errorf("Renderer.Render() wrote no output")
+			}
+		})
+	}
+}
+
+func TestRenderOptionsValidate(t *testing.T) {
+	withSize := func(size int) RenderOptions {
+		opts := DefaultRenderOptions()
+		opts.Size = size
+		return opts
+	}
+	withMargin := func(margin int) RenderOptions {
+		opts := DefaultRenderOptions()
+		opts.Margin = margin
+		return opts
+	}
+	testcases := []struct {
+		opts    RenderOptions
+		wantErr error
+	}{
+		{opts: withSize(4096), wantErr: nil},
+		{opts: withSize(0), wantErr: errors.New("size must be between 1 and 4096: given 0")},
+		{opts: withSize(100000), wantErr: errors.New("size must be between 1 and 4096: given 100000")},
+		{opts: withMargin(0), wantErr: nil},
+		{opts: withMargin(64), wantErr: nil},
+		{opts: withMargin(-1), wantErr: errors.New("margin must be between 0 and 64: given -1")},
+		{opts: withMargin(1000000), wantErr: errors.New("margin must be between 0 and 64: given 1000000")},
+	}
+
+	for _, tt := range testcases {
+		t.Run("testing RenderOptions.Validate()", func(t *testing.T) {
+			err := tt.opts.Validate()
+			if (err == nil) != (tt.wantErr == nil) || (err != nil && err.Error() != tt.wantErr.Error()) {
+				t.Errorf("RenderOptions.Validate() error = '%v'; expected '%v'", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPDFRendererValidate(t *testing.T) {
+	testcases := []struct {
+		renderer PDFRenderer
+		wantErr  error
+	}{
+		{renderer: PDFRenderer{ModuleSize: 1.5, Bleed: 3}, wantErr: nil},
+		{renderer: PDFRenderer{ModuleSize: -1}, wantErr: errors.New("module size must be between 0 and 50mm: given -1")},
+		{renderer: PDFRenderer{ModuleSize: math.NaN()}, wantErr: errors.New("module size must be between 0 and 50mm: given NaN")},
+		{renderer: PDFRenderer{ModuleSize: math.Inf(1)}, wantErr: errors.New("module size must be between 0 and 50mm: given +Inf")},
+		{renderer: PDFRenderer{Bleed: 100}, wantErr: errors.New("bleed must be between 0 and 20mm: given 100")},
+		{renderer: PDFRenderer{Bleed: math.NaN()}, wantErr: errors.New("bleed must be between 0 and 20mm: given NaN")},
+	}
+
+	for _, tt := range testcases {
+		t.Run("testing PDFRenderer.Validate()", func(t *testing.T) {
+			err := tt.renderer.Validate()
+			if (err == nil) != (tt.wantErr == nil) || (err != nil && err.Error() != tt.wantErr.Error()) {
+				t.Errorf("PDFRenderer.Validate() error = '%v'; expected '%v'", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,248 @@
+package render
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/pasca-l/wifi-qrcode-generator/qrcode"
+)
+
+type ModuleShape string
+
+const (
+	SquareModule  ModuleShape = "square"
+	DotModule     ModuleShape = "dot"
+	RoundedModule ModuleShape = "rounded"
+	LiquidModule  ModuleShape = "liquid" // rounds corners not touching other dark modules
+)
+
+// shape of finder pattern frames and balls
+type EyeShape string
+
+const (
+	SquareEye  EyeShape = "square"
+	RoundedEye EyeShape = "rounded"
+	CircleEye  EyeShape = "circle"
+)
+
+// styling of modules by their role, where zero value draws plain squares
+type Style struct {
+	Module        ModuleShape
+	EyeFrame      EyeShape
+	EyeBall       EyeShape
+	EyeFrameColor color.Color // uses foreground if nil
+	EyeBallColor  color.Color // uses foreground if nil
+}
+
+// parses shape names, where empty names use square shapes
+func NewStyle(module string, eyeFrame string, eyeBall string) (Style, error) {
+	style := Style{
+		Module:   ModuleShape(module),
+		EyeFrame: EyeShape(eyeFrame),
+		EyeBall:  EyeShape(eyeBall),
+	}
+	return style, style.Validate()
+}
+
+func (s Style) Validate() error {
+	switch s.Module {
+	case "", SquareModule, DotModule, RoundedModule, LiquidModule:
+	default:
+		return fmt.Errorf("unexpected module shape: %s", s.Module)
+	}
+	for _, eye := range []EyeShape{s.EyeFrame, s.EyeBall} {
+		switch eye {
+		case "", SquareEye, RoundedEye, CircleEye:
+		default:
+			return fmt.Errorf("unexpected eye shape: %s", eye)
+		}
+	}
+	return nil
+}
+
+// reports whether style draws plain square modules in a single color
+func (s Style) isPlain() bool {
+	return (s.Module == "" || s.Module == SquareModule) &&
+		(s.EyeFrame == "" || s.EyeFrame == SquareEye) &&
+		(s.EyeBall == "" || s.EyeBall == SquareEye) &&
+		s.EyeFrameColor == nil && s.EyeBallColor == nil
+}
+
+// rectangle with each corner rounded by radius, in module coordinates
+// radii are ordered as upper left, upper right, lower right and lower left
+type roundedRect struct {
+	x, y, w, h float64
+	radii      [4]float64
+}
+
+func (r roundedRect) contains(px, py float64) bool {
+	if px < r.x || px >= r.x+r.w || py < r.y || py >= r.y+r.h {
+		return false
+	}
+	corners := [4][2]float64{
+		{r.x + r.radii[0], r.y + r.radii[0]},
+		{r.x + r.w - r.radii[1], r.y + r.radii[1]},
+		{r.x + r.w - r.radii[2], r.y + r.h - r.radii[2]},
+		{r.x + r.radii[3], r.y + r.h - r.radii[3]},
+	}
+	for i, c := range corners {
+		// only points beyond the corner center, towards the corner, may lie outside
+		outX := (i == 0 || i == 3) && px < c[0] || (i == 1 || i == 2) && px > c[0]
+		outY := (i == 0 || i == 1) && py < c[1] || (i == 2 || i == 3) && py > c[1]
+		if outX && outY && math.Hypot(px-c[0], py-c[1]) > r.radii[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// converts rectangle into SVG path data, drawn clockwise
+func (r roundedRect) pathData() string {
+	d := new(strings.Builder)
+	tl, tr, br, bl := r.radii[0], r.radii[1], r.radii[2], r.radii[3]
+	arc := func(radius, dx, dy float64) {
+		if radius > 0 {
+			d.WriteString("a" + fnum(radius) + " " + fnum(radius) + " 0 0 1 " + fnum(dx) + " " + fnum(dy))
+		}
+	}
+	// skips straight lines with no length, such as for circles
+	line := func(cmd string, length float64) {
+		if fnum(length) != "0" {
+			d.WriteString(cmd + fnum(length))
+		}
+	}
+	d.WriteString("M" + fnum(r.x+tl) + " " + fnum(r.y))
+	line("h", r.w-tl-tr)
+	arc(tr, tr, tr)
+	line("v", r.h-tr-br)
+	arc(br, -br, br)
+	line("h", -(r.w - br - bl))
+	arc(bl, -bl, -bl)
+	line("v", -(r.h - bl - tl))
+	arc(tl, tl, -tl)
+	d.WriteString("z")
+	return d.String()
+}
+
+func uniformRect(x, y, w, h, radius float64) roundedRect {
+	return roundedRect{x: x, y: y, w: w, h: h, radii: [4]float64{radius, radius, radius, radius}}
+}
+
+// formats number with minimum digits for path data
+func fnum(f float64) string {
+	rounded := math.Round(f*1000) / 1000
+	if rounded == 0 {
+		return "0" // avoids negative zero
+	}
+	return strconv.FormatFloat(rounded, 'f', -1, 64)
+}
+
+// shape of a single dark module at (x, y), considering its neighbors for liquid shape
+func moduleShape(shape ModuleShape, pat qrcode.Pattern, x, y int) roundedRect {
+	fx, fy := float64(x), float64(y)
+	switch shape {
+	case DotModule:
+		// slightly smaller than module, so that adjacent dots do not touch
+		return uniformRect(fx+0.05, fy+0.05, 0.9, 0.9, 0.45)
+	case RoundedModule:
+		return uniformRect(fx, fy, 1, 1, 0.3)
+	case LiquidModule:
+		isDark := func(x, y int) bool {
+			return y >= 0 && y < len(pat) && x >= 0 && x < len(pat[y]) && pat[y][x]
+		}
+		radius := func(dx, dy int) float64 {
+			if isDark(x+dx, y) || isDark(x, y+dy) {
+				return 0
+			}
+			return 0.5
+		}
+		return roundedRect{x: fx, y: fy, w: 1, h: 1, radii: [4]float64{
+			radius(-1, -1), radius(1, -1), radius(1, 1), radius(-1, 1),
+		}}
+	default:
+		return uniformRect(fx, fy, 1, 1, 0)
+	}
+}
+
+// finder pattern drawn as a frame and a ball, with origin at its upper left corner
+type eye struct {
+	outer roundedRect // frame is outer area excluding inner area
+	inner roundedRect
+	ball  roundedRect
+}
+
+func newEye(frame EyeShape, ball EyeShape, x, y float64) eye {
+	frameRadius := map[EyeShape]float64{RoundedEye: 2, CircleEye: 3.5}[frame]
+	ballRadius := map[EyeShape]float64{RoundedEye: 0.75, CircleEye: 1.5}[ball]
+	return eye{
+		outer: uniformRect(x, y, 7, 7, frameRadius),
+		inner: uniformRect(x+1, y+1, 5, 5, max(frameRadius-1, 0)),
+		ball:  uniformRect(x+2, y+2, 3, 3, ballRadius),
+	}
+}
+
+func (e eye) frameContains(px, py float64) bool {
+	return e.outer.contains(px, py) && !e.inner.contains(px, py)
+}
+
+// styled shapes of code surrounded by margin, in module coordinates
+type styledShapes struct {
+	modules [][]*roundedRect // shape for each dark module, excluding finder patterns
+	eyes    []eye
+}
+
+func newStyledShapes(code qrcode.QRCode, style Style, margin int) styledShapes {
+	pat := WithMargin(code.Pattern, margin)
+	modules := make([][]*roundedRect, len(pat))
+	for y, row := range pat {
+		modules[y] = make([]*roundedRect, len(row))
+		for x, cell := range row {
+			if !cell || isFinder(code, x-margin, y-margin) {
+				continue
+			}
+			shape := moduleShape(style.Module, pat, x, y)
+			modules[y][x] = &shape
+		}
+	}
+
+	size := float64(len(code.Pattern))
+	m := float64(margin)
+	eyes := make([]eye, 0, 3)
+	for _, origin := range [][2]float64{{0, 0}, {size - 7, 0}, {0, size - 7}} {
+		eyes = append(eyes, newEye(style.EyeFrame, style.EyeBall, origin[0]+m, origin[1]+m))
+	}
+
+	return styledShapes{modules: modules, eyes: eyes}
+}
+
+func isFinder(code qrcode.QRCode, x, y int) bool {
+	return y >= 0 && y < len(code.Roles) && x >= 0 && x < len(code.Roles[y]) && code.Roles[y][x] == qrcode.FinderRole
+}
+
+// returns the color at point in module coordinates, or nil if light
+func (s styledShapes) colorAt(px, py float64, style Style, fg color.Color) color.Color {
+	for _, e := range s.eyes {
+		if e.frameContains(px, py) {
+			return colorOrDefault(style.EyeFrameColor, fg)
+		}
+		if e.ball.contains(px, py) {
+			return colorOrDefault(style.EyeBallColor, fg)
+		}
+	}
+
+	x, y := int(px), int(py)
+	if y < len(s.modules) && x < len(s.modules[y]) && s.modules[y][x] != nil && s.modules[y][x].contains(px, py) {
+		return fg
+	}
+	return nil
+}
+
+func colorOrDefault(c color.Color, def color.Color) color.Color {
+	if c == nil {
+		return def
+	}
+	return c
+}
@@ -0,0 +1,70 @@
+package render
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNewStyle(t *testing.T) {
+	testcases := []struct {
+		module  string
+		frame   string
+		ball    string
+		wantErr error
+	}{
+		{module: "", frame: "", ball: "", wantErr: nil},
+		{module: "liquid", frame: "circle", ball: "rounded", wantErr: nil},
+		{module: "star", frame: "", ball: "", wantErr: errors.New("unexpected module shape: star")},
+		{module: "dot", frame: "", ball: "heart", wantErr: errors.New("unexpected eye shape: heart")},
+	}
+
+	for _, tt := range testcases {
+		t.Run("testing NewStyle()", func(t *testing.T) {
+			_, err := NewStyle(tt.module, tt.frame, tt.ball)
+			if (err == nil) != (tt.wantErr == nil) || (err != nil && err.Error() != tt.wantErr.Error()) {
+				t.Errorf("NewStyle() error = '%v'; expected '%v'", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRoundedRectContains(t *testing.T) {
+	testcases := []struct {
+		rect   roundedRect
+		px, py float64
+		want   bool
+	}{
+		{rect: uniformRect(0, 0, 1, 1, 0), px: 0.01, py: 0.01, want: true},
+		{rect: uniformRect(0, 0, 1, 1, 0.5), px: 0.01, py: 0.01, want: false},
+		{rect: uniformRect(0, 0, 1, 1, 0.5), px: 0.5, py: 0.01, want: true},
+		{rect: uniformRect(0, 0, 1, 1, 0.5), px: 0.99, py: 0.99, want: false},
+		{rect: roundedRect{x: 0, y: 0, w: 1, h: 1, radii: [4]float64{0.5, 0, 0, 0}}, px: 0.99, py: 0.01, want: true},
+		{rect: uniformRect(0, 0, 1, 1, 0), px: 1.5, py: 0.5, want: false},
+	}
+
+	for _, tt := range testcases {
+		t.Run("testing roundedRect.contains()", func(t *testing.T) {
+			if got := tt.rect.contains(tt.px, tt.py); got != tt.want {
+				t.Errorf("roundedRect.contains(%v, %v) = %v; expected %v", tt.px, tt.py, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoundedRectPathData(t *testing.T) {
+	testcases := []struct {
+		rect roundedRect
+		want string
+	}{
+		{rect: uniformRect(1, 2, 3, 3, 0), want: "M1 2h3v3h-3v-3z"},
+		{rect: uniformRect(0, 0, 1, 1, 0.5), want: "M0.5 0a0.5 0.5 0 0 1 0.5 0.5a0.5 0.5 0 0 1 -0.5 0.5a0.5 0.5 0 0 1 -0.5 -0.5a0.5 0.5 0 0 1 0.5 -0.5z"},
+	}
+
+	for _, tt := range testcases {
+		t.Run("testing roundedRect.pathData()", func(t *testing.T) {
+			if got := tt.rect.pathData(); got != tt.want {
+				t.Errorf("roundedRect.pathData() = %v; expected %v", got, tt.want)
+			}
+		})
+	}
+}
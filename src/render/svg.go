@@ -0,0 +1,147 @@
+package render
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+
+	svg "github.com/ajstarks/svgo"
+	"github.com/pasca-l/wifi-qrcode-generator/qrcode"
+)
+
+// draws dark modules as a single path in module coordinates, scaled by viewBox
+// so that the image scales to any size without seams between modules
+type SVGRenderer struct {
+	Title string // accessible name, read by screen readers
+	Desc  string // accessible description
+}
+
+func (SVGRenderer) Name() string {
+	return "svg"
+}
+
+func (SVGRenderer) MIMEType() string {
+	return "image/svg+xml"
+}
+
+func (sr SVGRenderer) Render(w io.Writer, code qrcode.QRCode, opts RenderOptions) error {
+	err := opts.Validate()
+	if err != nil {
+		return err
+	}
+	err = checkLogo(code, opts)
+	if err != nil {
+		return err
+	}
+
+	pat := WithMargin(code.Pattern, opts.Margin)
+	size := len(pat)
+
+	s := svg.New(w)
+	attrs := []string{fmt.Sprintf(`viewBox="0 0 %d %d"`, size, size)}
+	if sr.Title != "" || sr.Desc != "" {
+		attrs = append(attrs, `role="img"`)
+	}
+	s.Start(opts.Size, opts.Size, attrs...)
+	if sr.Title != "" {
+		s.Title(sr.Title)
+	}
+	if sr.Desc != "" {
+		s.Desc(sr.Desc)
+	}
+	if opts.Gradient != nil {
+		s.Def()
+		defineGradient(s, *opts.Gradient, float64(opts.Margin), float64(len(code.Pattern)))
+		s.DefEnd()
+	}
+	s.Rect(0, 0, size, size, fmt.Sprintf(`fill="%s"`, hexColor(opts.Background)))
+	if opts.Style.isPlain() {
+		s.Path(ContourPath(pat), fmt.Sprintf(`fill="%s" shape-rendering="crispEdges"`, foregroundFill(opts)))
+	} else {
+		drawStyledSVG(s, code, opts)
+	}
+	if opts.Logo != nil {
+		// image keeps its aspect ratio centered in the box by default
+		x, y, w, h := logoBox(code, opts.Margin)
+		link := fmt.Sprintf("data:%s;base64,%s", opts.Logo.MIMEType, base64.StdEncoding.EncodeToString(opts.Logo.Data))
+		fmt.Fprintf(s.Writer, `<image x="%s" y="%s" width="%s" height="%s" href="%s" />`+"\n", fnum(x), fnum(y), fnum(w), fnum(h), link)
+
+		dark, light := knockoutFunctionModules(code, opts.Margin)
+		if !isEmpty(light) {
+			s.Path(ContourPath(light), fmt.Sprintf(`fill="%s" shape-rendering="crispEdges"`, hexColor(opts.Background)))
+		}
+		if !isEmpty(dark) {
+			s.Path(ContourPath(dark), fmt.Sprintf(`fill="%s" shape-rendering="crispEdges"`, foregroundFill(opts)))
+		}
+	}
+	s.End()
+
+	return nil
+}
+
+func drawStyledSVG(s *svg.SVG, code qrcode.QRCode, opts RenderOptions) {
+	shapes := newStyledShapes(code, opts.Style, opts.Margin)
+
+	modules := new(strings.Builder)
+	if opts.Style.Module == "" || opts.Style.Module == SquareModule {
+		// trace square modules for a compact path, excluding finder patterns
+		pat := qrcode.NewPattern(len(shapes.modules))
+		for y, row := range shapes.modules {
+			for x, shape := range row {
+				pat[y][x] = shape != nil
+			}
+		}
+		modules.WriteString(ContourPath(pat))
+	} else {
+		for _, row := range shapes.modules {
+			for _, shape := range row {
+				if shape != nil {
+					modules.WriteString(shape.pathData())
+				}
+			}
+		}
+	}
+	s.Path(modules.String(), fmt.Sprintf(`fill="%s"`, foregroundFill(opts)))
+
+	frames, balls := new(strings.Builder), new(strings.Builder)
+	for _, e := range shapes.eyes {
+		frames.WriteString(e.outer.pathData() + e.inner.pathData())
+		balls.WriteString(e.ball.pathData())
+	}
+	frameFill, ballFill := foregroundFill(opts), foregroundFill(opts)
+	if opts.Style.EyeFrameColor != nil {
+		frameFill = hexColor(opts.Style.EyeFrameColor)
+	}
+	if opts.Style.EyeBallColor != nil {
+		ballFill = hexColor(opts.Style.EyeBallColor)
+	}
+	s.Path(frames.String(), fmt.Sprintf(`fill="%s" fill-rule="evenodd"`, frameFill))
+	s.Path(balls.String(), fmt.Sprintf(`fill="%s"`, ballFill))
+}
+
+// id of gradient definition, referenced by fills of dark modules
+const gradientID string = "foreground"
+
+func foregroundFill(opts RenderOptions) string {
+	if opts.Gradient != nil {
+		return "url(#" + gradientID + ")"
+	}
+	return hexColor(opts.Foreground)
+}
+
+// defines gradient in user space, so that all paths share the same gradient over the code area
+func defineGradient(s *svg.SVG, g Gradient, offset float64, size float64) {
+	stops := fmt.Sprintf(`<stop offset="0" stop-color="%s" /><stop offset="1" stop-color="%s" />`, hexColor(g.From), hexColor(g.To))
+	switch g.Kind {
+	case RadialGradient:
+		fmt.Fprintf(s.Writer, `<radialGradient id="%s" gradientUnits="userSpaceOnUse" cx="%s" cy="%s" r="%s">%s</radialGradient>`+"\n",
+			gradientID, fnum(offset+size/2), fnum(offset+size/2), fnum(size*radialRadius), stops,
+		)
+	default:
+		x1, y1, x2, y2 := g.endpoints()
+		fmt.Fprintf(s.Writer, `<linearGradient id="%s" gradientUnits="userSpaceOnUse" x1="%s" y1="%s" x2="%s" y2="%s">%s</linearGradient>`+"\n",
+			gradientID, fnum(offset+x1*size), fnum(offset+y1*size), fnum(offset+x2*size), fnum(offset+y2*size), stops,
+		)
+	}
+}
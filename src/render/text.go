@@ -0,0 +1,166 @@
+package render
+
+import (
+	"bufio"
+	"fmt"
+	"image/color"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pasca-l/wifi-qrcode-generator/qrcode"
+)
+
+type TextMode string
+
+const (
+	HalfBlockText TextMode = "half-block" // packs 2 rows per line with Unicode half blocks
+	ANSIText      TextMode = "ansi"       // half blocks colored by ANSI escape sequences
+	ASCIIText     TextMode = "ascii"      // 2 characters per module, for terminals without Unicode
+)
+
+// draws code for terminals, where size is meaningless and only margin is used
+type TextRenderer struct {
+	Mode TextMode // half-block if empty
+	// terminal draws light text on dark background, so that light modules are drawn
+	// with characters instead of dark ones, ignored for ANSI mode as it sets both colors
+	DarkTerminal bool
+}
+
+func (TextRenderer) Name() string {
+	return "txt"
+}
+
+func (TextRenderer) MIMEType() string {
+	return "text/plain"
+}
+
+func ParseTextMode(mode string) (TextMode, error) {
+	switch TextMode(mode) {
+	case "":
+		return HalfBlockText, nil
+	case HalfBlockText, ANSIText, ASCIIText:
+		return TextMode(mode), nil
+	default:
+		return "", fmt.Errorf("unexpected text mode: %s", mode)
+	}
+}
+
+func (tr TextRenderer) Render(w io.Writer, code qrcode.QRCode, opts RenderOptions) error {
+	err := opts.Validate()
+	if err != nil {
+		return err
+	}
+	mode, err := ParseTextMode(string(tr.Mode))
+	if err != nil {
+		return err
+	}
+
+	pat := WithMargin(code.Pattern, opts.Margin)
+	bw := bufio.NewWriter(w)
+	switch mode {
+	case ANSIText:
+		writeANSI(bw, pat, opts)
+	case ASCIIText:
+		writeASCII(bw, pat, tr.DarkTerminal)
+	default:
+		writeHalfBlocks(bw, pat, tr.DarkTerminal)
+	}
+
+	return bw.Flush()
+}
+
+// reports whether module is drawn with characters, rather than left as terminal background
+func inked(pat qrcode.Pattern, x int, y int, darkTerminal bool) bool {
+	if y >= len(pat) {
+		// below the code for odd number of rows
+		return false
+	}
+	return pat[y][x] != darkTerminal
+}
+
+func writeHalfBlocks(bw *bufio.Writer, pat qrcode.Pattern, darkTerminal bool) {
+	for y := 0; y < len(pat); y += 2 {
+		for x := range pat[y] {
+			top, bottom := inked(pat, x, y, darkTerminal), inked(pat, x, y+1, darkTerminal)
+			switch {
+			case top && bottom:
+				bw.WriteString("█")
+			case top:
+				bw.WriteString("▀")
+			case bottom:
+				bw.WriteString("▄")
+			default:
+				bw.WriteString(" ")
+			}
+		}
+		bw.WriteString("\n")
+	}
+}
+
+// draws each module as 2 characters wide, to keep the code roughly square
+func writeASCII(bw *bufio.Writer, pat qrcode.Pattern, darkTerminal bool) {
+	for y, row := range pat {
+		for x := range row {
+			if inked(pat, x, y, darkTerminal) {
+				bw.WriteString("##")
+			} else {
+				bw.WriteString("  ")
+			}
+		}
+		bw.WriteString("\n")
+	}
+}
+
+// draws upper half blocks with foreground as upper module color and background as lower module color,
+// using 24-bit colors so that output does not depend on terminal theme
+func writeANSI(bw *bufio.Writer, pat qrcode.Pattern, opts RenderOptions) {
+	margin, codeSize := float64(opts.Margin), float64(len(pat)-2*opts.Margin)
+	colorAt := func(x, y int) color.Color {
+		if !pat[y][x] {
+			return opts.Background
+		}
+		return opts.foregroundAt((float64(x)+0.5-margin)/codeSize, (float64(y)+0.5-margin)/codeSize)
+	}
+
+	for y := 0; y < len(pat); y += 2 {
+		prev := ""
+		for x := range pat[y] {
+			seq := ansiColor(38, colorAt(x, y))
+			if y+1 < len(pat) {
+				seq += ansiColor(48, colorAt(x, y+1))
+			} else {
+				seq += "\x1b[49m" // default background below the code
+			}
+			if seq != prev {
+				bw.WriteString(seq)
+				prev = seq
+			}
+			bw.WriteString("▀")
+		}
+		bw.WriteString("\x1b[0m\n")
+	}
+}
+
+// escape sequence setting 24-bit color, with 38 for foreground and 48 for background
+func ansiColor(param int, c color.Color) string {
+	r, g, b, _ := c.RGBA()
+	return fmt.Sprintf("\x1b[%d;2;%d;%d;%dm", param, r>>8, g>>8, b>>8)
+}
+
+// reports whether terminal has dark background, from COLORFGBG set by terminals such as rxvt and Konsole
+// as foreground and background color indices, assuming light background if unknown
+func DetectDarkTerminal() bool {
+	return isDarkColorFGBG(os.Getenv("COLORFGBG"))
+}
+
+func isDarkColorFGBG(colorfgbg string) bool {
+	fields := strings.Split(colorfgbg, ";")
+	bg, err := strconv.Atoi(fields[len(fields)-1])
+	if err != nil {
+		return false
+	}
+	// indices 0 to 6 and 8 are dark colors in the standard 16 color palette
+	return (bg >= 0 && bg <= 6) || bg == 8
+}
@@ -0,0 +1,63 @@
+package render
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pasca-l/wifi-qrcode-generator/qrcode"
+)
+
+func TestTextRendererRender(t *testing.T) {
+	code := qrcode.QRCode{Pattern: qrcode.Pattern{{true, false, true}, {false, true, false}, {true, true, false}}}
+	opts := DefaultRenderOptions()
+	opts.Margin = 0
+
+	testcases := []struct {
+		renderer TextRenderer
+		want     string
+	}{
+		{renderer: TextRenderer{}, want: "▀▄▀\n▀▀ \n"},
+		{renderer: TextRenderer{DarkTerminal: true}, want: "▄▀▄\n  ▀\n"},
+		{renderer: TextRenderer{Mode: ASCIIText}, want: "##  ##\n  ##  \n####  \n"},
+		{renderer: TextRenderer{Mode: ASCIIText, DarkTerminal: true}, want: "  ##  \n##  ##\n    ##\n"},
+		{
+			renderer: TextRenderer{Mode: ANSIText},
+			want: "\x1b[38;2;0;0;0m\x1b[48;2;255;255;255m▀\x1b[38;2;255;255;255m\x1b[48;2;0;0;0m▀\x1b[38;2;0;0;0m\x1b[48;2;255;255;255m▀\x1b[0m\n" +
+				"\x1b[38;2;0;0;0m\x1b[49m▀▀\x1b[38;2;255;255;255m\x1b[49m▀\x1b[0m\n",
+		},
+	}
+
+	for _, tt := range testcases {
+		t.Run("testing TextRenderer.Render()", func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			err := tt.renderer.Render(buf, code, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("TextRenderer.Render() = %q; expected %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestIsDarkColorFGBG(t *testing.T) {
+	testcases := []struct {
+		colorfgbg string
+		want      bool
+	}{
+		{colorfgbg: "15;0", want: true},
+		{colorfgbg: "0;15", want: false},
+		{colorfgbg: "15;default;8", want: true},
+		{colorfgbg: "", want: false},
+	}
+
+	for _, tt := range testcases {
+		t.Run("testing isDarkColorFGBG()", func(t *testing.T) {
+			got := isDarkColorFGBG(tt.colorfgbg)
+			if got != tt.want {
+				t.Errorf("isDarkColorFGBG(%q) = %v; expected %v", tt.colorfgbg, got, tt.want)
+			}
+		})
+	}
+}
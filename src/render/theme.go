@@ -0,0 +1,173 @@
+package render
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+type GradientKind string
+
+const (
+	LinearGradient GradientKind = "linear"
+	RadialGradient GradientKind = "radial"
+)
+
+// gradient filling dark modules over the code area, excluding margin
+type Gradient struct {
+	Kind  GradientKind
+	From  color.Color // color at start of linear gradient, or at center of radial gradient
+	To    color.Color // color at end of linear gradient, or at corners of radial gradient
+	Angle float64     // direction of linear gradient in degrees, clockwise from left to right
+}
+
+// radius of radial gradient reaching corners of the code area, in unit square
+var radialRadius float64 = math.Sqrt2 / 2
+
+func (g Gradient) Validate() error {
+	switch g.Kind {
+	case LinearGradient, RadialGradient:
+	default:
+		return fmt.Errorf("unexpected gradient kind: %s", g.Kind)
+	}
+	if g.From == nil || g.To == nil {
+		return fmt.Errorf("gradient colors must be set")
+	}
+	return nil
+}
+
+// start and end points of linear gradient in unit square of the code area,
+// with y axis pointing down, placed so that corners get the end colors
+func (g Gradient) endpoints() (float64, float64, float64, float64) {
+	rad := g.Angle * math.Pi / 180
+	dx, dy := math.Cos(rad), math.Sin(rad)
+	half := (math.Abs(dx) + math.Abs(dy)) / 2
+	return 0.5 - dx*half, 0.5 - dy*half, 0.5 + dx*half, 0.5 + dy*half
+}
+
+// color at the position in unit square of the code area
+func (g Gradient) at(x, y float64) color.Color {
+	var t float64
+	switch g.Kind {
+	case RadialGradient:
+		t = math.Hypot(x-0.5, y-0.5) / radialRadius
+	default:
+		x1, y1, x2, y2 := g.endpoints()
+		dx, dy := x2-x1, y2-y1
+		t = ((x-x1)*dx + (y-y1)*dy) / (dx*dx + dy*dy)
+	}
+	return interpolate(g.From, g.To, min(max(t, 0), 1))
+}
+
+func interpolate(from color.Color, to color.Color, t float64) color.Color {
+	c0 := color.NRGBAModel.Convert(from).(color.NRGBA)
+	c1 := color.NRGBAModel.Convert(to).(color.NRGBA)
+	lerp := func(a, b uint8) uint8 {
+		return uint8(math.Round(float64(a) + (float64(b)-float64(a))*t))
+	}
+	return color.NRGBA{R: lerp(c0.R, c1.R), G: lerp(c0.G, c1.G), B: lerp(c0.B, c1.B), A: lerp(c0.A, c1.A)}
+}
+
+// color of dark modules at the position in unit square of the code area
+func (opts RenderOptions) foregroundAt(x, y float64) color.Color {
+	if opts.Gradient == nil {
+		return opts.Foreground
+	}
+	return opts.Gradient.at(x, y)
+}
+
+// set of colors applied to render options
+type Theme struct {
+	Foreground color.Color
+	Background color.Color
+	Gradient   *Gradient // fills dark modules instead of foreground, if set
+	Inverted   bool      // foreground is lighter than background
+}
+
+var themes = map[string]Theme{
+	"classic": {
+		Foreground: color.Black,
+		Background: color.White,
+	},
+	"forest": {
+		Foreground: color.RGBA{0x1b, 0x43, 0x32, 0xff},
+		Background: color.RGBA{0xf1, 0xfa, 0xee, 0xff},
+	},
+	"ocean": {
+		Foreground: color.RGBA{0x0b, 0x3d, 0x91, 0xff},
+		Background: color.White,
+		Gradient: &Gradient{
+			Kind:  LinearGradient,
+			From:  color.RGBA{0x0b, 0x3d, 0x91, 0xff},
+			To:    color.RGBA{0x0e, 0x5e, 0x6f, 0xff},
+			Angle: 45,
+		},
+	},
+	"sunset": {
+		Foreground: color.RGBA{0x7a, 0x1f, 0x5c, 0xff},
+		Background: color.RGBA{0xff, 0xfa, 0xf0, 0xff},
+		Gradient: &Gradient{
+			Kind: RadialGradient,
+			From: color.RGBA{0x7a, 0x1f, 0x5c, 0xff},
+			To:   color.RGBA{0xa3, 0x35, 0x0a, 0xff},
+		},
+	},
+	"midnight": {
+		Foreground: color.RGBA{0xe8, 0xee, 0xf7, 0xff},
+		Background: color.RGBA{0x0b, 0x1d, 0x3a, 0xff},
+		Inverted:   true,
+	},
+}
+
+func GetTheme(name string) (Theme, error) {
+	t, exists := themes[name]
+	if !exists {
+		return Theme{}, fmt.Errorf("unexpected theme: %s", name)
+	}
+	return t, nil
+}
+
+func ThemeNames() []string {
+	names := make([]string, 0, len(themes))
+	for name := range themes {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// swaps foreground and background for light-on-dark codes
+// gradient is dropped, as its dark colors would be drawn on the background swapped to dark
+func (t Theme) Invert() Theme {
+	t.Foreground, t.Background = t.Background, t.Foreground
+	t.Gradient = nil
+	t.Inverted = !t.Inverted
+	return t
+}
+
+func (t Theme) Apply(opts RenderOptions) RenderOptions {
+	opts.Foreground = t.Foreground
+	opts.Background = t.Background
+	opts.Gradient = t.Gradient
+	opts.Inverted = t.Inverted
+	return opts
+}
+
+// parses hex color in #rgb or #rrggbb form, where # is optional
+func ParseColor(s string) (color.Color, error) {
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) != 6 {
+		return nil, fmt.Errorf("unexpected color: %s", s)
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return nil, fmt.Errorf("unexpected color: %s", s)
+	}
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 0xff}, nil //nolint:gosec // bytes of 24 bit value
+}
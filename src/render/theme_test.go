@@ -0,0 +1,92 @@
+package render
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image/color"
+	"testing"
+
+	"github.com/pasca-l/wifi-qrcode-generator/qrcode"
+)
+
+func TestParseColor(t *testing.T) {
+	testcases := []struct {
+		s       string
+		want    color.Color
+		wantErr error
+	}{
+		{s: "#0b3d91", want: color.RGBA{0x0b, 0x3d, 0x91, 0xff}, wantErr: nil},
+		{s: "fff", want: color.RGBA{0xff, 0xff, 0xff, 0xff}, wantErr: nil},
+		{s: "#12345", want: nil, wantErr: errors.New("unexpected color: #12345")},
+		{s: "#gggggg", want: nil, wantErr: errors.New("unexpected color: #gggggg")},
+	}
+
+	for _, tt := range testcases {
+		t.Run("testing ParseColor()", func(t *testing.T) {
+			got, err := ParseColor(tt.s)
+			if (err == nil) != (tt.wantErr == nil) || (err != nil && err.Error() != tt.wantErr.Error()) {
+				t.Errorf("ParseColor() error = '%v'; expected '%v'", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseColor() = %v; expected %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGradientAt(t *testing.T) {
+	black, white := color.NRGBA{0, 0, 0, 0xff}, color.NRGBA{0xff, 0xff, 0xff, 0xff}
+	testcases := []struct {
+		gradient Gradient
+		x, y     float64
+		want     color.Color
+	}{
+		{gradient: Gradient{Kind: LinearGradient, From: black, To: white}, x: 0, y: 0.3, want: black},
+		{gradient: Gradient{Kind: LinearGradient, From: black, To: white}, x: 1, y: 0.7, want: white},
+		{gradient: Gradient{Kind: LinearGradient, From: black, To: white, Angle: 90}, x: 0.2, y: 1, want: white},
+		{gradient: Gradient{Kind: LinearGradient, From: black, To: white, Angle: 45}, x: 1, y: 0, want: color.NRGBA{0x80, 0x80, 0x80, 0xff}},
+		{gradient: Gradient{Kind: RadialGradient, From: black, To: white}, x: 0.5, y: 0.5, want: black},
+		{gradient: Gradient{Kind: RadialGradient, From: black, To: white}, x: 0, y: 1, want: white},
+	}
+
+	for _, tt := range testcases {
+		t.Run("testing Gradient.at()", func(t *testing.T) {
+			got := tt.gradient.at(tt.x, tt.y)
+			if got != tt.want {
+				t.Errorf("Gradient.at(%v, %v) = %v; expected %v", tt.x, tt.y, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestThemesRender(t *testing.T) {
+	src := "WIFI:T:WPA;S:\"ssid\";P:\"password\";;"
+	spec, err := qrcode.NewQRCodeSpec(src, qrcode.L)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := qrcode.NewQRCode(src, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range ThemeNames() {
+		theme, err := GetTheme(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// inverted themes must stay readable as well
+		for _, theme := range []Theme{theme, theme.Invert()} {
+			opts := theme.Apply(DefaultRenderOptions())
+			for _, r := range []Renderer{SVGRenderer{}, PNGRenderer{}, PDFRenderer{}, EPSRenderer{}} {
+				t.Run(fmt.Sprintf("testing Renderer.Render() with theme %s, inverted %v", name, theme.Inverted), func(t *testing.T) {
+					err := r.Render(new(bytes.Buffer), code, opts)
+					if err != nil {
+						t.Errorf("%s Renderer.Render() error = '%v'; expected nil", r.Name(), err)
+					}
+				})
+			}
+		}
+	}
+}
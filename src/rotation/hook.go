@@ -0,0 +1,101 @@
+package rotation
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"time"
+)
+
+// new password of network, pushed to access points before it is shown
+type Rotation struct {
+	NetworkID    string    `json:"network_id"`
+	Name         string    `json:"name"`
+	SSID         string    `json:"ssid"`
+	Password     string    `json:"password"`
+	NextPassword string    `json:"next_password"` // password of the following rotation, for access points accepting both
+	RotatedAt    time.Time `json:"rotated_at"`
+}
+
+// pushes new password to access points, such as through their controller API
+// rotation is aborted if any hook fails, so that the shown code keeps matching access points
+type Hook interface {
+	Push(ctx context.Context, r Rotation) error
+}
+
+// runs command with rotation as JSON on standard input, which is not visible to other processes unlike arguments
+type ExecHook struct {
+	Path string
+}
+
+func (h ExecHook) Push(ctx context.Context, r Rotation) error {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	// output is discarded, as commands echoing their input would leak passwords into errors and logs
+	cmd := exec.CommandContext(ctx, h.Path)
+	cmd.Stdin = bytes.NewReader(body)
+	err = cmd.Run()
+	if err != nil {
+		return fmt.Errorf("hook %s failed: %w", h.Path, err)
+	}
+	return nil
+}
+
+// header carrying HMAC-SHA256 of body in hex, such as "sha256=0123..."
+const SignatureHeader string = "X-Wifiqr-Signature-256"
+
+// posts rotation as JSON, signed by secret if given
+type WebhookHook struct {
+	URL    string
+	Secret string
+	Client *http.Client // http.DefaultClient if nil
+}
+
+// bytes of response bodies read, so that connections can be reused
+const maxResponseSize int64 = 1024
+
+func (h WebhookHook) Push(ctx context.Context, r Rotation) error {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", h.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if h.Secret != "" {
+		req.Header.Set(SignatureHeader, "sha256="+Sign([]byte(h.Secret), body))
+	}
+
+	client := h.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, maxResponseSize))
+	if res.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook %s responded with status %d", req.URL.Redacted(), res.StatusCode)
+	}
+	return nil
+}
+
+// returns HMAC-SHA256 of body in hex, for receivers to verify webhooks
+func Sign(secret []byte, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,176 @@
+package rotation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/pasca-l/wifi-qrcode-generator/passphrase"
+	"github.com/pasca-l/wifi-qrcode-generator/vault"
+)
+
+var ErrNotRotatable = errors.New("only WPA networks can be rotated")
+
+// interval of checking networks due, which is the resolution of schedules
+const checkInterval time.Duration = time.Minute
+
+// delay before failed rotations are retried, not to flood access points or webhooks
+const retryDelay time.Duration = 5 * time.Minute
+
+// time given to hooks of each rotation
+const hookTimeout time.Duration = time.Minute
+
+// rotates passwords of networks saved in vault, which are pushed through hooks before they are saved
+// next passwords are generated a rotation ahead, so that cards can be printed before they take effect
+type Rotator struct {
+	vault    *vault.Vault
+	schedule Schedule       // zero value only rotates on demand
+	location *time.Location // schedule is evaluated in, which is local time
+	hooks    []Hook
+	policy   passphrase.Policy
+
+	mu      sync.Mutex // serializes rotations and updates, so that hooks are not given passwords out of order and no change is lost
+	retryAt map[string]time.Time
+}
+
+func NewRotator(v *vault.Vault, schedule Schedule, hooks []Hook, policy passphrase.Policy) *Rotator {
+	return &Rotator{vault: v, schedule: schedule, location: time.Local, hooks: hooks, policy: policy, retryAt: make(map[string]time.Time)}
+}
+
+// returns time of next rotation of network, or zero time if not rotated on schedule
+func (r *Rotator) NextRotation(n vault.Network) time.Time {
+	if !n.Rotate || n.RotatedAt.IsZero() {
+		return time.Time{}
+	}
+	// rotation times are saved in UTC, while schedules are written in local time
+	return r.schedule.Next(n.RotatedAt.In(r.location))
+}
+
+// prepares network before saving, so that schedule is counted from when rotation is enabled
+// rotation is only allowed for WPA, as other networks have no shared passphrase
+func (r *Rotator) Prepare(n vault.Network, now time.Time) (vault.Network, error) {
+	if !n.Rotate {
+		n.NextPassword = ""
+		n.RotatedAt = time.Time{}
+		return n, nil
+	}
+	if n.Encryption != "WPA" {
+		return vault.Network{}, fmt.Errorf("%w: given %s", ErrNotRotatable, n.Encryption)
+	}
+	if n.RotatedAt.IsZero() {
+		n.RotatedAt = now.UTC().Truncate(time.Second)
+	}
+	if n.NextPassword == "" {
+		var err error
+		n.NextPassword, err = passphrase.Generate(r.policy)
+		if err != nil {
+			return vault.Network{}, err
+		}
+	}
+	return n, nil
+}
+
+// replaces password of network by the next one, once every hook accepts it
+func (r *Rotator) Rotate(ctx context.Context, id string, now time.Time) (vault.Network, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n, err := r.vault.Get(id)
+	if err != nil {
+		return vault.Network{}, err
+	}
+	if n.Encryption != "WPA" {
+		return vault.Network{}, fmt.Errorf("%w: given %s", ErrNotRotatable, n.Encryption)
+	}
+	// stored next password is pushed again on retries, so that hooks see the same one
+	password := n.NextPassword
+	if password == "" {
+		password, err = passphrase.Generate(r.policy)
+		if err != nil {
+			return vault.Network{}, err
+		}
+	}
+	next, err := passphrase.Generate(r.policy)
+	if err != nil {
+		return vault.Network{}, err
+	}
+	rotatedAt := now.UTC().Truncate(time.Second)
+
+	ctx, cancel := context.WithTimeout(ctx, hookTimeout)
+	defer cancel()
+	rot := Rotation{NetworkID: n.ID, Name: n.Name, SSID: n.SSID, Password: password, NextPassword: next, RotatedAt: rotatedAt}
+	for _, hook := range r.hooks {
+		err := hook.Push(ctx, rot)
+		if err != nil {
+			return vault.Network{}, err
+		}
+	}
+
+	n.Password, n.NextPassword, n.RotatedAt = password, next, rotatedAt
+	n, err = r.vault.Update(id, n)
+	if err != nil {
+		return vault.Network{}, fmt.Errorf("password was pushed, but cannot be saved: %w", err)
+	}
+	return n, nil
+}
+
+// updates network by the given function of the saved one, serialized with rotations,
+// so that updates neither overwrite a password pushed meanwhile nor are overwritten by it
+func (r *Rotator) Update(id string, update func(old vault.Network) (vault.Network, error)) (vault.Network, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	old, err := r.vault.Get(id)
+	if err != nil {
+		return vault.Network{}, err
+	}
+	n, err := update(old)
+	if err != nil {
+		return vault.Network{}, err
+	}
+	return r.vault.Update(id, n)
+}
+
+// rotates networks whose rotation is due, retrying failed ones after delay
+func (r *Rotator) RotateDue(ctx context.Context, now time.Time) error {
+	var errs []error
+	for _, n := range r.vault.List() {
+		due := r.NextRotation(n)
+		if due.IsZero() || now.Before(due) || now.Before(r.retryAt[n.ID]) {
+			continue
+		}
+		_, err := r.Rotate(ctx, n.ID, now)
+		if errors.Is(err, vault.ErrNotFound) {
+			// deleted after listed
+			continue
+		}
+		if err != nil {
+			r.retryAt[n.ID] = now.Add(retryDelay)
+			errs = append(errs, fmt.Errorf("network %s: %w", n.ID, err))
+			continue
+		}
+		delete(r.retryAt, n.ID)
+		slog.InfoContext(ctx, "rotated password", slog.String("network_id", n.ID))
+	}
+	return errors.Join(errs...)
+}
+
+// rotates due networks until context is done
+func (r *Rotator) Run(ctx context.Context) {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+	for {
+		err := r.RotateDue(ctx, time.Now())
+		if err != nil {
+			slog.ErrorContext(ctx, "cannot rotate password", slog.Any("error", err))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
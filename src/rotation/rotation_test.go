@@ -0,0 +1,293 @@
+package rotation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pasca-l/wifi-qrcode-generator/passphrase"
+	"github.com/pasca-l/wifi-qrcode-generator/vault"
+)
+
+type recordingHook struct {
+	rotations []Rotation
+	err       error
+}
+
+func (h *recordingHook) Push(ctx context.Context, r Rotation) error {
+	h.rotations = append(h.rotations, r)
+	return h.err
+}
+
+func newTestVault(t *testing.T) *vault.Vault {
+	t.Helper()
+	v, err := vault.Open(filepath.Join(t.TempDir(), "vault.json"), make([]byte, vault.KeySize))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestRotatorRotateDue(t *testing.T) {
+	v := newTestVault(t)
+	hook := &recordingHook{}
+	schedule, err := ParseSchedule("0 6 * * 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := NewRotator(v, schedule, []Hook{hook}, passphrase.DefaultPolicy())
+	r.location = time.UTC
+
+	// Wednesday
+	enabledAt := time.Date(2026, 10, 14, 10, 30, 0, 0, time.UTC)
+	n, err := r.Prepare(vault.Network{Name: "Guest", SSID: "guest", Password: "correct horse", Encryption: "WPA", Rotate: true}, enabledAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err = v.Create(n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := time.Date(2026, 10, 19, 6, 0, 0, 0, time.UTC)
+	if got := r.NextRotation(n); !got.Equal(want) {
+		t.Fatalf("Rotator.NextRotation() = %v; expected %v", got, want)
+	}
+
+	// not due yet
+	err = r.RotateDue(context.Background(), want.Add(-time.Minute))
+	if err != nil || len(hook.rotations) != 0 {
+		t.Fatalf("Rotator.RotateDue() before schedule pushed %d rotations, error = '%v'", len(hook.rotations), err)
+	}
+
+	// failed hooks keep current password, and are retried after delay
+	hook.err = errors.New("access point unreachable")
+	err = r.RotateDue(context.Background(), want)
+	if err == nil {
+		t.Errorf("Rotator.RotateDue() error = nil; expected error of hook")
+	}
+	got, _ := v.Get(n.ID)
+	if got.Password != "correct horse" {
+		t.Errorf("password after failed hook = %s; expected 'correct horse'", got.Password)
+	}
+	_ = r.RotateDue(context.Background(), want.Add(time.Minute))
+	if len(hook.rotations) != 1 {
+		t.Errorf("Rotator.RotateDue() within retry delay pushed %d rotations; expected 1", len(hook.rotations))
+	}
+
+	hook.err = nil
+	err = r.RotateDue(context.Background(), want.Add(retryDelay))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ = v.Get(n.ID)
+	// the pregenerated password is pushed on every attempt, then becomes the current one
+	if got.Password != n.NextPassword || hook.rotations[0].Password != n.NextPassword || hook.rotations[1].Password != n.NextPassword {
+		t.Errorf("password after rotation = %s; expected next password %s", got.Password, n.NextPassword)
+	}
+	if got.NextPassword == "" || got.NextPassword == n.NextPassword {
+		t.Errorf("next password after rotation = %s; expected newly generated one", got.NextPassword)
+	}
+	if want := time.Date(2026, 10, 26, 6, 0, 0, 0, time.UTC); !r.NextRotation(got).Equal(want) {
+		t.Errorf("Rotator.NextRotation() after rotation = %v; expected %v", r.NextRotation(got), want)
+	}
+}
+
+func TestRotatorNextRotation(t *testing.T) {
+	schedule, err := ParseSchedule("0 6 * * 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := NewRotator(newTestVault(t), schedule, nil, passphrase.DefaultPolicy())
+	r.location = time.FixedZone("JST", 9*60*60)
+
+	testcases := []struct {
+		rotatedAt time.Time
+		want      time.Time
+	}{
+		// Wednesday
+		{rotatedAt: time.Date(2026, 10, 14, 1, 30, 0, 0, time.UTC), want: time.Date(2026, 10, 19, 6, 0, 0, 0, r.location)},
+		// Sunday in UTC, while already Monday after 6:00 in local time
+		{rotatedAt: time.Date(2026, 10, 18, 22, 0, 0, 0, time.UTC), want: time.Date(2026, 10, 26, 6, 0, 0, 0, r.location)},
+		// Sunday in UTC, while Monday before 6:00 in local time
+		{rotatedAt: time.Date(2026, 10, 18, 20, 0, 0, 0, time.UTC), want: time.Date(2026, 10, 19, 6, 0, 0, 0, r.location)},
+	}
+
+	for _, tt := range testcases {
+		t.Run("testing Rotator.NextRotation()", func(t *testing.T) {
+			got := r.NextRotation(vault.Network{Rotate: true, RotatedAt: tt.rotatedAt})
+			if !got.Equal(tt.want) {
+				t.Errorf("Rotator.NextRotation() of %v = %v; expected %v", tt.rotatedAt, got, tt.want)
+			}
+		})
+	}
+}
+
+// signals when pushed, and holds the rotation until released
+type blockingHook struct {
+	pushed  chan struct{}
+	release chan struct{}
+}
+
+func (h blockingHook) Push(ctx context.Context, r Rotation) error {
+	close(h.pushed)
+	<-h.release
+	return nil
+}
+
+func TestRotatorUpdate(t *testing.T) {
+	v := newTestVault(t)
+	hook := blockingHook{pushed: make(chan struct{}), release: make(chan struct{})}
+	r := NewRotator(v, Schedule{}, []Hook{hook}, passphrase.DefaultPolicy())
+	n, err := r.Prepare(vault.Network{Name: "Guest", SSID: "guest", Password: "correct horse", Encryption: "WPA", Rotate: true}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err = v.Create(n)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rotated := make(chan error, 1)
+	go func() {
+		_, err := r.Rotate(context.Background(), n.ID, time.Now())
+		rotated <- err
+	}()
+	<-hook.pushed
+
+	// update waits for the rotation in progress, and is applied to the rotated network
+	updated := make(chan error, 1)
+	go func() {
+		_, err := r.Update(n.ID, func(old vault.Network) (vault.Network, error) {
+			old.Name = "Lobby"
+			return old, nil
+		})
+		updated <- err
+	}()
+	select {
+	case err := <-updated:
+		t.Fatalf("Rotator.Update() returned during rotation, error = '%v'; expected to wait", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(hook.release)
+	if err := <-rotated; err != nil {
+		t.Fatal(err)
+	}
+	if err := <-updated; err != nil {
+		t.Fatal(err)
+	}
+
+	got, _ := v.Get(n.ID)
+	if got.Name != "Lobby" || got.Password != n.NextPassword {
+		t.Errorf("network after rotation and update = %q with password %q; expected %q with %q", got.Name, got.Password, "Lobby", n.NextPassword)
+	}
+}
+
+func TestRotatorPrepare(t *testing.T) {
+	r := NewRotator(newTestVault(t), Schedule{}, nil, passphrase.DefaultPolicy())
+	now := time.Now()
+
+	testcases := []struct {
+		network vault.Network
+		wantErr error
+	}{
+		{network: vault.Network{SSID: "guest", Encryption: "WPA", Rotate: true}, wantErr: nil},
+		{network: vault.Network{SSID: "guest", Encryption: "WPA", NextPassword: "stale", RotatedAt: now}, wantErr: nil},
+		{network: vault.Network{SSID: "guest", Encryption: "nopass", Rotate: true}, wantErr: errors.New("only WPA networks can be rotated: given nopass")},
+	}
+
+	for _, tt := range testcases {
+		t.Run("testing Rotator.Prepare()", func(t *testing.T) {
+			got, err := r.Prepare(tt.network, now)
+			if (err == nil) != (tt.wantErr == nil) || (err != nil && err.Error() != tt.wantErr.Error()) {
+				t.Fatalf("Rotator.Prepare() error = '%v'; expected '%v'", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got.Rotate != (got.NextPassword != "") || got.Rotate != !got.RotatedAt.IsZero() {
+				t.Errorf("Rotator.Prepare() = %+v; expected next password and rotation time only if rotated", got)
+			}
+		})
+	}
+}
+
+func TestExecHook(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "rotation.json")
+	script := filepath.Join(dir, "hook.sh")
+	err := os.WriteFile(script, []byte("#!/bin/sh\ncat > "+out+"\n"), 0o700)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := Rotation{NetworkID: "id", SSID: "guest", Password: "new password"}
+	err = ExecHook{Path: script}.Push(context.Background(), want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Rotation
+	err = json.Unmarshal(data, &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("ExecHook.Push() gave %+v; expected %+v", got, want)
+	}
+
+	err = ExecHook{Path: "/bin/false"}.Push(context.Background(), want)
+	if err == nil {
+		t.Errorf("ExecHook.Push() of failing command error = nil; expected error")
+	}
+
+	// output of commands echoing their input is not kept in errors
+	echo := filepath.Join(dir, "echo.sh")
+	err = os.WriteFile(echo, []byte("#!/bin/sh\ncat\nexit 1\n"), 0o700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ExecHook{Path: echo}.Push(context.Background(), want)
+	if err == nil || strings.Contains(err.Error(), want.Password) {
+		t.Errorf("ExecHook.Push() of echoing command error = '%v'; expected error without password", err)
+	}
+}
+
+func TestWebhookHook(t *testing.T) {
+	secret := "webhook secret"
+	var got Rotation
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get(SignatureHeader) != "sha256="+Sign([]byte(secret), body) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.Unmarshal(body, &got)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	want := Rotation{NetworkID: "id", SSID: "guest", Password: "new password"}
+	err := WebhookHook{URL: ts.URL, Secret: secret}.Push(context.Background(), want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("WebhookHook.Push() gave %+v; expected %+v", got, want)
+	}
+
+	err = WebhookHook{URL: ts.URL, Secret: "wrong"}.Push(context.Background(), want)
+	if err == nil || err.Error() != "webhook "+ts.URL+" responded with status 401" {
+		t.Errorf("WebhookHook.Push() error = '%v'; expected status 401", err)
+	}
+}
@@ -0,0 +1,68 @@
+package rotation
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseSchedule(t *testing.T) {
+	testcases := []struct {
+		expr    string
+		wantErr error
+	}{
+		{expr: "0 6 * * 1", wantErr: nil},
+		{expr: "*/15 9-17 * * 1-5", wantErr: nil},
+		{expr: "0 0 1,15 * *", wantErr: nil},
+		{expr: "@weekly", wantErr: nil},
+		{expr: "0 6 * *", wantErr: errors.New("schedule must have 5 fields: given '0 6 * *'")},
+		{expr: "60 6 * * 1", wantErr: errors.New("minute must be between 0 and 59: given '60'")},
+		{expr: "0 18-9 * * *", wantErr: errors.New("hour must be between 0 and 23: given '18-9'")},
+		{expr: "0 6 * * mon", wantErr: errors.New("cannot convert value 'mon' of day of week to integer")},
+		{expr: "*/0 * * * *", wantErr: errors.New("cannot convert value '0' of minute to step")},
+		{expr: "0 0 30 2 *", wantErr: errors.New("schedule never matches: given '0 0 30 2 *'")},
+	}
+
+	for _, tt := range testcases {
+		t.Run("testing ParseSchedule()", func(t *testing.T) {
+			_, err := ParseSchedule(tt.expr)
+			if (err == nil) != (tt.wantErr == nil) || (err != nil && err.Error() != tt.wantErr.Error()) {
+				t.Errorf("ParseSchedule() error = '%v'; expected '%v'", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	// Wednesday
+	from := time.Date(2026, 10, 14, 10, 30, 0, 0, time.UTC)
+
+	testcases := []struct {
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{expr: "0 6 * * 1", from: from, want: time.Date(2026, 10, 19, 6, 0, 0, 0, time.UTC)},
+		{expr: "0 6 * * 7", from: from, want: time.Date(2026, 10, 18, 6, 0, 0, 0, time.UTC)},
+		{expr: "*/15 * * * *", from: from, want: time.Date(2026, 10, 14, 10, 45, 0, 0, time.UTC)},
+		{expr: "30 10 * * *", from: from, want: time.Date(2026, 10, 15, 10, 30, 0, 0, time.UTC)},
+		{expr: "0 0 1 * *", from: from, want: time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)},
+		{expr: "0 0 29 2 *", from: from, want: time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// either day of month or day of week matches, if both are restricted
+		{expr: "0 0 20 * 5", from: from, want: time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)},
+		{expr: "@monthly", from: from, want: time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range testcases {
+		t.Run("testing Schedule.Next()", func(t *testing.T) {
+			s, err := ParseSchedule(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := s.Next(tt.from)
+			if !got.Equal(tt.want) {
+				t.Errorf("Schedule.Next() of '%s' = %v; expected %v", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
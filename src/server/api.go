@@ -0,0 +1,359 @@
+package server
+
+import (
+	"bytes"
+	"crypto/rand"
+	_ "embed"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pasca-l/wifi-qrcode-generator/qrcode"
+	"github.com/pasca-l/wifi-qrcode-generator/render"
+)
+
+//go:embed openapi.json
+var openAPIDocument []byte
+
+// problem details of failed API requests
+// referenced: https://www.rfc-editor.org/rfc/rfc7807
+type problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+}
+
+func writeProblem(w http.ResponseWriter, r *http.Request, status int, detail string) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(problem{
+		Type:     "about:blank", // status code alone describes the problem
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   detail,
+		Instance: r.URL.Path,
+	})
+}
+
+type codeRequest struct {
+	Payload payloadRequest `json:"payload"`
+	ECL     string         `json:"ecl"`
+	Render  renderRequest  `json:"render"`
+	Image   string         `json:"image"` // inline or link, inline if empty
+}
+
+type payloadRequest struct {
+	Type       string      `json:"type"` // only wifi is supported, which is also the default
+	SSID       string      `json:"ssid"`
+	Password   string      `json:"password"`
+	Encryption string      `json:"encryption"`
+	Hidden     bool        `json:"hidden"`
+	PSK        bool        `json:"psk"` // encodes PSK derived from passphrase, instead of passphrase itself
+	EAP        *eapRequest `json:"eap"`
+}
+
+type eapRequest struct {
+	Method            string `json:"method"`
+	Phase2            string `json:"phase2"`
+	Identity          string `json:"identity"`
+	AnonymousIdentity string `json:"anonymous_identity"`
+}
+
+// render options named after form fields, so that both are validated in the same way
+type renderRequest struct {
+	Format        string   `json:"format"`
+	Size          int      `json:"size"`
+	Margin        *int     `json:"margin"`
+	ModuleShape   string   `json:"module_shape"`
+	EyeFrame      string   `json:"eye_frame"`
+	EyeBall       string   `json:"eye_ball"`
+	Theme         string   `json:"theme"`
+	Foreground    string   `json:"foreground"`
+	Background    string   `json:"background"`
+	Gradient      string   `json:"gradient"`
+	GradientTo    string   `json:"gradient_to"`
+	GradientAngle *float64 `json:"gradient_angle"`
+	Invert        bool     `json:"invert"`
+	TextMode      string   `json:"text_mode"`
+	Terminal      string   `json:"terminal"`
+	Page          string   `json:"page"`
+	CropMarks     bool     `json:"crop_marks"`
+	ModuleMM      *float64 `json:"module_mm"`
+	BleedMM       *float64 `json:"bleed_mm"`
+}
+
+type codeResponse struct {
+	Payload  string        `json:"payload"`
+	Version  int           `json:"version"`
+	ECL      string        `json:"ecl"`
+	Mask     int           `json:"mask"`
+	Size     int           `json:"size"` // modules per side, excluding margin
+	Image    imageResponse `json:"image"`
+	Warnings []string      `json:"warnings,omitempty"`
+}
+
+type imageResponse struct {
+	MediaType string     `json:"media_type"`
+	Data      string     `json:"data,omitempty"` // base64 encoded image, if inline
+	Href      string     `json:"href,omitempty"` // link to the image, if linked
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// converts payload into parameters of the form
+func (p payloadRequest) values() url.Values {
+	params := url.Values{
+		"ssid":       {p.SSID},
+		"password":   {p.Password},
+		"encryption": {p.Encryption},
+		"hidden":     {strconv.FormatBool(p.Hidden)},
+		"psk":        {strconv.FormatBool(p.PSK)},
+	}
+	if p.EAP != nil {
+		params.Set("eap_method", p.EAP.Method)
+		params.Set("phase2", p.EAP.Phase2)
+		params.Set("identity", p.EAP.Identity)
+		params.Set("anonymous_identity", p.EAP.AnonymousIdentity)
+	}
+	return params
+}
+
+// converts render options into parameters of the form, leaving unset ones empty
+func (o renderRequest) values() url.Values {
+	params := url.Values{
+		"format":       {o.Format},
+		"module_shape": {o.ModuleShape},
+		"eye_frame":    {o.EyeFrame},
+		"eye_ball":     {o.EyeBall},
+		"theme":        {o.Theme},
+		"foreground":   {o.Foreground},
+		"background":   {o.Background},
+		"gradient":     {o.Gradient},
+		"gradient_to":  {o.GradientTo},
+		"invert":       {strconv.FormatBool(o.Invert)},
+		"text_mode":    {o.TextMode},
+		"terminal":     {o.Terminal},
+		"page":         {o.Page},
+		"crop_marks":   {strconv.FormatBool(o.CropMarks)},
+	}
+	if o.Margin != nil {
+		params.Set("margin", strconv.Itoa(*o.Margin))
+	}
+	for name, value := range map[string]*float64{"gradient_angle": o.GradientAngle, "module_mm": o.ModuleMM, "bleed_mm": o.BleedMM} {
+		if value != nil {
+			params.Set(name, strconv.FormatFloat(*value, 'f', -1, 64))
+		}
+	}
+	return params
+}
+
+// generates code from JSON spec, reporting how it was encoded along with the image
+func (s *server) codesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		w.Header().Set("Allow", "POST")
+		writeProblem(w, r, http.StatusMethodNotAllowed, "POST method required")
+		return
+	}
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "application/json" {
+		writeProblem(w, r, http.StatusUnsupportedMediaType, "request body must be application/json")
+		return
+	}
+
+	var req codeRequest
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, s.config.MaxBodySize))
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(&req)
+	if err != nil {
+		writeProblem(w, r, limitStatus(err, http.StatusBadRequest), fmt.Sprintf("cannot decode request: %v", err))
+		return
+	}
+
+	// well-formed requests with invalid values cannot be processed
+	res, err := s.generateCode(req)
+	if errors.Is(err, errImageStoreFull) {
+		w.Header().Set("Retry-After", strconv.Itoa(int(imageLifetime.Seconds())))
+		writeProblem(w, r, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+	if err != nil {
+		writeProblem(w, r, limitStatus(err, http.StatusUnprocessableEntity), err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if res.Image.Href != "" {
+		w.Header().Set("Location", res.Image.Href)
+		w.WriteHeader(http.StatusCreated)
+	}
+	_ = json.NewEncoder(w).Encode(res)
+}
+
+func (s *server) generateCode(req codeRequest) (codeResponse, error) {
+	if req.Payload.Type != "" && req.Payload.Type != wifiPayload {
+		return codeResponse{}, fmt.Errorf("unexpected payload type: %s", req.Payload.Type)
+	}
+	if req.Image != "" && req.Image != "inline" && req.Image != "link" {
+		return codeResponse{}, fmt.Errorf("unexpected image delivery: %s", req.Image)
+	}
+	spec, err := qrcode.NewWifiSpec(req.Payload.values())
+	if err != nil {
+		return codeResponse{}, err
+	}
+	ecl, err := s.eclOrDefault(req.ECL)
+	if err != nil {
+		return codeResponse{}, err
+	}
+
+	src := spec.Encode()
+	qrCodeSpec, err := qrcode.NewQRCodeSpec(src, ecl)
+	if err != nil {
+		return codeResponse{}, err
+	}
+	err = s.checkBudget(qrCodeSpec.Version(), qrCodeSpec.ECL())
+	if err != nil {
+		return codeResponse{}, err
+	}
+	code, err := qrcode.NewQRCode(src, qrCodeSpec)
+	if err != nil {
+		return codeResponse{}, err
+	}
+
+	params := req.Render.values()
+	renderer, err := s.newRenderer(params, spec)
+	if err != nil {
+		return codeResponse{}, err
+	}
+	opts, err := renderOptions(params)
+	if err != nil {
+		return codeResponse{}, err
+	}
+	if req.Render.Size != 0 {
+		opts.Size = req.Render.Size
+	}
+	err = opts.Validate()
+	if err != nil {
+		return codeResponse{}, err
+	}
+	warnings, err := render.CheckContrast(opts)
+	if err != nil {
+		return codeResponse{}, err
+	}
+	buf := new(bytes.Buffer)
+	start := time.Now()
+	err = renderer.Render(buf, code, opts)
+	if err != nil {
+		return codeResponse{}, err
+	}
+	s.metrics.observeRender(time.Since(start))
+	s.metrics.observeCode(wifiPayload, renderer.Name(), code)
+
+	res := codeResponse{
+		Payload:  src,
+		Version:  int(code.Version),
+		ECL:      code.ECL.ToString(),
+		Mask:     int(code.Mask),
+		Size:     len(code.Pattern),
+		Image:    imageResponse{MediaType: renderer.MIMEType()},
+		Warnings: warnings,
+	}
+	if req.Image == "link" {
+		id, expiresAt, err := s.images.add(buf.Bytes(), renderer.MIMEType())
+		if err != nil {
+			return codeResponse{}, err
+		}
+		res.Image.Href = s.config.BasePath + imagePath + id
+		res.Image.ExpiresAt = &expiresAt
+	} else {
+		res.Image.Data = base64.StdEncoding.EncodeToString(buf.Bytes())
+	}
+	return res, nil
+}
+
+// path of linked images, followed by their identifiers
+const imagePath string = "/api/v1/images/"
+
+// serves images linked from responses, until they expire
+func (s *server) imageHandler(w http.ResponseWriter, r *http.Request) {
+	data, mediaType, ok := s.images.get(r.PathValue("id"))
+	if !ok {
+		writeProblem(w, r, http.StatusNotFound, "image does not exist or has expired")
+		return
+	}
+	if mediaType == (render.TextRenderer{}).MIMEType() {
+		mediaType += "; charset=utf-8"
+	}
+	w.Header().Set("Content-Type", mediaType)
+	// images hold credentials, so that they must not be kept by shared caches
+	w.Header().Set("Cache-Control", "private, no-store")
+	_, _ = w.Write(data)
+}
+
+func (s *server) openAPIHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(openAPIDocument)
+}
+
+// time and number of images kept for links, bounding memory held by unused ones
+const imageLifetime time.Duration = 10 * time.Minute
+const maxStoredImages int = 256
+
+var errImageStoreFull = errors.New("too many linked images: retry later or use inline images")
+
+type storedImage struct {
+	data      []byte
+	mediaType string
+	expiresAt time.Time
+}
+
+// images kept in memory, keyed by random identifiers which cannot be guessed
+type imageStore struct {
+	mu     sync.Mutex
+	images map[string]storedImage
+}
+
+func newImageStore() *imageStore {
+	return &imageStore{images: make(map[string]storedImage)}
+}
+
+func (s *imageStore) add(data []byte, mediaType string) (string, time.Time, error) {
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	id := base64.RawURLEncoding.EncodeToString(b)
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, image := range s.images {
+		if now.After(image.expiresAt) {
+			delete(s.images, key)
+		}
+	}
+	if len(s.images) >= maxStoredImages {
+		return "", time.Time{}, errImageStoreFull
+	}
+	expiresAt := now.Add(imageLifetime).UTC().Truncate(time.Second)
+	s.images[id] = storedImage{data: data, mediaType: mediaType, expiresAt: expiresAt}
+	return id, expiresAt, nil
+}
+
+func (s *imageStore) get(id string) ([]byte, string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	image, ok := s.images[id]
+	if !ok || time.Now().After(image.expiresAt) {
+		return nil, "", false
+	}
+	return image.data, image.mediaType, true
+}
@@ -0,0 +1,37 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCodesHandler(t *testing.T) {
+	s := newTestServer(t, testConfig())
+	handler := s.handler()
+
+	payload := `"payload": {"ssid": "guest", "password": "correct horse", "encryption": "WPA"}`
+	testcases := []struct {
+		body       string
+		wantStatus int
+	}{
+		{body: `{` + payload + `}`, wantStatus: http.StatusOK},
+		{body: `{` + payload + `, "render": {"format": "png", "size": 4096}}`, wantStatus: http.StatusOK},
+		{body: `{` + payload + `, "render": {"format": "png", "size": 100000}}`, wantStatus: http.StatusUnprocessableEntity},
+		{body: `{` + payload + `, "render": {"format": "png", "size": -1}}`, wantStatus: http.StatusUnprocessableEntity},
+		{body: `{` + payload + `, "render": {"margin": 1000000}}`, wantStatus: http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range testcases {
+		t.Run("testing server.codesHandler()", func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/api/v1/codes", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			res := httptest.NewRecorder()
+			handler.ServeHTTP(res, req)
+			if res.Code != tt.wantStatus {
+				t.Errorf("POST /api/v1/codes %s status = %d; expected %d: %s", tt.body, res.Code, tt.wantStatus, res.Body.String())
+			}
+		})
+	}
+}
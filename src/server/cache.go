@@ -0,0 +1,117 @@
+package server
+
+import (
+	"container/list"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// rendered response, kept in cache and written to clients
+type cachedResponse struct {
+	contentType string
+	warnings    []string
+	body        []byte
+}
+
+func (c cachedResponse) size() int64 {
+	size := int64(len(c.contentType) + len(c.body))
+	for _, w := range c.warnings {
+		size += int64(len(w))
+	}
+	return size
+}
+
+type cacheKey [sha256.Size]byte
+
+// returns entity tag of response, which is derived from key as responses are deterministic
+func (k cacheKey) etag() string {
+	return `"` + hex.EncodeToString(k[:16]) + `"`
+}
+
+type cacheEntry struct {
+	key      cacheKey
+	response cachedResponse
+}
+
+// bounded in-memory LRU cache of rendered responses
+// keys are HMAC of requests with a secret chosen at start, so that neither keys
+// nor entity tags derived from them reveal the credentials they were generated from
+type responseCache struct {
+	secret  []byte
+	maxSize int64 // in bytes, caching nothing if 0
+
+	mu      sync.Mutex
+	size    int64
+	order   *list.List // of *cacheEntry, most recently used first
+	entries map[cacheKey]*list.Element
+}
+
+func newResponseCache(maxSize int64) *responseCache {
+	secret := make([]byte, 32)
+	_, _ = rand.Read(secret)
+	return &responseCache{
+		secret:  secret,
+		maxSize: maxSize,
+		order:   list.New(),
+		entries: make(map[cacheKey]*list.Element),
+	}
+}
+
+// returns key of request to route, where form values are encoded in sorted order
+func (c *responseCache) key(route string, form url.Values) cacheKey {
+	mac := hmac.New(sha256.New, c.secret)
+	mac.Write([]byte(route + "\x00" + form.Encode()))
+	var k cacheKey
+	copy(k[:], mac.Sum(nil))
+	return k
+}
+
+func (c *responseCache) get(k cacheKey) (cachedResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[k]
+	if !ok {
+		return cachedResponse{}, false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*cacheEntry).response, true
+}
+
+// adds response, evicting least recently used ones until it fits
+func (c *responseCache) add(k cacheKey, res cachedResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// large responses would evict most of the others
+	if res.size() > c.maxSize/8 {
+		return
+	}
+	if elem, ok := c.entries[k]; ok {
+		c.order.MoveToFront(elem)
+		return
+	}
+	for c.size+res.size() > c.maxSize {
+		oldest := c.order.Back()
+		entry := c.order.Remove(oldest).(*cacheEntry)
+		delete(c.entries, entry.key)
+		c.size -= entry.response.size()
+	}
+	c.entries[k] = c.order.PushFront(&cacheEntry{key: k, response: res})
+	c.size += res.size()
+}
+
+// reports whether client already has the response of entity tag
+func notModified(r *http.Request, etag string) bool {
+	for _, tag := range strings.Split(r.Header.Get("If-None-Match"), ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == etag || tag == "*" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestResponseCache(t *testing.T) {
+	res := cachedResponse{contentType: "text/plain", body: []byte("0")}
+	// cache is filled by eight responses, as each of them may take an eighth of it
+	c := newResponseCache(res.size() * 8)
+	keys := make([]cacheKey, 9)
+	for i := range keys {
+		keys[i] = c.key("qrcode", url.Values{"ssid": {strconv.Itoa(i)}})
+	}
+	for _, k := range keys[:8] {
+		c.add(k, res)
+	}
+	// least recently used one is the second, after the first one is used
+	c.get(keys[0])
+	c.add(keys[8], res)
+
+	testcases := []struct {
+		key  cacheKey
+		want bool
+	}{
+		{key: keys[0], want: true},
+		{key: keys[1], want: false},
+		{key: keys[2], want: true},
+		{key: keys[8], want: true},
+	}
+	for _, tt := range testcases {
+		t.Run("testing responseCache.get()", func(t *testing.T) {
+			_, got := c.get(tt.key)
+			if got != tt.want {
+				t.Errorf("responseCache.get() = %v; expected %v", got, tt.want)
+			}
+		})
+	}
+
+	if c.key("card", url.Values{"ssid": {"0"}}) == keys[0] {
+		t.Errorf("responseCache.key() expected to differ by route")
+	}
+}
+
+func TestQRCodeHandlerCache(t *testing.T) {
+	s := newTestServer(t, testConfig())
+	handler := s.handler()
+	form := url.Values{"ssid": {"guest"}, "password": {"correct horse"}, "encryption": {"WPA"}, "format": {"txt"}}
+	post := func(etag string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/qrcode", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		return res
+	}
+
+	first := post("")
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" {
+		t.Fatalf("POST /qrcode status = %d, ETag = %q", first.Code, etag)
+	}
+	if strings.Contains(etag, "guest") || strings.Contains(etag, "horse") {
+		t.Errorf("ETag = %s; expected not to contain payload", etag)
+	}
+
+	second := post("")
+	if second.Body.String() != first.Body.String() || second.Header().Get("ETag") != etag {
+		t.Errorf("POST /qrcode expected to return the same response")
+	}
+	if s.metrics.cache[true] != 1 || s.metrics.cache[false] != 1 {
+		t.Errorf("cache hits = %d, misses = %d; expected 1, 1", s.metrics.cache[true], s.metrics.cache[false])
+	}
+
+	conditional := post(etag)
+	if conditional.Code != http.StatusNotModified || conditional.Body.Len() != 0 {
+		t.Errorf("POST /qrcode with If-None-Match status = %d; expected %d", conditional.Code, http.StatusNotModified)
+	}
+}
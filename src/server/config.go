@@ -0,0 +1,417 @@
+package server
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pasca-l/wifi-qrcode-generator/passphrase"
+	"github.com/pasca-l/wifi-qrcode-generator/qrcode"
+	"github.com/pasca-l/wifi-qrcode-generator/render"
+	"github.com/pasca-l/wifi-qrcode-generator/rotation"
+	"github.com/pasca-l/wifi-qrcode-generator/vault"
+)
+
+type Config struct {
+	Addr     string // listen address, such as ":8080"
+	TLSCert  string // serves HTTPS if both certificate and key are given
+	TLSKey   string
+	BasePath string // path prefix added by reverse proxy, such as "/wifi"
+
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	ShutdownTimeout   time.Duration // time to drain requests in flight on SIGINT or SIGTERM
+	MaxBodySize       int64         // in bytes, also the memory limit of uploaded files
+	CacheSize         int64         // in bytes of rendered responses kept in memory, caching nothing if 0
+	RequestsPerMinute int           // per client generating codes, except loopback other than trusted proxies, unlimited if 0
+	Burst             int           // requests allowed at once before being limited
+	TrustedProxies    string        // comma separated addresses or prefixes of proxies, whose clients are taken from X-Forwarded-For
+	MaxVersion        int           // largest code version generated per request
+	TokenLifetime     time.Duration // longest lifetime of tokens referencing networks in image links
+
+	DefaultECL    qrcode.ErrorCorrectionLevel
+	DefaultFormat string
+	SigningKey    string // PEM file signing mobileconfig profiles, unsigned if empty
+
+	VaultPath     string        // encrypted file saving networks, disabled if empty
+	VaultKey      string        // AES-256 key of vault in base64
+	AdminToken    string        // bearer token administering vault, required along with vault
+	ShareLifetime time.Duration // longest lifetime of share links
+
+	RotationSchedule      string // cron expression rotating passwords of networks, only on demand if empty
+	RotationMode          string // mode of generated passphrases
+	RotationExec          string // command given rotations on standard input
+	RotationWebhook       string // URL given rotations by POST
+	RotationWebhookSecret string // key signing webhook bodies by HMAC-SHA256
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Addr:              ":8080",
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      30 * time.Second,
+		IdleTimeout:       2 * time.Minute,
+		ShutdownTimeout:   15 * time.Second,
+		MaxBodySize:       4 << 20,
+		CacheSize:         32 << 20,
+		RequestsPerMinute: 120,
+		Burst:             20,
+		MaxVersion:        25,
+		TokenLifetime:     24 * time.Hour,
+		DefaultECL:        qrcode.L,
+		DefaultFormat:     "svg",
+		ShareLifetime:     30 * 24 * time.Hour,
+		RotationMode:      string(passphrase.PrintableMode),
+	}
+}
+
+// setting read from config file, environment variable and flag, all given as strings
+type setting struct {
+	key   string // key in config file, prefixed by table name
+	env   string
+	flag  string
+	usage string
+	set   func(c *Config, value string) error
+}
+
+func settings() []setting {
+	return []setting{
+		{key: "addr", env: "WIFIQR_ADDR", flag: "addr", usage: "listen address", set: func(c *Config, v string) error {
+			c.Addr = v
+			return nil
+		}},
+		{key: "tls.cert", env: "WIFIQR_TLS_CERT", flag: "tls-cert", usage: "TLS certificate file", set: func(c *Config, v string) error {
+			c.TLSCert = v
+			return nil
+		}},
+		{key: "tls.key", env: "WIFIQR_TLS_KEY", flag: "tls-key", usage: "TLS private key file", set: func(c *Config, v string) error {
+			c.TLSKey = v
+			return nil
+		}},
+		{key: "base_path", env: "WIFIQR_BASE_PATH", flag: "base-path", usage: "path prefix behind reverse proxy", set: func(c *Config, v string) error {
+			c.BasePath = v
+			return nil
+		}},
+		durationSetting("timeouts.read_header", "read-header-timeout", func(c *Config) *time.Duration { return &c.ReadHeaderTimeout }),
+		durationSetting("timeouts.read", "read-timeout", func(c *Config) *time.Duration { return &c.ReadTimeout }),
+		durationSetting("timeouts.write", "write-timeout", func(c *Config) *time.Duration { return &c.WriteTimeout }),
+		durationSetting("timeouts.idle", "idle-timeout", func(c *Config) *time.Duration { return &c.IdleTimeout }),
+		durationSetting("timeouts.shutdown", "shutdown-timeout", func(c *Config) *time.Duration { return &c.ShutdownTimeout }),
+		{key: "limits.max_body_size", env: "WIFIQR_MAX_BODY_SIZE", flag: "max-body-size", usage: "maximum request body size in bytes", set: func(c *Config, v string) error {
+			size, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return fmt.Errorf("cannot convert value '%s' to integer", v)
+			}
+			c.MaxBodySize = size
+			return nil
+		}},
+		{key: "limits.cache_size", env: "WIFIQR_CACHE_SIZE", flag: "cache-size", usage: "size of rendered responses cached in memory in bytes, 0 to disable", set: func(c *Config, v string) error {
+			size, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return fmt.Errorf("cannot convert value '%s' to integer", v)
+			}
+			c.CacheSize = size
+			return nil
+		}},
+		intSetting("limits.requests_per_minute", "requests-per-minute", "requests per minute per client, 0 to disable rate limiting", func(c *Config) *int { return &c.RequestsPerMinute }),
+		intSetting("limits.burst", "burst", "requests per client allowed at once", func(c *Config) *int { return &c.Burst }),
+		{key: "limits.trusted_proxies", env: "WIFIQR_TRUSTED_PROXIES", flag: "trusted-proxies", usage: "comma separated addresses or prefixes of reverse proxies setting X-Forwarded-For", set: func(c *Config, v string) error {
+			c.TrustedProxies = v
+			return nil
+		}},
+		intSetting("limits.max_version", "max-version", "largest code version generated per request", func(c *Config) *int { return &c.MaxVersion }),
+		durationSetting("tokens.lifetime", "token-lifetime", func(c *Config) *time.Duration { return &c.TokenLifetime }),
+		{key: "defaults.ecl", env: "WIFIQR_DEFAULT_ECL", flag: "default-ecl", usage: "default error correction level", set: func(c *Config, v string) error {
+			ecl, err := qrcode.ToErrorCorrectionLevel(v)
+			c.DefaultECL = ecl
+			return err
+		}},
+		{key: "defaults.format", env: "WIFIQR_DEFAULT_FORMAT", flag: "default-format", usage: "default output format", set: func(c *Config, v string) error {
+			c.DefaultFormat = v
+			return nil
+		}},
+		{key: "signing_key", env: "WIFIQR_SIGNING_KEY", flag: "signing-key", usage: "PEM file with certificate and key signing mobileconfig profiles", set: func(c *Config, v string) error {
+			c.SigningKey = v
+			return nil
+		}},
+		{key: "vault.path", env: "WIFIQR_VAULT_PATH", flag: "vault-path", usage: "encrypted file saving networks", set: func(c *Config, v string) error {
+			c.VaultPath = v
+			return nil
+		}},
+		{key: "vault.key", env: "WIFIQR_VAULT_KEY", flag: "vault-key", usage: "AES-256 key of vault in base64, such as generated by `openssl rand -base64 32`", set: func(c *Config, v string) error {
+			c.VaultKey = v
+			return nil
+		}},
+		{key: "vault.admin_token", env: "WIFIQR_ADMIN_TOKEN", flag: "admin-token", usage: "bearer token administering vault, required along with vault path", set: func(c *Config, v string) error {
+			c.AdminToken = v
+			return nil
+		}},
+		durationSetting("vault.share_lifetime", "share-lifetime", func(c *Config) *time.Duration { return &c.ShareLifetime }),
+		{key: "rotation.schedule", env: "WIFIQR_ROTATION_SCHEDULE", flag: "rotation-schedule", usage: "cron expression rotating passwords of networks, such as \"0 6 * * 1\" or \"@weekly\"", set: func(c *Config, v string) error {
+			c.RotationSchedule = v
+			return nil
+		}},
+		{key: "rotation.mode", env: "WIFIQR_ROTATION_MODE", flag: "rotation-mode", usage: "mode of rotated passphrases: diceware, printable or hex", set: func(c *Config, v string) error {
+			c.RotationMode = v
+			return nil
+		}},
+		{key: "rotation.exec", env: "WIFIQR_ROTATION_EXEC", flag: "rotation-exec", usage: "command given new passwords as JSON on standard input", set: func(c *Config, v string) error {
+			c.RotationExec = v
+			return nil
+		}},
+		{key: "rotation.webhook", env: "WIFIQR_ROTATION_WEBHOOK", flag: "rotation-webhook", usage: "URL given new passwords as JSON by POST", set: func(c *Config, v string) error {
+			c.RotationWebhook = v
+			return nil
+		}},
+		{key: "rotation.webhook_secret", env: "WIFIQR_ROTATION_WEBHOOK_SECRET", flag: "rotation-webhook-secret", usage: "key signing webhook bodies by HMAC-SHA256", set: func(c *Config, v string) error {
+			c.RotationWebhookSecret = v
+			return nil
+		}},
+	}
+}
+
+func durationSetting(key string, flagName string, field func(c *Config) *time.Duration) setting {
+	return setting{
+		key:   key,
+		env:   "WIFIQR_" + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_")),
+		flag:  flagName,
+		usage: strings.ReplaceAll(flagName, "-", " ") + ", such as 30s",
+		set: func(c *Config, v string) error {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return fmt.Errorf("cannot convert value '%s' to duration", v)
+			}
+			*field(c) = d
+			return nil
+		},
+	}
+}
+
+func intSetting(key string, flagName string, usage string, field func(c *Config) *int) setting {
+	return setting{
+		key:   key,
+		env:   "WIFIQR_" + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_")),
+		flag:  flagName,
+		usage: usage,
+		set: func(c *Config, v string) error {
+			i, err := strconv.Atoi(v)
+			if err != nil {
+				return fmt.Errorf("cannot convert value '%s' to integer", v)
+			}
+			*field(c) = i
+			return nil
+		},
+	}
+}
+
+// loads config from defaults, optional TOML file, environment variables and flags,
+// where the later ones take precedence
+func LoadConfig(args []string, getenv func(string) string) (Config, error) {
+	fs := flag.NewFlagSet("wifi-qrcode-generator", flag.ContinueOnError)
+	configFile := fs.String("config", getenv("WIFIQR_CONFIG"), "TOML config file")
+	flagValues := make(map[string]string)
+	for _, s := range settings() {
+		fs.Func(s.flag, fmt.Sprintf("%s (env %s)", s.usage, s.env), func(v string) error {
+			flagValues[s.flag] = v
+			return nil
+		})
+	}
+	err := fs.Parse(args)
+	if err != nil {
+		return Config{}, err
+	}
+
+	fileValues := make(map[string]string)
+	if *configFile != "" {
+		f, err := os.Open(*configFile)
+		if err != nil {
+			return Config{}, err
+		}
+		defer f.Close()
+		fileValues, err = parseTOML(f)
+		if err != nil {
+			return Config{}, fmt.Errorf("%s: %w", *configFile, err)
+		}
+	}
+
+	config := DefaultConfig()
+	known := make(map[string]bool)
+	for _, s := range settings() {
+		known[s.key] = true
+		for _, source := range []struct{ name, value string }{
+			{name: *configFile + ": " + s.key, value: fileValues[s.key]},
+			{name: s.env, value: getenv(s.env)},
+			{name: "-" + s.flag, value: flagValues[s.flag]},
+		} {
+			if source.value == "" {
+				continue
+			}
+			err := s.set(&config, source.value)
+			if err != nil {
+				return Config{}, fmt.Errorf("%s: %w", source.name, err)
+			}
+		}
+	}
+	for key := range fileValues {
+		if !known[key] {
+			return Config{}, fmt.Errorf("%s: unexpected key: %s", *configFile, key)
+		}
+	}
+
+	return config, config.Validate()
+}
+
+func (c Config) Validate() error {
+	if (c.TLSCert == "") != (c.TLSKey == "") {
+		return fmt.Errorf("both TLS certificate and key must be given")
+	}
+	if c.BasePath != "" && (!strings.HasPrefix(c.BasePath, "/") || strings.HasSuffix(c.BasePath, "/")) {
+		return fmt.Errorf("base path must start with '/' and must not end with '/': given %s", c.BasePath)
+	}
+	if c.MaxBodySize < 1 {
+		return fmt.Errorf("max body size must be larger than 0: given %d", c.MaxBodySize)
+	}
+	if c.RequestsPerMinute < 0 || (c.RequestsPerMinute > 0 && c.Burst < 1) {
+		return fmt.Errorf("requests per minute must not be negative, and burst must be larger than 0 if limited: given %d, %d", c.RequestsPerMinute, c.Burst)
+	}
+	_, err := parsePrefixes(c.TrustedProxies)
+	if err != nil {
+		return err
+	}
+	if c.MaxVersion < 1 || c.MaxVersion > 40 {
+		return fmt.Errorf("max version must be between 1 and 40: given %d", c.MaxVersion)
+	}
+	if c.TokenLifetime <= 0 {
+		return fmt.Errorf("token lifetime must be positive: given %s", c.TokenLifetime)
+	}
+	if c.CacheSize < 0 {
+		return fmt.Errorf("cache size must not be negative: given %d", c.CacheSize)
+	}
+	if c.VaultPath == "" && c.VaultKey != "" {
+		return fmt.Errorf("vault path must be given with vault key")
+	}
+	if c.VaultPath != "" {
+		_, err := vault.ParseKey(c.VaultKey)
+		if err != nil {
+			return err
+		}
+		if c.AdminToken == "" {
+			return fmt.Errorf("admin token must be given with vault path")
+		}
+	}
+	if c.VaultPath == "" && (c.RotationSchedule != "" || c.RotationExec != "" || c.RotationWebhook != "") {
+		return fmt.Errorf("vault path must be given with rotation")
+	}
+	if c.RotationSchedule != "" {
+		_, err := rotation.ParseSchedule(c.RotationSchedule)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = passphrase.ParseMode(c.RotationMode)
+	if err != nil {
+		return err
+	}
+	if c.RotationWebhook != "" {
+		u, err := url.Parse(c.RotationWebhook)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("rotation webhook must be an http or https URL")
+		}
+	}
+	if c.ShareLifetime <= 0 {
+		return fmt.Errorf("share lifetime must be positive: given %s", c.ShareLifetime)
+	}
+	_, err = render.GetByName(c.DefaultFormat)
+	return err
+}
+
+// parses the subset of TOML used by config files, which are tables of strings, integers and booleans
+// values are returned as strings, keyed by table name and key joined with '.'
+// referenced: https://toml.io/en/v1.0.0
+func parseTOML(r io.Reader) (map[string]string, error) {
+	values := make(map[string]string)
+	table := ""
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(stripTOMLComment(scanner.Text()))
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") || strings.HasPrefix(line, "[[") {
+				return nil, fmt.Errorf("line %d: unexpected table header: %s", n, line)
+			}
+			table = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			return nil, fmt.Errorf("line %d: expected key = value: %s", n, line)
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if table != "" {
+			key = table + "." + key
+		}
+		if _, exists := values[key]; exists {
+			return nil, fmt.Errorf("line %d: duplicate key: %s", n, key)
+		}
+
+		switch {
+		case strings.HasPrefix(value, `"`):
+			s, err := strconv.Unquote(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: cannot decode string: %s", n, value)
+			}
+			values[key] = s
+		case strings.HasPrefix(value, "'"):
+			// literal string without escapes
+			if len(value) < 2 || !strings.HasSuffix(value, "'") {
+				return nil, fmt.Errorf("line %d: cannot decode string: %s", n, value)
+			}
+			values[key] = value[1 : len(value)-1]
+		case value == "true" || value == "false":
+			values[key] = value
+		default:
+			// integers may be separated by underscores
+			i, err := strconv.ParseInt(strings.ReplaceAll(value, "_", ""), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: unexpected value: %s", n, value)
+			}
+			values[key] = strconv.FormatInt(i, 10)
+		}
+	}
+	return values, scanner.Err()
+}
+
+// removes comment starting with '#' outside of strings
+func stripTOMLComment(line string) string {
+	var quote rune
+	escaped := false
+	for i, c := range line {
+		switch {
+		case escaped:
+			escaped = false
+		case quote == '"' && c == '\\':
+			escaped = true
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
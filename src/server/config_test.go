@@ -0,0 +1,99 @@
+package server
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pasca-l/wifi-qrcode-generator/qrcode"
+)
+
+func TestParseTOML(t *testing.T) {
+	testcases := []struct {
+		src     string
+		want    map[string]string
+		wantErr error
+	}{
+		{
+			src: `addr = ":9000" # comment
+base_path = '/wifi#1'
+
+[limits]
+max_body_size = 1_048_576
+`,
+			want: map[string]string{"addr": ":9000", "base_path": "/wifi#1", "limits.max_body_size": "1048576"},
+		},
+		{src: "[timeouts]\nread = \"5s\"\nread = \"6s\"\n", wantErr: errors.New("line 3: duplicate key: timeouts.read")},
+		{src: "addr = :9000\n", wantErr: errors.New("line 1: unexpected value: :9000")},
+		{src: "[[servers]]\n", wantErr: errors.New("line 1: unexpected table header: [[servers]]")},
+	}
+
+	for _, tt := range testcases {
+		t.Run("testing parseTOML()", func(t *testing.T) {
+			got, err := parseTOML(strings.NewReader(tt.src))
+			if (err == nil) != (tt.wantErr == nil) || (err != nil && err.Error() != tt.wantErr.Error()) {
+				t.Fatalf("parseTOML() error = '%v'; expected '%v'", err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseTOML() = %v; expected %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	err := os.WriteFile(path, []byte("addr = \":9000\"\n\n[timeouts]\nread = \"5s\"\n\n[defaults]\necl = \"M\"\n"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testcases := []struct {
+		args    []string
+		env     map[string]string
+		want    func(c *Config)
+		wantErr error
+	}{
+		{args: nil, want: func(c *Config) {}},
+		{
+			args: []string{"-config", path},
+			want: func(c *Config) { c.Addr, c.ReadTimeout, c.DefaultECL = ":9000", 5*time.Second, qrcode.M },
+		},
+		{
+			// flags take precedence over environment, which takes precedence over file
+			args: []string{"-addr", ":9002"},
+			env:  map[string]string{"WIFIQR_CONFIG": path, "WIFIQR_ADDR": ":9001", "WIFIQR_READ_TIMEOUT": "7s"},
+			want: func(c *Config) { c.Addr, c.ReadTimeout, c.DefaultECL = ":9002", 7*time.Second, qrcode.M },
+		},
+		{args: []string{"-tls-cert", "cert.pem"}, wantErr: errors.New("both TLS certificate and key must be given")},
+		{args: []string{"-base-path", "/wifi/"}, wantErr: errors.New("base path must start with '/' and must not end with '/': given /wifi/")},
+		{env: map[string]string{"WIFIQR_IDLE_TIMEOUT": "soon"}, wantErr: errors.New("WIFIQR_IDLE_TIMEOUT: cannot convert value 'soon' to duration")},
+		{args: []string{"-vault-path", "networks.vault"}, env: map[string]string{"WIFIQR_VAULT_KEY": "c2hvcnQ="}, wantErr: errors.New("vault key must be 32 bytes encoded in base64")},
+		{env: map[string]string{"WIFIQR_VAULT_KEY": "c2hvcnQ="}, wantErr: errors.New("vault path must be given with vault key")},
+		{args: []string{"-vault-path", "networks.vault"}, env: map[string]string{"WIFIQR_VAULT_KEY": "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA="}, wantErr: errors.New("admin token must be given with vault path")},
+		{args: []string{"-rotation-schedule", "@weekly"}, wantErr: errors.New("vault path must be given with rotation")},
+		{args: []string{"-rotation-mode", "base64"}, wantErr: errors.New("cannot convert value 'base64' to type Mode")},
+		{args: []string{"-trusted-proxies", "127.0.0.1, proxy"}, wantErr: errors.New("cannot convert value 'proxy' to address or prefix")},
+	}
+
+	for _, tt := range testcases {
+		t.Run("testing LoadConfig()", func(t *testing.T) {
+			got, err := LoadConfig(tt.args, func(key string) string { return tt.env[key] })
+			if (err == nil) != (tt.wantErr == nil) || (err != nil && err.Error() != tt.wantErr.Error()) {
+				t.Fatalf("LoadConfig() error = '%v'; expected '%v'", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			want := DefaultConfig()
+			tt.want(&want)
+			if got != want {
+				t.Errorf("LoadConfig() = %+v; expected %+v", got, want)
+			}
+		})
+	}
+}
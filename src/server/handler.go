@@ -1,24 +1,152 @@
 package server
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image/color"
+	"mime"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 
+	"github.com/pasca-l/wifi-qrcode-generator/batch"
+	"github.com/pasca-l/wifi-qrcode-generator/layout"
+	"github.com/pasca-l/wifi-qrcode-generator/profile"
 	"github.com/pasca-l/wifi-qrcode-generator/qrcode"
+	"github.com/pasca-l/wifi-qrcode-generator/render"
 )
 
-func indexHandler(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "text/html")
-	http.ServeFile(w, r, "index.html")
+func (s *server) qrcodeHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "POST method required", http.StatusMethodNotAllowed)
+		return
+	}
+	// logo is uploaded as a file, but plain forms are also accepted
+	err := parseForm(r, s.config.MaxBodySize)
+	if err != nil {
+		http.Error(w, err.Error(), limitStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	logo, err := readLogo(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// codes with logo are not cached, as uploaded files are not part of the form values
+	if logo != nil {
+		res, status, err := s.renderQRCode(r.PostForm, logo)
+		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+		writeRendered(w, res)
+		return
+	}
+
+	s.serveQRCode(w, r, r.PostForm)
 }
 
-func qrcodeHandler(w http.ResponseWriter, r *http.Request) {
+// serves code of network in form from cache, or renders it to cache
+func (s *server) serveQRCode(w http.ResponseWriter, r *http.Request, form url.Values) {
+	key := s.cache.key("qrcode", form)
+	w.Header().Set("ETag", key.etag())
+	w.Header().Set("Cache-Control", "private, no-cache")
+	if notModified(r, key.etag()) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	res, ok := s.cache.get(key)
+	s.metrics.observeCache(ok)
+	if !ok {
+		var status int
+		var err error
+		res, status, err = s.renderQRCode(form, nil)
+		if err != nil {
+			w.Header().Del("ETag")
+			http.Error(w, err.Error(), status)
+			return
+		}
+		s.cache.add(key, res)
+	}
+	writeRendered(w, res)
+}
+
+// renders code of network in form, returning status code along with error
+func (s *server) renderQRCode(form url.Values, logo *render.Logo) (cachedResponse, int, error) {
+	wifiSpec, err := qrcode.NewWifiSpec(form)
+	if err != nil {
+		return cachedResponse{}, http.StatusBadRequest, err
+	}
+	ecl, err := s.eclOrDefault(form.Get("ecl"))
+	if err != nil {
+		return cachedResponse{}, http.StatusBadRequest, err
+	}
+
+	var qrCode qrcode.QRCode
+	if logo != nil {
+		// logo that cannot be recovered by error correction is a client error
+		qrCode, err = s.generateQRCodeWithLogo(wifiSpec, ecl, form.Get("logo_ratio"))
+		if err != nil {
+			return cachedResponse{}, limitStatus(err, http.StatusBadRequest), err
+		}
+	} else {
+		qrCode, err = s.generateQRCode(wifiSpec, ecl)
+		if err != nil {
+			return cachedResponse{}, limitStatus(err, http.StatusInternalServerError), err
+		}
+	}
+
+	renderer, err := s.newRenderer(form, wifiSpec)
+	if err != nil {
+		return cachedResponse{}, http.StatusBadRequest, err
+	}
+	opts, err := renderOptions(form)
+	if err != nil {
+		return cachedResponse{}, http.StatusBadRequest, err
+	}
+	opts.Logo = logo
+	warnings, err := render.CheckContrast(opts)
+	if err != nil {
+		return cachedResponse{}, http.StatusBadRequest, err
+	}
+
+	// render into buffer, as logo errors are only found while rendering
+	buf := new(bytes.Buffer)
+	start := time.Now()
+	err = renderer.Render(buf, qrCode, opts)
+	if err != nil {
+		return cachedResponse{}, http.StatusBadRequest, err
+	}
+	s.metrics.observeRender(time.Since(start))
+	s.metrics.observeCode(wifiPayload, renderer.Name(), qrCode)
+
+	contentType := renderer.MIMEType()
+	if _, isText := renderer.(render.TextRenderer); isText {
+		contentType += "; charset=utf-8"
+	}
+	return cachedResponse{contentType: contentType, warnings: warnings, body: buf.Bytes()}, http.StatusOK, nil
+}
+
+func writeRendered(w http.ResponseWriter, res cachedResponse) {
+	for _, warning := range res.warnings {
+		w.Header().Add(contrastWarningHeader, warning)
+	}
+	w.Header().Set("Content-Type", res.contentType)
+	_, _ = w.Write(res.body)
+}
+
+func (s *server) cardHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "POST" {
 		http.Error(w, "POST method required", http.StatusMethodNotAllowed)
 		return
 	}
-	err := r.ParseForm()
+	// logo and template are uploaded as files, but plain forms are also accepted
+	err := parseForm(r, s.config.MaxBodySize)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		http.Error(w, err.Error(), limitStatus(err, http.StatusBadRequest))
 		return
 	}
 
@@ -27,23 +155,416 @@ func qrcodeHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	src := wifiSpec.Encode()
+	card, err := newCard(r, wifiSpec)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	tmpl, err := cardTemplate(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	qrCodeSpec, err := qrcode.NewQRCodeSpec(src, qrcode.L)
+	qrCode, err := s.generateQRCode(wifiSpec, s.config.DefaultECL)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), limitStatus(err, http.StatusInternalServerError))
 		return
 	}
-	qrCode, err := qrcode.NewQRCode(src, qrCodeSpec)
+
+	format := layout.Format(s.formatOrDefault(r.PostForm.Get("format")))
+	buf := new(bytes.Buffer)
+	start := time.Now()
+	err = layout.Render(buf, tmpl, card, qrCode, format)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.metrics.observeRender(time.Since(start))
+	s.metrics.observeCode(wifiPayload, "card-"+string(format), qrCode)
+
+	w.Header().Set("Content-Type", format.MIMEType())
+	_, err = buf.WriteTo(w)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+}
+
+func (s *server) batchHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "POST method required", http.StatusMethodNotAllowed)
+		return
+	}
+	err := r.ParseMultipartForm(s.config.MaxBodySize)
+	if err != nil {
+		http.Error(w, err.Error(), limitStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	file, header, err := r.FormFile("networks")
+	if err != nil {
+		http.Error(w, fmt.Sprintf("networks file is required: %v", err), http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	inputFormat, err := batch.DetectFormat(r.PostForm.Get("input_format"), header.Filename)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ecl, err := s.eclOrDefault(r.PostForm.Get("ecl"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// every row takes a token as a request does, where the first one is taken by the request itself
+	budget := func(row int, version qrcode.Version, ecl qrcode.ErrorCorrectionLevel) error {
+		err := s.checkBudget(version, ecl)
+		if err != nil || row == 1 {
+			return err
+		}
+		return s.take(r)
+	}
+	// every invalid row is reported before the archive is streamed
+	entries, err := batch.Read(file, inputFormat, ecl, budget)
+	var rateErr rateError
+	if errors.As(err, &rateErr) {
+		writeRateError(w, r, rateErr, writePlainError)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), limitStatus(err, http.StatusBadRequest))
+		return
+	}
 
-	w.Header().Set("Content-Type", "image/svg+xml")
-	err = qrcode.DrawQRCode(w, qrCode)
+	format := r.PostForm.Get("format")
+	if format == "" {
+		format = defaultBatchFormat
+	}
+	renderer, err := render.GetByName(format)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	opts, err := renderOptions(r.PostForm)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	err = opts.Validate()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	names, err := batch.ParseNameTemplate(r.PostForm.Get("name"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	archive := batch.Archive{
+		Renderer:     renderer,
+		Options:      opts,
+		Names:        names,
+		ContactSheet: r.PostForm.Get("contact_sheet") == "true",
+	}
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="wifi-qrcodes.zip"`)
+	for _, entry := range entries {
+		s.metrics.observeCode(wifiPayload, renderer.Name(), entry.Code)
+	}
+	// status is already sent while streaming, so that errors only truncate the archive
+	_ = archive.Write(w, entries)
+}
+
+// serves network as a provisioning profile, for devices without camera
+// mobileconfig is signed if signer is configured
+func (s *server) profileHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "POST method required", http.StatusMethodNotAllowed)
+		return
+	}
+	err := r.ParseForm()
+	if err != nil {
+		http.Error(w, err.Error(), limitStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	wifiSpec, err := qrcode.NewWifiSpec(r.PostForm)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if r.PostForm.Get("profile_format") == "" {
+		http.Error(w, "profile_format is required", http.StatusBadRequest)
+		return
+	}
+	format, err := profile.DetectFormat(r.PostForm.Get("profile_format"), "")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// signer only applies to mobileconfig
+	var signer *profile.Signer
+	if format == profile.Mobileconfig {
+		signer = s.signer
+	}
+
+	buf := new(bytes.Buffer)
+	err = profile.Export(buf, wifiSpec, format, signer)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", format.MIMEType())
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": format.FileName(wifiSpec.SSID())}))
+	_, err = buf.WriteTo(w)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 }
+
+// payload type of codes generated from networks, counted by metrics
+const wifiPayload string = "wifi"
+
+func (s *server) generateQRCode(spec qrcode.WifiSpec, ecl qrcode.ErrorCorrectionLevel) (qrcode.QRCode, error) {
+	src := spec.Encode()
+	qrCodeSpec, err := qrcode.NewQRCodeSpec(src, ecl)
+	if err != nil {
+		return qrcode.QRCode{}, err
+	}
+	err = s.checkBudget(qrCodeSpec.Version(), qrCodeSpec.ECL())
+	if err != nil {
+		return qrcode.QRCode{}, err
+	}
+	return qrcode.NewQRCode(src, qrCodeSpec)
+}
+
+// logo width relative to code width, used when none is specified
+const defaultLogoRatio float64 = 0.2
+
+func (s *server) generateQRCodeWithLogo(spec qrcode.WifiSpec, ecl qrcode.ErrorCorrectionLevel, ratio string) (qrcode.QRCode, error) {
+	logoRatio := defaultLogoRatio
+	if ratio != "" {
+		var err error
+		logoRatio, err = strconv.ParseFloat(ratio, 64)
+		if err != nil {
+			return qrcode.QRCode{}, fmt.Errorf("cannot convert value '%s' of logo_ratio to number", ratio)
+		}
+	}
+
+	src := spec.Encode()
+	qrCodeSpec, err := qrcode.NewQRCodeSpecWithLogo(src, ecl, logoRatio)
+	if err != nil {
+		return qrcode.QRCode{}, err
+	}
+	err = s.checkBudget(qrCodeSpec.Version(), qrCodeSpec.ECL())
+	if err != nil {
+		return qrcode.QRCode{}, err
+	}
+	return qrcode.NewQRCode(src, qrCodeSpec)
+}
+
+func newCard(r *http.Request, spec qrcode.WifiSpec) (layout.Card, error) {
+	card := layout.Card{
+		Spec:         spec,
+		MaskPassword: r.PostForm.Get("mask_password") == "true",
+	}
+
+	if validUntil := r.PostForm.Get("valid_until"); validUntil != "" {
+		date, err := time.Parse(time.DateOnly, validUntil)
+		if err != nil {
+			return layout.Card{}, fmt.Errorf("cannot convert value '%s' of valid_until to date", validUntil)
+		}
+		card.ValidUntil = date
+	}
+
+	logo, err := readLogo(r)
+	if err != nil {
+		return layout.Card{}, err
+	}
+	card.Logo = logo
+	return card, nil
+}
+
+// parses multipart form if given, otherwise plain form
+// ParseMultipartForm is not used for both, as it drops errors of plain forms, such as body too large
+func parseForm(r *http.Request, maxMemory int64) error {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		return r.ParseMultipartForm(maxMemory)
+	}
+	return r.ParseForm()
+}
+
+// returns uploaded logo if given, otherwise nil
+func readLogo(r *http.Request) (*render.Logo, error) {
+	file, header, err := r.FormFile("logo")
+	if errors.Is(err, http.ErrMissingFile) || errors.Is(err, http.ErrNotMultipart) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return render.ReadLogo(file, header.Header.Get("Content-Type"))
+}
+
+// returns uploaded template if given, otherwise built-in template by name
+func cardTemplate(r *http.Request) (layout.Template, error) {
+	file, _, err := r.FormFile("template_file")
+	if errors.Is(err, http.ErrMissingFile) || errors.Is(err, http.ErrNotMultipart) {
+		return layout.Get(r.PostForm.Get("template"))
+	}
+	if err != nil {
+		return layout.Template{}, err
+	}
+	defer file.Close()
+
+	return layout.ParseTemplate(file)
+}
+
+// renderer name used for batches when none is specified, as archives are mostly printed
+const defaultBatchFormat string = "png"
+
+// theme name used when none is specified
+const defaultTheme string = "classic"
+
+// header listing contrast warnings, one value per warning
+const contrastWarningHeader string = "X-Contrast-Warning"
+
+// error correction level used when none is specified, as configured
+func (s *server) eclOrDefault(ecl string) (qrcode.ErrorCorrectionLevel, error) {
+	if ecl == "" {
+		return s.config.DefaultECL, nil
+	}
+	return qrcode.ToErrorCorrectionLevel(ecl)
+}
+
+// renderer name used when none is specified
+func (s *server) formatOrDefault(format string) string {
+	if format == "" {
+		return s.config.DefaultFormat
+	}
+	return format
+}
+
+func renderOptions(params url.Values) (render.RenderOptions, error) {
+	opts := render.DefaultRenderOptions()
+	style, err := render.NewStyle(params.Get("module_shape"), params.Get("eye_frame"), params.Get("eye_ball"))
+	if err != nil {
+		return render.RenderOptions{}, err
+	}
+	opts.Style = style
+	if params.Get("margin") != "" {
+		opts.Margin, err = strconv.Atoi(params.Get("margin"))
+		if err != nil {
+			return render.RenderOptions{}, fmt.Errorf("cannot convert value '%s' of margin to integer", params.Get("margin"))
+		}
+	}
+
+	theme, err := renderTheme(params)
+	if err != nil {
+		return render.RenderOptions{}, err
+	}
+	return theme.Apply(opts), nil
+}
+
+// builds theme from a built-in theme, overridden by individual colors
+func renderTheme(params url.Values) (render.Theme, error) {
+	name := params.Get("theme")
+	if name == "" {
+		name = defaultTheme
+	}
+	theme, err := render.GetTheme(name)
+	if err != nil {
+		return render.Theme{}, err
+	}
+
+	for name, field := range map[string]*color.Color{"foreground": &theme.Foreground, "background": &theme.Background} {
+		if params.Get(name) == "" {
+			continue
+		}
+		*field, err = render.ParseColor(params.Get(name))
+		if err != nil {
+			return render.Theme{}, fmt.Errorf("cannot convert value '%s' of %s to color", params.Get(name), name)
+		}
+	}
+	// solid color given explicitly replaces gradient of the theme
+	if params.Get("foreground") != "" {
+		theme.Gradient = nil
+	}
+
+	if kind := params.Get("gradient"); kind != "" {
+		to, err := render.ParseColor(params.Get("gradient_to"))
+		if err != nil {
+			return render.Theme{}, fmt.Errorf("cannot convert value '%s' of gradient_to to color", params.Get("gradient_to"))
+		}
+		angle := 0.0
+		if params.Get("gradient_angle") != "" {
+			angle, err = strconv.ParseFloat(params.Get("gradient_angle"), 64)
+			if err != nil {
+				return render.Theme{}, fmt.Errorf("cannot convert value '%s' of gradient_angle to number", params.Get("gradient_angle"))
+			}
+		}
+		theme.Gradient = &render.Gradient{Kind: render.GradientKind(kind), From: theme.Foreground, To: to, Angle: angle}
+	}
+
+	if params.Get("invert") == "true" {
+		theme = theme.Invert()
+	}
+	return theme, nil
+}
+
+// returns renderer of the requested format, configured for the network
+func (s *server) newRenderer(params url.Values, spec qrcode.WifiSpec) (render.Renderer, error) {
+	renderer, err := render.GetByName(s.formatOrDefault(params.Get("format")))
+	if err != nil {
+		return nil, err
+	}
+	switch rr := renderer.(type) {
+	case render.PDFRenderer:
+		return configurePDFRenderer(rr, params, spec)
+	case render.SVGRenderer:
+		rr.Title = fmt.Sprintf("Wi-Fi QR code for network %s", spec.SSID())
+		return rr, nil
+	case render.TextRenderer:
+		// terminal theme of the client is unknown, so that it must be given explicitly
+		rr.Mode, err = render.ParseTextMode(params.Get("text_mode"))
+		if err != nil {
+			return nil, err
+		}
+		rr.DarkTerminal = params.Get("terminal") == "dark"
+		return rr, nil
+	default:
+		return renderer, nil
+	}
+}
+
+// sets print options for PDF output, captioning the code with SSID
+func configurePDFRenderer(pr render.PDFRenderer, params url.Values, spec qrcode.WifiSpec) (render.PDFRenderer, error) {
+	pageSize, err := render.ParsePageSize(params.Get("page"))
+	if err != nil {
+		return render.PDFRenderer{}, err
+	}
+	pr.PageSize = pageSize
+	pr.CropMarks = params.Get("crop_marks") == "true"
+	pr.Caption = spec.SSID()
+
+	for name, field := range map[string]*float64{"module_mm": &pr.ModuleSize, "bleed_mm": &pr.Bleed} {
+		if params.Get(name) == "" {
+			continue
+		}
+		*field, err = strconv.ParseFloat(params.Get(name), 64)
+		if err != nil {
+			return render.PDFRenderer{}, fmt.Errorf("cannot convert value '%s' of %s to number", params.Get(name), name)
+		}
+	}
+
+	return pr, pr.Validate()
+}
@@ -0,0 +1,236 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pasca-l/wifi-qrcode-generator/qrcode"
+)
+
+// error of code exceeding version allowed per request, which grows with payload and ecl
+type budgetError struct {
+	version    qrcode.Version
+	ecl        qrcode.ErrorCorrectionLevel
+	maxVersion int
+}
+
+func (e budgetError) Error() string {
+	return fmt.Sprintf("payload needs version %d at ecl %s, exceeding the limit of version %d", e.version, e.ecl.ToString(), e.maxVersion)
+}
+
+func (s *server) checkBudget(ver qrcode.Version, ecl qrcode.ErrorCorrectionLevel) error {
+	if int(ver) > s.config.MaxVersion {
+		return budgetError{version: ver, ecl: ecl, maxVersion: s.config.MaxVersion}
+	}
+	return nil
+}
+
+// returns status of error found while reading request or generating code,
+// where exceeding limits is reported as 413 instead of the given status
+func limitStatus(err error, status int) int {
+	var maxBytesErr *http.MaxBytesError
+	var budgetErr budgetError
+	if errors.As(err, &maxBytesErr) || errors.As(err, &budgetErr) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return status
+}
+
+// writes error of status with detail, either as plain text or as problem details
+type errorWriter func(w http.ResponseWriter, r *http.Request, status int, detail string)
+
+func writePlainError(w http.ResponseWriter, r *http.Request, status int, detail string) {
+	http.Error(w, detail, status)
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// token bucket per client, refilled at rate up to burst
+// referenced: https://en.wikipedia.org/wiki/Token_bucket
+type rateLimiter struct {
+	rate  float64 // tokens per second
+	burst float64
+
+	mu      sync.Mutex
+	buckets map[netip.Prefix]*bucket
+}
+
+// buckets kept before full ones are dropped, as they are same as new ones,
+// and then the least recently used ones, so that rotating addresses cannot grow them
+const maxBuckets int = 10000
+
+func newRateLimiter(perMinute int, burst int) *rateLimiter {
+	return &rateLimiter{
+		rate:    float64(perMinute) / 60,
+		burst:   float64(burst),
+		buckets: make(map[netip.Prefix]*bucket),
+	}
+}
+
+// takes a token of client, or returns time until one is available
+func (l *rateLimiter) allow(client netip.Addr, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	key := bucketKey(client)
+	b, ok := l.buckets[key]
+	if !ok {
+		if len(l.buckets) >= maxBuckets {
+			l.dropFull(now)
+		}
+		if len(l.buckets) >= maxBuckets {
+			l.dropOldest()
+		}
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	}
+	b.tokens--
+	return true, 0
+}
+
+func (l *rateLimiter) dropFull(now time.Time) {
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+func (l *rateLimiter) dropOldest() {
+	var oldest netip.Prefix
+	var last time.Time
+	for key, b := range l.buckets {
+		if last.IsZero() || b.last.Before(last) {
+			oldest, last = key, b.last
+		}
+	}
+	delete(l.buckets, oldest)
+}
+
+// keys IPv6 clients by /64 prefix, which is typically assigned to a single host
+func bucketKey(client netip.Addr) netip.Prefix {
+	bits := client.BitLen()
+	if client.Is6() {
+		bits = 64
+	}
+	key, _ := client.Prefix(bits)
+	return key
+}
+
+// parses comma separated addresses or prefixes, such as "127.0.0.1, 10.0.0.0/8"
+func parsePrefixes(s string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0)
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if addr, err := netip.ParseAddr(item); err == nil {
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(item)
+		if err != nil {
+			return nil, fmt.Errorf("cannot convert value '%s' to address or prefix", item)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+func (s *server) isTrustedProxy(addr netip.Addr) bool {
+	for _, prefix := range s.trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// returns address of client of request, taken from X-Forwarded-For if sent by a trusted proxy,
+// where the rightmost address not of a trusted proxy is the client, as proxies append their peers
+func (s *server) clientAddr(r *http.Request) netip.Addr {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	client, _ := netip.ParseAddr(host)
+	client = client.Unmap()
+	if !s.isTrustedProxy(client) {
+		return client
+	}
+
+	forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(strings.TrimSpace(forwarded[i]))
+		if err != nil {
+			break
+		}
+		client = addr.Unmap()
+		if !s.isTrustedProxy(client) {
+			break
+		}
+	}
+	return client
+}
+
+// error of client running out of tokens, which are available again after wait
+type rateError struct {
+	wait time.Duration
+}
+
+func (e rateError) Error() string {
+	return "too many requests, retry later"
+}
+
+// takes a token of the client of request, except for loopback clients such as health checks,
+// unless they are trusted proxies, whose clients are limited instead
+func (s *server) take(r *http.Request) error {
+	if s.limiter == nil {
+		return nil
+	}
+	client := s.clientAddr(r)
+	if client.IsLoopback() && !s.isTrustedProxy(client) {
+		return nil
+	}
+
+	ok, wait := s.limiter.allow(client, time.Now())
+	if !ok {
+		return rateError{wait: wait}
+	}
+	return nil
+}
+
+func writeRateError(w http.ResponseWriter, r *http.Request, err rateError, writeError errorWriter) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(err.wait.Seconds()))))
+	writeError(w, r, http.StatusTooManyRequests, err.Error())
+}
+
+// limits rate of requests per client address, taking a token for each request
+func (s *server) limit(next http.HandlerFunc, writeError errorWriter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var rateErr rateError
+		if errors.As(s.take(r), &rateErr) {
+			writeRateError(w, r, rateErr, writeError)
+			return
+		}
+		next(w, r)
+	}
+}
@@ -0,0 +1,206 @@
+package server
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterAllow(t *testing.T) {
+	l := newRateLimiter(60, 2)
+	client := netip.MustParseAddr("203.0.113.1")
+	start := time.Now()
+
+	testcases := []struct {
+		client   netip.Addr
+		after    time.Duration
+		want     bool
+		wantWait time.Duration
+	}{
+		{client: client, after: 0, want: true},
+		{client: client, after: 0, want: true},
+		{client: client, after: 0, want: false, wantWait: time.Second},
+		{client: netip.MustParseAddr("203.0.113.2"), after: 0, want: true},
+		{client: client, after: 500 * time.Millisecond, want: false, wantWait: 500 * time.Millisecond},
+		{client: client, after: time.Second, want: true},
+		// IPv6 clients share a bucket in the same /64
+		{client: netip.MustParseAddr("2001:db8::1"), after: time.Second, want: true},
+		{client: netip.MustParseAddr("2001:db8::2"), after: time.Second, want: true},
+		{client: netip.MustParseAddr("2001:db8::3"), after: time.Second, want: false, wantWait: time.Second},
+		{client: netip.MustParseAddr("2001:db8:0:1::1"), after: time.Second, want: true},
+	}
+
+	for _, tt := range testcases {
+		t.Run("testing rateLimiter.allow()", func(t *testing.T) {
+			got, gotWait := l.allow(tt.client, start.Add(tt.after))
+			if got != tt.want || gotWait != tt.wantWait {
+				t.Errorf("rateLimiter.allow() = %v, %v; expected %v, %v", got, gotWait, tt.want, tt.wantWait)
+			}
+		})
+	}
+}
+
+func TestRateLimiterMaxBuckets(t *testing.T) {
+	l := newRateLimiter(60, 2)
+	start := time.Now()
+	// buckets that are not full are kept until the hard cap
+	for i := range maxBuckets + 10 {
+		client := netip.AddrFrom4([4]byte{10, byte(i >> 16), byte(i >> 8), byte(i)})
+		l.allow(client, start.Add(time.Duration(i)*time.Microsecond))
+	}
+	if len(l.buckets) != maxBuckets {
+		t.Errorf("rateLimiter.buckets = %d buckets; expected %d", len(l.buckets), maxBuckets)
+	}
+	// least recently used ones are dropped
+	if _, ok := l.buckets[bucketKey(netip.AddrFrom4([4]byte{10, 0, 0, 0}))]; ok {
+		t.Errorf("rateLimiter.buckets has the least recently used bucket; expected it to be dropped")
+	}
+}
+
+func TestServerLimits(t *testing.T) {
+	config := DefaultConfig()
+	config.MaxBodySize = 1024
+	config.MaxVersion = 2
+	config.RequestsPerMinute = 1
+	config.Burst = 1
+	s := newTestServer(t, config)
+	handler := s.handler()
+
+	form := func(ssid string) string {
+		return url.Values{"ssid": {ssid}, "encryption": {"nopass"}, "format": {"txt"}}.Encode()
+	}
+	multipartBody := new(bytes.Buffer)
+	mw := multipart.NewWriter(multipartBody)
+	_ = mw.WriteField("ssid", strings.Repeat("a", 2048))
+	_ = mw.Close()
+
+	testcases := []struct {
+		remoteAddr  string
+		contentType string
+		body        string
+		wantStatus  int
+	}{
+		// loopback clients are never limited
+		{remoteAddr: "127.0.0.1:1234", body: form("guest"), wantStatus: http.StatusOK},
+		{remoteAddr: "[::1]:1234", body: form("guest"), wantStatus: http.StatusOK},
+		{remoteAddr: "127.0.0.1:1234", body: form(strings.Repeat("a", 2048)), wantStatus: http.StatusRequestEntityTooLarge},
+		{remoteAddr: "127.0.0.1:1234", contentType: mw.FormDataContentType(), body: multipartBody.String(), wantStatus: http.StatusRequestEntityTooLarge},
+		{remoteAddr: "127.0.0.1:1234", body: form(strings.Repeat("a", 100)), wantStatus: http.StatusRequestEntityTooLarge},
+		// others are allowed a request at once
+		{remoteAddr: "203.0.113.1:1234", body: form("guest"), wantStatus: http.StatusOK},
+		{remoteAddr: "203.0.113.1:1234", body: form("guest"), wantStatus: http.StatusTooManyRequests},
+	}
+
+	for _, tt := range testcases {
+		t.Run("testing server.limit()", func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/qrcode", strings.NewReader(tt.body))
+			req.RemoteAddr = tt.remoteAddr
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			res := httptest.NewRecorder()
+			handler.ServeHTTP(res, req)
+			if res.Code != tt.wantStatus {
+				t.Errorf("POST /qrcode from %s status = %d; expected %d: %s", tt.remoteAddr, res.Code, tt.wantStatus, res.Body.String())
+			}
+			if res.Code == http.StatusTooManyRequests && res.Header().Get("Retry-After") != "60" {
+				t.Errorf("Retry-After = %q; expected %q", res.Header().Get("Retry-After"), "60")
+			}
+		})
+	}
+}
+
+func TestBatchLimits(t *testing.T) {
+	config := DefaultConfig()
+	config.MaxVersion = 2
+	config.RequestsPerMinute = 1
+	config.Burst = 2
+	s := newTestServer(t, config)
+	handler := s.handler()
+
+	networks := func(rows ...string) (string, string) {
+		body := new(bytes.Buffer)
+		mw := multipart.NewWriter(body)
+		fw, _ := mw.CreateFormFile("networks", "networks.csv")
+		_, _ = fw.Write([]byte("ssid,encryption\n" + strings.Join(rows, "\n") + "\n"))
+		_ = mw.Close()
+		return mw.FormDataContentType(), body.String()
+	}
+
+	testcases := []struct {
+		remoteAddr string
+		rows       []string
+		wantStatus int
+	}{
+		// rows beyond the first take a token each
+		{remoteAddr: "203.0.113.1:1234", rows: []string{"lobby,nopass", "room,nopass"}, wantStatus: http.StatusOK},
+		{remoteAddr: "203.0.113.2:1234", rows: []string{"lobby,nopass", "room,nopass", "hall,nopass"}, wantStatus: http.StatusTooManyRequests},
+		{remoteAddr: "203.0.113.3:1234", rows: []string{strings.Repeat("a", 40) + ",nopass"}, wantStatus: http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range testcases {
+		t.Run("testing server.batchHandler()", func(t *testing.T) {
+			contentType, body := networks(tt.rows...)
+			req := httptest.NewRequest("POST", "/batch", strings.NewReader(body))
+			req.RemoteAddr = tt.remoteAddr
+			req.Header.Set("Content-Type", contentType)
+			res := httptest.NewRecorder()
+			handler.ServeHTTP(res, req)
+			if res.Code != tt.wantStatus {
+				t.Errorf("POST /batch of %d rows status = %d; expected %d: %s", len(tt.rows), res.Code, tt.wantStatus, res.Body.String())
+			}
+		})
+	}
+}
+
+func TestTrustedProxyLimits(t *testing.T) {
+	config := DefaultConfig()
+	config.RequestsPerMinute = 1
+	config.Burst = 1
+	config.TrustedProxies = "127.0.0.1, 10.0.0.0/8"
+	s := newTestServer(t, config)
+	handler := s.handler()
+
+	testcases := []struct {
+		remoteAddr   string
+		forwardedFor string
+		wantStatus   int
+	}{
+		// clients of trusted proxies are limited by the forwarded address
+		{remoteAddr: "127.0.0.1:1234", forwardedFor: "203.0.113.1", wantStatus: http.StatusOK},
+		{remoteAddr: "127.0.0.1:1234", forwardedFor: "203.0.113.1", wantStatus: http.StatusTooManyRequests},
+		// addresses before the rightmost untrusted one are set by clients, and ignored
+		{remoteAddr: "127.0.0.1:1234", forwardedFor: "198.51.100.1, 203.0.113.2, 10.0.0.1", wantStatus: http.StatusOK},
+		{remoteAddr: "127.0.0.1:1234", forwardedFor: "198.51.100.2, 203.0.113.2", wantStatus: http.StatusTooManyRequests},
+		// trusted proxies are limited without forwarded address, while other loopback clients are not
+		{remoteAddr: "127.0.0.1:1234", wantStatus: http.StatusOK},
+		{remoteAddr: "127.0.0.1:1234", wantStatus: http.StatusTooManyRequests},
+		{remoteAddr: "[::1]:1234", forwardedFor: "203.0.113.1", wantStatus: http.StatusOK},
+		{remoteAddr: "[::1]:1234", forwardedFor: "203.0.113.1", wantStatus: http.StatusOK},
+		// forwarded address is not taken from untrusted clients
+		{remoteAddr: "203.0.113.3:1234", forwardedFor: "203.0.113.4", wantStatus: http.StatusOK},
+		{remoteAddr: "203.0.113.3:1234", forwardedFor: "203.0.113.5", wantStatus: http.StatusTooManyRequests},
+	}
+
+	for _, tt := range testcases {
+		t.Run("testing server.limit()", func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/v1/passphrases", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.forwardedFor != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwardedFor)
+			}
+			res := httptest.NewRecorder()
+			handler.ServeHTTP(res, req)
+			if res.Code != tt.wantStatus {
+				t.Errorf("GET from %s for %s status = %d; expected %d", tt.remoteAddr, tt.forwardedFor, res.Code, tt.wantStatus)
+			}
+		})
+	}
+}
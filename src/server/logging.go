@@ -0,0 +1,146 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+)
+
+// header carrying request identifier, which is also taken from proxies if given
+const requestIDHeader string = "X-Request-Id"
+
+// values of a request filled while handling it, shared through context
+type requestInfo struct {
+	id    string
+	route string
+}
+
+type requestInfoKey struct{}
+
+// returns identifier of request, or empty string outside of handlers
+func requestID(ctx context.Context) string {
+	if info, ok := ctx.Value(requestInfoKey{}).(*requestInfo); ok {
+		return info.id
+	}
+	return ""
+}
+
+func newRequestID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// accepts identifiers from proxies only if short and printable, as they are logged
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+	for _, c := range id {
+		if c <= ' ' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// records status and size of response
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	size   int64
+}
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	n, err := rec.ResponseWriter.Write(b)
+	rec.size += int64(n)
+	return n, err
+}
+
+// exposes underlying writer to http.ResponseController, so that archives are still flushed
+func (rec *responseRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+// logs and counts every request, without query or body as they may carry SSIDs and passwords
+func (s *server) logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		info := &requestInfo{id: r.Header.Get(requestIDHeader)}
+		if !validRequestID(info.id) {
+			info.id = newRequestID()
+		}
+		w.Header().Set(requestIDHeader, info.id)
+		rec := &responseRecorder{ResponseWriter: w}
+
+		next.ServeHTTP(rec, r.WithContext(context.WithValue(r.Context(), requestInfoKey{}, info)))
+
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+		route := info.route
+		if route == "" {
+			route = "none"
+		}
+		s.metrics.observeRequest(r.Method, route, rec.status)
+		slog.InfoContext(r.Context(), "request",
+			slog.String("request_id", info.id),
+			slog.String("method", r.Method),
+			slog.String("path", s.redactPath(r.URL.Path)),
+			slog.String("route", route),
+			slog.Int("status", rec.status),
+			slog.Int64("size", rec.size),
+			slog.Duration("duration", time.Since(start)),
+		)
+	})
+}
+
+// paths followed by tokens of share links and identifiers of linked images, which grant access to codes
+var capabilityPaths = map[string]string{
+	sharePath: "{token}",
+	imagePath: "{id}",
+}
+
+// replaces capabilities in path by the names of their wildcards, so that logs do not grant access to codes
+func (s *server) redactPath(p string) string {
+	// paths are cleaned as mux does, so that unclean ones redirected by it are redacted as well
+	cleaned := path.Clean(p)
+	for prefix, wildcard := range capabilityPaths {
+		rest, found := strings.CutPrefix(cleaned, s.config.BasePath+prefix)
+		if !found {
+			continue
+		}
+		_, suffix, hasSuffix := strings.Cut(rest, "/")
+		redacted := s.config.BasePath + prefix + wildcard
+		if hasSuffix {
+			redacted += "/" + suffix
+		}
+		return redacted
+	}
+	return p
+}
+
+// records pattern of the route matched by mux, which is only set on the request given to it
+func recordRoute(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mux.ServeHTTP(w, r)
+		if info, ok := r.Context().Value(requestInfoKey{}).(*requestInfo); ok {
+			info.route = r.Pattern
+		}
+	})
+}
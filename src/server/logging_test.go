@@ -0,0 +1,79 @@
+package server
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestLogRequests(t *testing.T) {
+	buf := new(bytes.Buffer)
+	defaultLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(buf, nil)))
+	defer slog.SetDefault(defaultLogger)
+
+	s := newTestServer(t, testConfig())
+	handler := s.handler()
+
+	testcases := []struct {
+		requestID string
+		wantID    bool // whether given identifier is kept
+	}{
+		{requestID: "", wantID: false},
+		{requestID: "proxy-0123", wantID: true},
+		{requestID: "with space", wantID: false},
+	}
+
+	for _, tt := range testcases {
+		t.Run("testing server.logRequests()", func(t *testing.T) {
+			buf.Reset()
+			form := url.Values{"ssid": {"secret-ssid"}, "password": {"secret-password"}, "encryption": {"WPA"}}
+			req := httptest.NewRequest("POST", "/qrcode?ssid=secret-ssid", strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			req.Header.Set(requestIDHeader, tt.requestID)
+			res := httptest.NewRecorder()
+			handler.ServeHTTP(res, req)
+
+			got := res.Header().Get(requestIDHeader)
+			if (got == tt.requestID) != tt.wantID || got == "" {
+				t.Errorf("%s = %q; given %q", requestIDHeader, got, tt.requestID)
+			}
+			if !strings.Contains(buf.String(), `"request_id":"`+got+`"`) {
+				t.Errorf("log = %s; expected to contain request id %s", buf.String(), got)
+			}
+			if strings.Contains(buf.String(), "secret") {
+				t.Errorf("log = %s; expected not to contain payload", buf.String())
+			}
+		})
+	}
+}
+
+func TestRedactPath(t *testing.T) {
+	s := newTestServer(t, testConfig())
+
+	testcases := []struct {
+		basePath string
+		path     string
+		want     string
+	}{
+		{path: "/qrcode", want: "/qrcode"},
+		{path: "/share/secret-token", want: "/share/{token}"},
+		{path: "/share/secret-token/qrcode.svg", want: "/share/{token}/qrcode.svg"},
+		{path: "//share/./secret-token", want: "/share/{token}"},
+		{path: "/api/v1/images/secret-id", want: "/api/v1/images/{id}"},
+		{basePath: "/wifi", path: "/wifi/share/secret-token", want: "/wifi/share/{token}"},
+	}
+
+	for _, tt := range testcases {
+		t.Run("testing server.redactPath()", func(t *testing.T) {
+			s.config.BasePath = tt.basePath
+			got := s.redactPath(tt.path)
+			if got != tt.want {
+				t.Errorf("server.redactPath(%q) = %q; expected %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package server
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pasca-l/wifi-qrcode-generator/qrcode"
+)
+
+// upper bounds of latency buckets in seconds, as generation takes from microseconds to a second
+var latencyBuckets = []float64{0.0001, 0.00025, 0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1}
+
+// stages of generation, observed by latency histograms
+const (
+	stageEncode          string = "encode"
+	stageErrorCorrection string = "error_correction"
+	stageMaskSelection   string = "mask_selection"
+	stageRender          string = "render"
+)
+
+type histogram struct {
+	counts []uint64 // count of observations in each bucket, not cumulative
+	sum    float64
+	count  uint64
+}
+
+func (h *histogram) observe(seconds float64) {
+	i, _ := slices.BinarySearch(latencyBuckets, seconds)
+	if i < len(h.counts) {
+		h.counts[i]++
+	}
+	h.sum += seconds
+	h.count++
+}
+
+// metrics written in Prometheus text format, without labels taken from payloads,
+// so that the number of series is bounded and nothing secret is exposed
+// referenced: https://prometheus.io/docs/instrumenting/exposition_formats/
+type metrics struct {
+	mu       sync.Mutex
+	requests map[requestLabels]uint64
+	codes    map[codeLabels]uint64
+	stages   map[string]*histogram
+	cache    map[bool]uint64 // keyed by whether response was cached
+}
+
+type requestLabels struct {
+	method string
+	route  string // pattern of matched route, or "none"
+	code   string
+}
+
+type codeLabels struct {
+	payload  string
+	renderer string
+	version  string
+	ecl      string
+}
+
+func newMetrics() *metrics {
+	m := &metrics{
+		requests: make(map[requestLabels]uint64),
+		codes:    make(map[codeLabels]uint64),
+		stages:   make(map[string]*histogram),
+		cache:    make(map[bool]uint64),
+	}
+	for _, stage := range []string{stageEncode, stageErrorCorrection, stageMaskSelection, stageRender} {
+		m.stages[stage] = &histogram{counts: make([]uint64, len(latencyBuckets))}
+	}
+	return m
+}
+
+func (m *metrics) observeRequest(method string, route string, status int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests[requestLabels{method: method, route: route, code: strconv.Itoa(status)}]++
+}
+
+// counts generated code, and observes time spent on its generation
+func (m *metrics) observeCode(payload string, renderer string, code qrcode.QRCode) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.codes[codeLabels{payload: payload, renderer: renderer, version: strconv.Itoa(int(code.Version)), ecl: code.ECL.ToString()}]++
+	m.stages[stageEncode].observe(code.Timings.Encode.Seconds())
+	m.stages[stageErrorCorrection].observe(code.Timings.ErrorCorrection.Seconds())
+	m.stages[stageMaskSelection].observe(code.Timings.MaskSelection.Seconds())
+}
+
+func (m *metrics) observeRender(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stages[stageRender].observe(d.Seconds())
+}
+
+func (m *metrics) observeCache(hit bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.cache[hit]++
+}
+
+func (m *metrics) write(w io.Writer) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	b := new(strings.Builder)
+	b.WriteString("# HELP wifiqr_http_requests_total Requests handled, by route and status code.\n")
+	b.WriteString("# TYPE wifiqr_http_requests_total counter\n")
+	requests := slices.SortedFunc(maps.Keys(m.requests), func(a, b requestLabels) int {
+		return cmp.Or(strings.Compare(a.route, b.route), strings.Compare(a.method, b.method), strings.Compare(a.code, b.code))
+	})
+	for _, k := range requests {
+		fmt.Fprintf(b, "wifiqr_http_requests_total{method=%s,route=%s,code=%s} %d\n",
+			quoteLabel(k.method), quoteLabel(k.route), quoteLabel(k.code), m.requests[k])
+	}
+
+	b.WriteString("# HELP wifiqr_codes_total Codes generated, by payload type, renderer, version and error correction level.\n")
+	b.WriteString("# TYPE wifiqr_codes_total counter\n")
+	codes := slices.SortedFunc(maps.Keys(m.codes), func(a, b codeLabels) int {
+		return cmp.Or(strings.Compare(a.payload, b.payload), strings.Compare(a.renderer, b.renderer),
+			cmp.Compare(len(a.version), len(b.version)), strings.Compare(a.version, b.version), strings.Compare(a.ecl, b.ecl))
+	})
+	for _, k := range codes {
+		fmt.Fprintf(b, "wifiqr_codes_total{payload=%s,renderer=%s,version=%s,ecl=%s} %d\n",
+			quoteLabel(k.payload), quoteLabel(k.renderer), quoteLabel(k.version), quoteLabel(k.ecl), m.codes[k])
+	}
+
+	b.WriteString("# HELP wifiqr_cache_requests_total Lookups of rendered responses in cache, by result.\n")
+	b.WriteString("# TYPE wifiqr_cache_requests_total counter\n")
+	fmt.Fprintf(b, "wifiqr_cache_requests_total{result=\"hit\"} %d\n", m.cache[true])
+	fmt.Fprintf(b, "wifiqr_cache_requests_total{result=\"miss\"} %d\n", m.cache[false])
+
+	b.WriteString("# HELP wifiqr_stage_duration_seconds Time spent on each stage of generation.\n")
+	b.WriteString("# TYPE wifiqr_stage_duration_seconds histogram\n")
+	for _, stage := range slices.Sorted(maps.Keys(m.stages)) {
+		h := m.stages[stage]
+		cumulative := uint64(0)
+		for i, le := range latencyBuckets {
+			cumulative += h.counts[i]
+			fmt.Fprintf(b, "wifiqr_stage_duration_seconds_bucket{stage=%s,le=%s} %d\n",
+				quoteLabel(stage), quoteLabel(strconv.FormatFloat(le, 'g', -1, 64)), cumulative)
+		}
+		fmt.Fprintf(b, "wifiqr_stage_duration_seconds_bucket{stage=%s,le=\"+Inf\"} %d\n", quoteLabel(stage), h.count)
+		fmt.Fprintf(b, "wifiqr_stage_duration_seconds_sum{stage=%s} %s\n", quoteLabel(stage), strconv.FormatFloat(h.sum, 'g', -1, 64))
+		fmt.Fprintf(b, "wifiqr_stage_duration_seconds_count{stage=%s} %d\n", quoteLabel(stage), h.count)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// quotes label value, where only backslash, double quote and line feed are escaped
+func quoteLabel(v string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v) + `"`
+}
+
+func (s *server) metricsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = s.metrics.write(w)
+}
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	s := newTestServer(t, testConfig())
+	handler := s.handler()
+
+	form := url.Values{"ssid": {"secret-ssid"}, "password": {"secret-password"}, "encryption": {"WPA"}, "format": {"txt"}}
+	req := httptest.NewRequest("POST", "/qrcode", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	if res.Code != http.StatusOK {
+		t.Fatalf("POST /qrcode status = %d; expected %d", res.Code, http.StatusOK)
+	}
+	if res.Header().Get(requestIDHeader) == "" {
+		t.Errorf("POST /qrcode has no %s header", requestIDHeader)
+	}
+
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest("GET", "/metrics", nil))
+	body := res.Body.String()
+
+	testcases := []struct {
+		want string
+	}{
+		{want: `wifiqr_http_requests_total{method="POST",route="/qrcode",code="200"} 1`},
+		{want: `wifiqr_codes_total{payload="wifi",renderer="txt",version="3",ecl="L"} 1`},
+		{want: `wifiqr_stage_duration_seconds_count{stage="mask_selection"} 1`},
+		{want: `wifiqr_stage_duration_seconds_count{stage="render"} 1`},
+	}
+	for _, tt := range testcases {
+		t.Run("testing metrics.write()", func(t *testing.T) {
+			if !strings.Contains(body, tt.want) {
+				t.Errorf("metrics.write() = %s; expected to contain %s", body, tt.want)
+			}
+		})
+	}
+	if strings.Contains(body, "secret") {
+		t.Errorf("metrics.write() = %s; expected not to contain payload", body)
+	}
+}
+
+func TestQuoteLabel(t *testing.T) {
+	testcases := []struct {
+		value string
+		want  string
+	}{
+		{value: "GET /static/{name}", want: `"GET /static/{name}"`},
+		{value: "a\\b\"c\nd", want: `"a\\b\"c\nd"`},
+	}
+
+	for _, tt := range testcases {
+		t.Run("testing quoteLabel()", func(t *testing.T) {
+			got := quoteLabel(tt.value)
+			if got != tt.want {
+				t.Errorf("quoteLabel() = %s; expected %s", got, tt.want)
+			}
+		})
+	}
+}
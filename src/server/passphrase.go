@@ -0,0 +1,67 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/pasca-l/wifi-qrcode-generator/passphrase"
+)
+
+type passphraseResponse struct {
+	Passphrase  string  `json:"passphrase"`
+	Mode        string  `json:"mode"`
+	EntropyBits float64 `json:"entropy_bits"` // estimate under policy, rounded to a tenth
+}
+
+// generates passphrase under policy in query, avoiding special and look-alike characters unless disabled
+func (s *server) passphrasesHandler(w http.ResponseWriter, r *http.Request) {
+	policy, err := passphrasePolicy(r)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	err = policy.Validate()
+	if err != nil {
+		writeProblem(w, r, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	value, err := passphrase.Generate(policy)
+	if err != nil {
+		writeProblem(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, passphraseResponse{
+		Passphrase:  value,
+		Mode:        string(policy.Mode),
+		EntropyBits: math.Round(policy.Entropy()*10) / 10,
+	})
+}
+
+func passphrasePolicy(r *http.Request) (passphrase.Policy, error) {
+	query := r.URL.Query()
+	policy := passphrase.DefaultPolicy()
+	var err error
+	if mode := query.Get("mode"); mode != "" {
+		policy.Mode, err = passphrase.ParseMode(mode)
+		if err != nil {
+			return passphrase.Policy{}, err
+		}
+	}
+	if length := query.Get("length"); length != "" {
+		policy.Length, err = strconv.Atoi(length)
+		if err != nil {
+			return passphrase.Policy{}, fmt.Errorf("cannot convert value '%s' of length to integer", length)
+		}
+	}
+	for key, field := range map[string]*bool{"avoid_special": &policy.AvoidSpecial, "avoid_look_alike": &policy.AvoidLookAlike} {
+		if value := query.Get(key); value != "" {
+			*field, err = strconv.ParseBool(value)
+			if err != nil {
+				return passphrase.Policy{}, fmt.Errorf("cannot convert value '%s' of %s to boolean", value, key)
+			}
+		}
+	}
+	return policy, nil
+}
@@ -0,0 +1,53 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+)
+
+func TestPassphrasesHandler(t *testing.T) {
+	s := newTestServer(t, testConfig())
+	handler := s.handler()
+
+	testcases := []struct {
+		target      string
+		wantStatus  int
+		want        *regexp.Regexp
+		wantEntropy float64
+	}{
+		{target: "/api/v1/passphrases", wantStatus: http.StatusOK, want: regexp.MustCompile(`^[!-~]{16}$`), wantEntropy: 98.4},
+		{target: "/api/v1/passphrases?mode=diceware&length=5", wantStatus: http.StatusOK, want: regexp.MustCompile(`^[a-z]{3,6}(-[a-z]{3,6}){4}$`), wantEntropy: 51.7},
+		{target: "/api/v1/passphrases?mode=hex", wantStatus: http.StatusOK, want: regexp.MustCompile(`^[0-9a-f]{64}$`), wantEntropy: 256},
+		{target: "/api/v1/passphrases?length=20&avoid_special=false&avoid_look_alike=false", wantStatus: http.StatusOK, want: regexp.MustCompile(`^[!-~]{20}$`), wantEntropy: 131.1},
+		{target: "/api/v1/passphrases?mode=base64", wantStatus: http.StatusBadRequest},
+		{target: "/api/v1/passphrases?avoid_special=maybe", wantStatus: http.StatusBadRequest},
+		{target: "/api/v1/passphrases?length=64", wantStatus: http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range testcases {
+		t.Run("testing server.passphrasesHandler()", func(t *testing.T) {
+			res := httptest.NewRecorder()
+			handler.ServeHTTP(res, httptest.NewRequest("GET", tt.target, nil))
+			if res.Code != tt.wantStatus {
+				t.Fatalf("GET %s status = %d; expected %d: %s", tt.target, res.Code, tt.wantStatus, res.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			if res.Header().Get("Cache-Control") != "no-store" {
+				t.Errorf("GET %s Cache-Control = %s; expected no-store", tt.target, res.Header().Get("Cache-Control"))
+			}
+			var got passphraseResponse
+			err := json.NewDecoder(res.Body).Decode(&got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tt.want.MatchString(got.Passphrase) || got.EntropyBits != tt.wantEntropy {
+				t.Errorf("GET %s = %+v; expected to match %s with entropy %.1f", tt.target, got, tt.want, tt.wantEntropy)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package server
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/pasca-l/wifi-qrcode-generator/layout"
+	"github.com/pasca-l/wifi-qrcode-generator/passphrase"
+	"github.com/pasca-l/wifi-qrcode-generator/rotation"
+	"github.com/pasca-l/wifi-qrcode-generator/vault"
+)
+
+// template of cards printed for networks when none is specified, as they are placed at front desks
+const defaultNetworkTemplate string = "table-tent"
+
+func newRotator(config Config, v *vault.Vault) (*rotation.Rotator, error) {
+	var schedule rotation.Schedule
+	if config.RotationSchedule != "" {
+		var err error
+		schedule, err = rotation.ParseSchedule(config.RotationSchedule)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var hooks []rotation.Hook
+	if config.RotationExec != "" {
+		hooks = append(hooks, rotation.ExecHook{Path: config.RotationExec})
+	}
+	if config.RotationWebhook != "" {
+		hooks = append(hooks, rotation.WebhookHook{URL: config.RotationWebhook, Secret: config.RotationWebhookSecret})
+	}
+	policy := passphrase.DefaultPolicy()
+	policy.Mode = passphrase.Mode(config.RotationMode)
+	return rotation.NewRotator(v, schedule, hooks, policy), nil
+}
+
+// rotates password of network at once, regardless of schedule
+func (s *server) rotateNetworkHandler(w http.ResponseWriter, r *http.Request) {
+	n, err := s.rotator.Rotate(r.Context(), r.PathValue("id"), time.Now())
+	if errors.Is(err, vault.ErrNotFound) || errors.Is(err, rotation.ErrNotRotatable) {
+		writeVaultProblem(w, r, err)
+		return
+	}
+	if err != nil {
+		// hooks failed to push password, so that the current one is kept
+		writeProblem(w, r, http.StatusBadGateway, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, s.networkResponse(n))
+}
+
+// renders card of network with current password, or with next one to be printed ahead of rotation
+func (s *server) networkCardHandler(w http.ResponseWriter, r *http.Request) {
+	n, err := s.vault.Get(r.PathValue("id"))
+	if err != nil {
+		writeVaultProblem(w, r, err)
+		return
+	}
+	query := r.URL.Query()
+
+	// card is valid until the password is rotated
+	validUntil := s.rotator.NextRotation(n)
+	switch query.Get("password") {
+	case "", "current":
+	case "next":
+		if n.NextPassword == "" {
+			writeProblem(w, r, http.StatusNotFound, "network has no next password, as it is not rotated")
+			return
+		}
+		n.Password = n.NextPassword
+		if !validUntil.IsZero() {
+			n.RotatedAt = validUntil
+			validUntil = s.rotator.NextRotation(n)
+		}
+	default:
+		writeProblem(w, r, http.StatusBadRequest, "password must be current or next: given "+query.Get("password"))
+		return
+	}
+
+	spec, err := n.Spec()
+	if err != nil {
+		writeProblem(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+	card := layout.Card{Spec: spec, MaskPassword: query.Get("mask_password") == "true", ValidUntil: validUntil}
+	tmpl, err := layout.Get(cmp.Or(query.Get("template"), defaultNetworkTemplate))
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	format := layout.Format(cmp.Or(query.Get("format"), string(layout.PDFFormat)))
+
+	code, err := s.generateQRCode(spec, s.config.DefaultECL)
+	if err != nil {
+		writeProblem(w, r, limitStatus(err, http.StatusInternalServerError), err.Error())
+		return
+	}
+	buf := new(bytes.Buffer)
+	start := time.Now()
+	err = layout.Render(buf, tmpl, card, code, format)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.metrics.observeRender(time.Since(start))
+	s.metrics.observeCode(wifiPayload, "card-"+string(format), code)
+
+	w.Header().Set("Content-Type", format.MIMEType())
+	w.Header().Set("Cache-Control", "no-store")
+	_, _ = buf.WriteTo(w)
+}
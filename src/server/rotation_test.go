@@ -0,0 +1,81 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRotateNetworkHandler(t *testing.T) {
+	s := newVaultServer(t, "admin")
+	handler := s.handler()
+
+	res := vaultRequest(handler, "POST", "/api/v1/networks", `{"name": "Guest", "payload": {"ssid": "guest", "password": "correct horse", "encryption": "WPA"}, "rotate": true}`, "admin")
+	if res.Code != http.StatusCreated {
+		t.Fatalf("POST /api/v1/networks status = %d; expected %d: %s", res.Code, http.StatusCreated, res.Body.String())
+	}
+	var created networkResponse
+	err := json.NewDecoder(res.Body).Decode(&created)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := s.vault.Get(created.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n.NextPassword == "" {
+		t.Fatalf("next password = ''; expected generated one")
+	}
+	if strings.Contains(res.Body.String(), n.NextPassword) {
+		t.Errorf("response = %s; expected not to contain next password", res.Body.String())
+	}
+
+	res = vaultRequest(handler, "POST", "/api/v1/networks/"+n.ID+"/rotate", "", "admin")
+	if res.Code != http.StatusOK {
+		t.Fatalf("POST rotate status = %d; expected %d: %s", res.Code, http.StatusOK, res.Body.String())
+	}
+	rotated, err := s.vault.Get(n.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rotated.Password != n.NextPassword {
+		t.Errorf("password after rotation = %s; expected next password %s", rotated.Password, n.NextPassword)
+	}
+
+	open, err := s.vault.Create(n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	open.Encryption, open.Password, open.Rotate, open.NextPassword = "nopass", "", false, ""
+	open, err = s.vault.Update(open.ID, open)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testcases := []struct {
+		method        string
+		target        string
+		wantStatus    int
+		wantMediaType string
+	}{
+		{method: "GET", target: "/api/v1/networks/" + n.ID + "/card", wantStatus: http.StatusOK, wantMediaType: "application/pdf"},
+		{method: "GET", target: "/api/v1/networks/" + n.ID + "/card?password=next&format=svg", wantStatus: http.StatusOK, wantMediaType: "image/svg+xml"},
+		{method: "GET", target: "/api/v1/networks/" + n.ID + "/card?password=previous", wantStatus: http.StatusBadRequest},
+		{method: "GET", target: "/api/v1/networks/" + open.ID + "/card?password=next", wantStatus: http.StatusNotFound},
+		{method: "POST", target: "/api/v1/networks/" + open.ID + "/rotate", wantStatus: http.StatusUnprocessableEntity},
+		{method: "POST", target: "/api/v1/networks/unknown/rotate", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range testcases {
+		t.Run("testing rotation handlers", func(t *testing.T) {
+			res := vaultRequest(handler, tt.method, tt.target, "", "admin")
+			if res.Code != tt.wantStatus {
+				t.Errorf("%s %s status = %d; expected %d: %s", tt.method, tt.target, res.Code, tt.wantStatus, res.Body.String())
+			}
+			if tt.wantMediaType != "" && res.Header().Get("Content-Type") != tt.wantMediaType {
+				t.Errorf("%s %s Content-Type = %s; expected %s", tt.method, tt.target, res.Header().Get("Content-Type"), tt.wantMediaType)
+			}
+		})
+	}
+}
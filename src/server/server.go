@@ -1,23 +1,231 @@
 package server
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"log/slog"
 	"net/http"
-	"time"
+	"net/netip"
+	"net/url"
+	"sync/atomic"
+
+	"github.com/pasca-l/wifi-qrcode-generator/profile"
+	"github.com/pasca-l/wifi-qrcode-generator/qrcode"
+	"github.com/pasca-l/wifi-qrcode-generator/rotation"
+	"github.com/pasca-l/wifi-qrcode-generator/vault"
 )
 
-func Serve() error {
-	http.HandleFunc("/", indexHandler)
-	http.HandleFunc("/qrcode", qrcodeHandler)
+// state shared by handlers
+type server struct {
+	config         Config
+	signer         *profile.Signer
+	images         *imageStore
+	assets         assets
+	page           *template.Template
+	ready          atomic.Bool // set once self-test passes, and unset on shutdown
+	metrics        *metrics
+	cache          *responseCache
+	limiter        *rateLimiter // nil if rate is not limited
+	trustedProxies []netip.Prefix
+	tokens         *tokenStore
+	vault          *vault.Vault // nil if not configured
+	vaultKey       []byte
+	rotator        *rotation.Rotator // set along with vault
+}
+
+// serves until context is done, then drains requests in flight within the shutdown timeout
+func Serve(ctx context.Context, config Config) error {
+	s, err := newServer(config)
+	if err != nil {
+		return err
+	}
 
 	server := http.Server{
-		Addr:              ":8080",
-		Handler:           nil,
-		ReadHeaderTimeout: 10 * time.Second,
+		Addr:              config.Addr,
+		Handler:           s.handler(),
+		ReadHeaderTimeout: config.ReadHeaderTimeout,
+		ReadTimeout:       config.ReadTimeout,
+		WriteTimeout:      config.WriteTimeout,
+		IdleTimeout:       config.IdleTimeout,
 	}
-	err := server.ListenAndServe()
+	serveErr := make(chan error, 1)
+	go func() {
+		if config.TLSCert != "" {
+			serveErr <- server.ListenAndServeTLS(config.TLSCert, config.TLSKey)
+			return
+		}
+		serveErr <- server.ListenAndServe()
+	}()
+
+	err = s.selfTest()
 	if err != nil {
-		return err
+		err = fmt.Errorf("self-test failed: %w", err)
+	} else {
+		s.ready.Store(true)
+		slog.Info("listening", slog.String("addr", config.Addr))
+		if config.RotationSchedule != "" {
+			go s.rotator.Run(ctx)
+		}
+		select {
+		case err = <-serveErr:
+			return err
+		case <-ctx.Done():
+		}
+	}
+
+	slog.Info("shutting down", slog.Duration("timeout", config.ShutdownTimeout))
+	// stop being ready first, so that no more requests are routed here while draining
+	s.ready.Store(false)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout)
+	defer cancel()
+	shutdownErr := server.Shutdown(shutdownCtx)
+	if listenErr := <-serveErr; !errors.Is(listenErr, http.ErrServerClosed) {
+		return listenErr
+	}
+	return errors.Join(err, shutdownErr)
+}
+
+// returns server configured and wired as served, validating the config
+func newServer(config Config) (*server, error) {
+	err := config.Validate()
+	if err != nil {
+		return nil, err
+	}
+	s := &server{config: config, images: newImageStore(), metrics: newMetrics(), cache: newResponseCache(config.CacheSize), tokens: newTokenStore()}
+	if config.RequestsPerMinute > 0 {
+		s.limiter = newRateLimiter(config.RequestsPerMinute, config.Burst)
+	}
+	s.trustedProxies, err = parsePrefixes(config.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+	s.assets, err = loadAssets()
+	if err != nil {
+		return nil, err
+	}
+	s.page, err = parsePage(s.assets)
+	if err != nil {
+		return nil, err
+	}
+	if config.VaultPath != "" {
+		s.vaultKey, err = vault.ParseKey(config.VaultKey)
+		if err != nil {
+			return nil, err
+		}
+		s.vault, err = vault.Open(config.VaultPath, s.vaultKey)
+		if err != nil {
+			return nil, err
+		}
+		s.rotator, err = newRotator(config, s.vault)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if config.SigningKey != "" {
+		s.signer, err = profile.LoadSigner(config.SigningKey)
+		if err != nil {
+			return nil, err
+		}
 	}
+	return s, nil
+}
 
+// generates and decodes a known code, to check that codes are generated as expected
+func (s *server) selfTest() error {
+	const want = `WIFI:T:WPA;S:"self-test";P:"correct horse";;`
+	spec, err := qrcode.NewWifiSpec(url.Values{
+		"ssid":       {"self-test"},
+		"password":   {"correct horse"},
+		"encryption": {"WPA"},
+	})
+	if err != nil {
+		return err
+	}
+	if spec.Encode() != want {
+		return fmt.Errorf("payload = %s; expected %s", spec.Encode(), want)
+	}
+	// payload fits a single block of version 3-L, and blocks of unequal lengths of version 5-H,
+	// so that interleaving of blocks is checked as well
+	for _, ecl := range []qrcode.ErrorCorrectionLevel{qrcode.L, qrcode.H} {
+		code, err := s.generateQRCode(spec, ecl)
+		if err != nil {
+			return err
+		}
+		got, err := qrcode.Decode(code.Pattern)
+		if err != nil {
+			return fmt.Errorf("version %d-%s: %w", code.Version, ecl.ToString(), err)
+		}
+		if got != want {
+			return fmt.Errorf("version %d-%s decoded = %s; expected %s", code.Version, ecl.ToString(), got, want)
+		}
+	}
 	return nil
 }
+
+// routes requests, which are placed under the base path if configured
+func (s *server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", s.healthzHandler)
+	mux.HandleFunc("GET /readyz", s.readyzHandler)
+	mux.HandleFunc("GET /metrics", s.metricsHandler)
+	mux.HandleFunc("GET /{$}", s.indexHandler)
+	mux.HandleFunc("GET "+staticPath+"{name}", s.staticHandler)
+	mux.HandleFunc("/qrcode", s.limit(s.qrcodeHandler, writePlainError))
+	mux.HandleFunc("/card", s.limit(s.cardHandler, writePlainError))
+	mux.HandleFunc("/batch", s.limit(s.batchHandler, writePlainError))
+	mux.HandleFunc("/profile", s.limit(s.profileHandler, writePlainError))
+	for _, ext := range imageExtensions {
+		mux.HandleFunc("GET /qrcode."+ext, s.limit(s.qrcodeImageHandler(ext), writePlainError))
+	}
+	mux.HandleFunc("/api/v1/codes", s.limit(s.codesHandler, writeProblem))
+	mux.HandleFunc("/api/v1/tokens", s.limit(s.tokensHandler, writeProblem))
+	mux.HandleFunc("GET /api/v1/passphrases", s.limit(s.passphrasesHandler, writeProblem))
+	mux.HandleFunc("GET /api/v1/networks", s.admin(s.listNetworksHandler))
+	mux.HandleFunc("POST /api/v1/networks", s.admin(s.createNetworkHandler))
+	mux.HandleFunc("GET /api/v1/networks/{id}", s.admin(s.getNetworkHandler))
+	mux.HandleFunc("PUT /api/v1/networks/{id}", s.admin(s.updateNetworkHandler))
+	mux.HandleFunc("DELETE /api/v1/networks/{id}", s.admin(s.deleteNetworkHandler))
+	mux.HandleFunc("POST /api/v1/networks/{id}/shares", s.admin(s.shareNetworkHandler))
+	mux.HandleFunc("DELETE /api/v1/networks/{id}/shares", s.admin(s.revokeSharesHandler))
+	mux.HandleFunc("POST /api/v1/networks/{id}/rotate", s.admin(s.rotateNetworkHandler))
+	mux.HandleFunc("GET /api/v1/networks/{id}/card", s.admin(s.networkCardHandler))
+	mux.HandleFunc("GET "+sharePath+"{token}", s.limit(s.sharePageHandler, writePlainError))
+	mux.HandleFunc("GET "+sharePath+"{token}/qrcode.svg", s.limit(s.shareImageHandler, writePlainError))
+	mux.HandleFunc("GET "+imagePath+"{id}", s.imageHandler)
+	mux.HandleFunc("GET /api/v1/openapi.json", s.openAPIHandler)
+
+	handler := recordRoute(mux)
+	if s.config.BasePath != "" {
+		base := http.NewServeMux()
+		base.Handle(s.config.BasePath+"/", http.StripPrefix(s.config.BasePath, handler))
+		// page links are relative, so that they must be resolved against the trailing slash
+		base.Handle(s.config.BasePath, http.RedirectHandler(s.config.BasePath+"/", http.StatusMovedPermanently))
+		handler = base
+	}
+
+	return s.logRequests(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxBodySize)
+		handler.ServeHTTP(w, r)
+	}))
+}
+
+// reports that the process is alive, regardless of readiness
+func (s *server) healthzHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	_, _ = w.Write([]byte("ok\n"))
+}
+
+// reports whether requests should be routed here, which is after self-test and before shutdown
+func (s *server) readyzHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	if !s.ready.Load() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte("not ready\n"))
+		return
+	}
+	_, _ = w.Write([]byte("ok\n"))
+}
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// returns server wired as by Serve, failing the test if it cannot be
+func newTestServer(t *testing.T, config Config) *server {
+	t.Helper()
+	s, err := newServer(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// default config without rate limit, as every request of tests comes from the same address
+func testConfig() Config {
+	config := DefaultConfig()
+	config.RequestsPerMinute = 0
+	return config
+}
+
+func TestSelfTest(t *testing.T) {
+	s := newTestServer(t, testConfig())
+	err := s.selfTest()
+	if err != nil {
+		t.Errorf("server.selfTest() error = '%v'", err)
+	}
+}
+
+func TestReadyzHandler(t *testing.T) {
+	s := newTestServer(t, testConfig())
+	handler := s.handler()
+
+	testcases := []struct {
+		ready      bool
+		path       string
+		wantStatus int
+	}{
+		{ready: false, path: "/healthz", wantStatus: http.StatusOK},
+		{ready: false, path: "/readyz", wantStatus: http.StatusServiceUnavailable},
+		{ready: true, path: "/readyz", wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range testcases {
+		t.Run("testing readyzHandler()", func(t *testing.T) {
+			s.ready.Store(tt.ready)
+			res := httptest.NewRecorder()
+			handler.ServeHTTP(res, httptest.NewRequest("GET", tt.path, nil))
+			if res.Code != tt.wantStatus {
+				t.Errorf("GET %s status = %d; expected %d", tt.path, res.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestServeShutdown(t *testing.T) {
+	config := DefaultConfig()
+	config.Addr = "127.0.0.1:0"
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	done := make(chan error, 1)
+	go func() { done <- Serve(ctx, config) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Serve() error = '%v'", err)
+		}
+	case <-time.After(config.ShutdownTimeout):
+		t.Errorf("Serve() did not return after context is done")
+	}
+}
@@ -0,0 +1,229 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// number of specs kept for tokens, bounding memory held by unused ones
+const maxStoredSpecs int = 10000
+
+var errTokenStoreFull = errors.New("too many tokens: retry later or use shorter lifetimes")
+var errInvalidToken = errors.New("token is invalid or has expired")
+
+// parameters of the form which carry the network, and are therefore only taken from stored specs
+var payloadParams = []string{"ssid", "password", "encryption", "hidden", "psk", "eap_method", "phase2", "identity", "anonymous_identity"}
+
+type storedSpec struct {
+	params    url.Values
+	expiresAt time.Time
+}
+
+// specs referenced by signed tokens with random identifiers
+type tokenStore struct {
+	secret []byte
+
+	mu    sync.Mutex
+	specs map[string]storedSpec
+}
+
+func newTokenStore() *tokenStore {
+	secret := make([]byte, 32)
+	_, _ = rand.Read(secret)
+	return &tokenStore{secret: secret, specs: make(map[string]storedSpec)}
+}
+
+// returns token formed as "<id>.<expiry in unix seconds>.<signature>",
+// where the signature is HMAC of the former two
+func signToken(secret []byte, id string, expiresAt time.Time) string {
+	payload := id + "." + strconv.FormatInt(expiresAt.Unix(), 10)
+	return payload + "." + tokenSignature(secret, payload)
+}
+
+func tokenSignature(secret []byte, payload string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// returns identifier of token, if signed and not expired
+func verifyToken(secret []byte, token string, now time.Time) (string, error) {
+	i := strings.LastIndex(token, ".")
+	if i < 0 || !hmac.Equal([]byte(token[i+1:]), []byte(tokenSignature(secret, token[:i]))) {
+		return "", errInvalidToken
+	}
+	id, expiry, _ := strings.Cut(token[:i], ".")
+	unix, err := strconv.ParseInt(expiry, 10, 64)
+	if err != nil || now.After(time.Unix(unix, 0)) {
+		return "", errInvalidToken
+	}
+	return id, nil
+}
+
+// stores parameters of the form, returning token referencing them until expiry
+func (s *tokenStore) add(params url.Values, lifetime time.Duration) (string, time.Time, error) {
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	id := base64.RawURLEncoding.EncodeToString(b)
+	now := time.Now()
+	expiresAt := now.Add(lifetime).UTC().Truncate(time.Second)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, spec := range s.specs {
+		if now.After(spec.expiresAt) {
+			delete(s.specs, key)
+		}
+	}
+	if len(s.specs) >= maxStoredSpecs {
+		return "", time.Time{}, errTokenStoreFull
+	}
+	s.specs[id] = storedSpec{params: params, expiresAt: expiresAt}
+
+	return signToken(s.secret, id, expiresAt), expiresAt, nil
+}
+
+// returns parameters referenced by token, if signed and not expired
+func (s *tokenStore) get(token string, now time.Time) (url.Values, error) {
+	id, err := verifyToken(s.secret, token, now)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	spec, ok := s.specs[id]
+	if !ok || now.After(spec.expiresAt) {
+		return nil, errInvalidToken
+	}
+	return spec.params, nil
+}
+
+type tokenRequest struct {
+	Payload payloadRequest `json:"payload"`
+	ECL     string         `json:"ecl"`
+	Render  renderRequest  `json:"render"` // defaults of images, format is chosen by extension
+	TTL     int            `json:"ttl"`    // lifetime in seconds, up to the configured one
+}
+
+type tokenResponse struct {
+	Token     string            `json:"token"`
+	ExpiresAt time.Time         `json:"expires_at"`
+	Links     map[string]string `json:"links"` // image links by format
+}
+
+// formats of images served by GET, chosen by extension
+var imageExtensions = []string{"svg", "png", "txt"}
+
+// issues token referencing network, so that images are embedded without credentials in links
+func (s *server) tokensHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		w.Header().Set("Allow", "POST")
+		writeProblem(w, r, http.StatusMethodNotAllowed, "POST method required")
+		return
+	}
+	var req tokenRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	res, err := s.issueToken(req)
+	if errors.Is(err, errTokenStoreFull) {
+		w.Header().Set("Retry-After", "60")
+		writeProblem(w, r, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+	if err != nil {
+		writeProblem(w, r, limitStatus(err, http.StatusUnprocessableEntity), err.Error())
+		return
+	}
+
+	w.Header().Set("Location", res.Links[imageExtensions[0]])
+	writeJSON(w, http.StatusCreated, res)
+}
+
+func (s *server) issueToken(req tokenRequest) (tokenResponse, error) {
+	if req.Payload.Type != "" && req.Payload.Type != wifiPayload {
+		return tokenResponse{}, fmt.Errorf("unexpected payload type: %s", req.Payload.Type)
+	}
+	lifetime := s.config.TokenLifetime
+	if req.TTL < 0 || time.Duration(req.TTL)*time.Second > lifetime {
+		return tokenResponse{}, fmt.Errorf("ttl must be between 0 and %d seconds: given %d", int(lifetime.Seconds()), req.TTL)
+	}
+	if req.TTL > 0 {
+		lifetime = time.Duration(req.TTL) * time.Second
+	}
+
+	params := req.Render.values()
+	params.Del("format")
+	for key, values := range req.Payload.values() {
+		params[key] = values
+	}
+	params.Set("ecl", req.ECL)
+
+	// spec is checked once by generating its code, so that tokens are only issued for valid ones
+	_, _, err := s.renderQRCode(params, nil)
+	if err != nil {
+		return tokenResponse{}, err
+	}
+
+	token, expiresAt, err := s.tokens.add(params, lifetime)
+	if err != nil {
+		return tokenResponse{}, err
+	}
+	res := tokenResponse{Token: token, ExpiresAt: expiresAt, Links: make(map[string]string)}
+	for _, ext := range imageExtensions {
+		res.Links[ext] = s.config.BasePath + "/qrcode." + ext + "?" + url.Values{"token": {token}}.Encode()
+	}
+	return res, nil
+}
+
+// renders image of format by extension, from token or from query without password
+// render options in query take precedence over the ones stored for token
+func (s *server) qrcodeImageHandler(format string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		form := make(url.Values)
+		for key, values := range query {
+			form[key] = values
+		}
+
+		if token := query.Get("token"); token != "" {
+			stored, err := s.tokens.get(token, time.Now())
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			// network is only taken from token, so that links cannot be altered
+			for _, key := range payloadParams {
+				form.Del(key)
+			}
+			for key, values := range stored {
+				if _, given := form[key]; !given {
+					form[key] = values
+				}
+			}
+		} else if query.Has("password") {
+			// links end up in logs, histories and referrers of embedding pages
+			http.Error(w, "password must not be given in links: issue a token with POST /api/v1/tokens instead", http.StatusBadRequest)
+			return
+		}
+		form.Del("token")
+		form.Set("format", format)
+
+		s.serveQRCode(w, r, form)
+	}
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTokenStoreGet(t *testing.T) {
+	store := newTokenStore()
+	params := url.Values{"ssid": {"guest"}}
+	token, _, err := store.add(params, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, rest, _ := strings.Cut(token, ".")
+	expiry, signature, _ := strings.Cut(rest, ".")
+
+	testcases := []struct {
+		token   string
+		now     time.Time
+		wantErr error
+	}{
+		{token: token, now: time.Now(), wantErr: nil},
+		{token: token, now: time.Now().Add(2 * time.Hour), wantErr: errInvalidToken},
+		// extending expiry breaks signature
+		{token: id + "." + expiry + "0." + signature, now: time.Now(), wantErr: errInvalidToken},
+		{token: id + "." + expiry + "." + strings.Repeat("A", len(signature)), now: time.Now(), wantErr: errInvalidToken},
+		{token: "", now: time.Now(), wantErr: errInvalidToken},
+	}
+
+	for _, tt := range testcases {
+		t.Run("testing tokenStore.get()", func(t *testing.T) {
+			got, err := store.get(tt.token, tt.now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("tokenStore.get() error = '%v'; expected '%v'", err, tt.wantErr)
+			}
+			if err == nil && got.Get("ssid") != "guest" {
+				t.Errorf("tokenStore.get() = %v; expected %v", got, params)
+			}
+		})
+	}
+}
+
+func TestQRCodeImageHandler(t *testing.T) {
+	s := newTestServer(t, testConfig())
+	handler := s.handler()
+
+	body := `{"payload": {"ssid": "guest", "password": "correct horse", "encryption": "WPA"}, "render": {"theme": "ocean"}}`
+	req := httptest.NewRequest("POST", "/api/v1/tokens", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	if res.Code != http.StatusCreated {
+		t.Fatalf("POST /api/v1/tokens status = %d; expected %d: %s", res.Code, http.StatusCreated, res.Body.String())
+	}
+	var issued tokenResponse
+	err := json.NewDecoder(res.Body).Decode(&issued)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(issued.Links["svg"], "horse") {
+		t.Errorf("link = %s; expected not to contain password", issued.Links["svg"])
+	}
+
+	testcases := []struct {
+		target        string
+		wantStatus    int
+		wantMediaType string
+	}{
+		{target: issued.Links["svg"], wantStatus: http.StatusOK, wantMediaType: "image/svg+xml"},
+		{target: issued.Links["png"] + "&size=100", wantStatus: http.StatusOK, wantMediaType: "image/png"},
+		{target: issued.Links["txt"], wantStatus: http.StatusOK, wantMediaType: "text/plain; charset=utf-8"},
+		{target: "/qrcode.svg?token=invalid", wantStatus: http.StatusNotFound},
+		{target: "/qrcode.svg?ssid=cafe&encryption=nopass", wantStatus: http.StatusOK, wantMediaType: "image/svg+xml"},
+		{target: "/qrcode.svg?ssid=cafe&encryption=WPA&password=secret", wantStatus: http.StatusBadRequest},
+		{target: "/qrcode.gif?ssid=cafe&encryption=nopass", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range testcases {
+		t.Run("testing server.qrcodeImageHandler()", func(t *testing.T) {
+			res := httptest.NewRecorder()
+			handler.ServeHTTP(res, httptest.NewRequest("GET", tt.target, nil))
+			if res.Code != tt.wantStatus {
+				t.Fatalf("GET %s status = %d; expected %d: %s", tt.target, res.Code, tt.wantStatus, res.Body.String())
+			}
+			if tt.wantMediaType != "" && res.Header().Get("Content-Type") != tt.wantMediaType {
+				t.Errorf("GET %s Content-Type = %s; expected %s", tt.target, res.Header().Get("Content-Type"), tt.wantMediaType)
+			}
+		})
+	}
+
+	// network in query is ignored for tokens, so that links cannot be altered
+	withToken := httptest.NewRecorder()
+	handler.ServeHTTP(withToken, httptest.NewRequest("GET", issued.Links["txt"], nil))
+	altered := httptest.NewRecorder()
+	handler.ServeHTTP(altered, httptest.NewRequest("GET", issued.Links["txt"]+"&ssid=other&eap_method=PEAP", nil))
+	if altered.Body.String() != withToken.Body.String() {
+		t.Errorf("GET %s with network in query expected to render the stored network", issued.Links["txt"])
+	}
+}
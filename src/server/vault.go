@@ -0,0 +1,344 @@
+package server
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pasca-l/wifi-qrcode-generator/vault"
+)
+
+// path of share pages, followed by their tokens
+const sharePath string = "/share/"
+
+type networkRequest struct {
+	Name    string         `json:"name"`
+	Payload payloadRequest `json:"payload"`
+	Rotate  bool           `json:"rotate"` // replaces password on configured schedule
+}
+
+// saved network without its password, which is never returned once saved
+type networkResponse struct {
+	ID           string      `json:"id"`
+	Name         string      `json:"name"`
+	SSID         string      `json:"ssid"`
+	Encryption   string      `json:"encryption"`
+	Hidden       bool        `json:"hidden"`
+	PSK          bool        `json:"psk"`
+	HasPassword  bool        `json:"has_password"`
+	EAP          *eapRequest `json:"eap,omitempty"`
+	UpdatedAt    time.Time   `json:"updated_at"`
+	Rotate       bool        `json:"rotate"`
+	RotatedAt    time.Time   `json:"rotated_at,omitzero"`
+	NextRotation time.Time   `json:"next_rotation,omitzero"`
+}
+
+type shareRequest struct {
+	TTL int `json:"ttl"` // lifetime in seconds, up to the configured one
+}
+
+type shareResponse struct {
+	Href      string    `json:"href"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (req networkRequest) network() (vault.Network, error) {
+	if req.Payload.Type != "" && req.Payload.Type != wifiPayload {
+		return vault.Network{}, fmt.Errorf("unexpected payload type: %s", req.Payload.Type)
+	}
+	n := vault.Network{
+		Name:       req.Name,
+		SSID:       req.Payload.SSID,
+		Password:   req.Payload.Password,
+		Encryption: req.Payload.Encryption,
+		Hidden:     req.Payload.Hidden,
+		PSK:        req.Payload.PSK,
+		Rotate:     req.Rotate,
+	}
+	if req.Payload.EAP != nil {
+		n.EAP = &vault.EAP{
+			Method:            req.Payload.EAP.Method,
+			Phase2:            req.Payload.EAP.Phase2,
+			Identity:          req.Payload.EAP.Identity,
+			AnonymousIdentity: req.Payload.EAP.AnonymousIdentity,
+		}
+	}
+	return n, nil
+}
+
+func (s *server) networkResponse(n vault.Network) networkResponse {
+	res := networkResponse{
+		ID:           n.ID,
+		Name:         n.Name,
+		SSID:         n.SSID,
+		Encryption:   n.Encryption,
+		Hidden:       n.Hidden,
+		PSK:          n.PSK,
+		HasPassword:  n.Password != "",
+		UpdatedAt:    n.UpdatedAt,
+		Rotate:       n.Rotate,
+		RotatedAt:    n.RotatedAt,
+		NextRotation: s.rotator.NextRotation(n),
+	}
+	if n.EAP != nil {
+		res.EAP = &eapRequest{
+			Method:            n.EAP.Method,
+			Phase2:            n.EAP.Phase2,
+			Identity:          n.EAP.Identity,
+			AnonymousIdentity: n.EAP.AnonymousIdentity,
+		}
+	}
+	return res
+}
+
+// allows administration of vault with admin token, which is required along with vault
+// even from loopback, as reverse proxies on the same host forward every client
+func (s *server) admin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.vault == nil {
+			writeProblem(w, r, http.StatusNotFound, "vault is not configured")
+			return
+		}
+		token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !found || s.config.AdminToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(s.config.AdminToken)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="vault"`)
+			writeProblem(w, r, http.StatusUnauthorized, "admin token is required")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// decodes JSON body of request, writing problem if it cannot
+func decodeJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "application/json" {
+		writeProblem(w, r, http.StatusUnsupportedMediaType, "request body must be application/json")
+		return false
+	}
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(v)
+	if err != nil {
+		writeProblem(w, r, limitStatus(err, http.StatusBadRequest), fmt.Sprintf("cannot decode request: %v", err))
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writes problem of vault error, where invalid networks cannot be processed
+func writeVaultProblem(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, vault.ErrNotFound) {
+		writeProblem(w, r, http.StatusNotFound, err.Error())
+		return
+	}
+	writeProblem(w, r, http.StatusUnprocessableEntity, err.Error())
+}
+
+func (s *server) listNetworksHandler(w http.ResponseWriter, r *http.Request) {
+	networks := s.vault.List()
+	res := make([]networkResponse, 0, len(networks))
+	for _, n := range networks {
+		res = append(res, s.networkResponse(n))
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (s *server) createNetworkHandler(w http.ResponseWriter, r *http.Request) {
+	var req networkRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	n, err := req.network()
+	if err == nil {
+		n, err = s.rotator.Prepare(n, time.Now())
+	}
+	if err == nil {
+		n, err = s.vault.Create(n)
+	}
+	if err != nil {
+		writeVaultProblem(w, r, err)
+		return
+	}
+	w.Header().Set("Location", s.config.BasePath+"/api/v1/networks/"+n.ID)
+	writeJSON(w, http.StatusCreated, s.networkResponse(n))
+}
+
+func (s *server) getNetworkHandler(w http.ResponseWriter, r *http.Request) {
+	n, err := s.vault.Get(r.PathValue("id"))
+	if err != nil {
+		writeVaultProblem(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.networkResponse(n))
+}
+
+// replaces network, keeping its password if not given, as it is never returned to be sent back
+func (s *server) updateNetworkHandler(w http.ResponseWriter, r *http.Request) {
+	var req networkRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	// updated through rotator, as rotations in progress would save their passwords over the update
+	n, err := s.rotator.Update(r.PathValue("id"), func(old vault.Network) (vault.Network, error) {
+		n, err := req.network()
+		if err != nil {
+			return vault.Network{}, err
+		}
+		if n.Password == "" {
+			n.Password = old.Password
+		}
+		// rotation state is not given by clients, and kept while rotation is enabled
+		n.NextPassword, n.RotatedAt = old.NextPassword, old.RotatedAt
+		return s.rotator.Prepare(n, time.Now())
+	})
+	if err != nil {
+		writeVaultProblem(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.networkResponse(n))
+}
+
+func (s *server) deleteNetworkHandler(w http.ResponseWriter, r *http.Request) {
+	err := s.vault.Delete(r.PathValue("id"))
+	if err != nil {
+		writeVaultProblem(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// secret signing share links, derived from vault key so that links stay valid across restarts
+func (s *server) shareSecret() []byte {
+	mac := hmac.New(sha256.New, s.vaultKey)
+	mac.Write([]byte("share links"))
+	return mac.Sum(nil)
+}
+
+// issues link to a page showing the current code of network, without its password
+// links refer to the network, so that they show the new code once its password is changed
+func (s *server) shareNetworkHandler(w http.ResponseWriter, r *http.Request) {
+	var req shareRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	n, err := s.vault.Get(r.PathValue("id"))
+	if err != nil {
+		writeVaultProblem(w, r, err)
+		return
+	}
+	lifetime := s.config.ShareLifetime
+	if req.TTL < 0 || time.Duration(req.TTL)*time.Second > lifetime {
+		writeProblem(w, r, http.StatusUnprocessableEntity, fmt.Sprintf("ttl must be between 0 and %d seconds: given %d", int(lifetime.Seconds()), req.TTL))
+		return
+	}
+	if req.TTL > 0 {
+		lifetime = time.Duration(req.TTL) * time.Second
+	}
+
+	expiresAt := time.Now().Add(lifetime).UTC().Truncate(time.Second)
+	res := shareResponse{
+		Href:      s.config.BasePath + sharePath + signToken(s.shareSecret(), shareID(n), expiresAt),
+		ExpiresAt: expiresAt,
+	}
+	w.Header().Set("Location", res.Href)
+	writeJSON(w, http.StatusCreated, res)
+}
+
+// revokes every share link of network issued so far
+func (s *server) revokeSharesHandler(w http.ResponseWriter, r *http.Request) {
+	_, err := s.vault.RevokeShares(r.PathValue("id"))
+	if err != nil {
+		writeVaultProblem(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// identifies network in share links along with its share generation, as "id~generation"
+func shareID(n vault.Network) string {
+	return n.ID + "~" + strconv.Itoa(n.ShareGeneration)
+}
+
+// returns network shared by token, unless its share links are revoked since
+func (s *server) sharedNetwork(token string) (vault.Network, error) {
+	if s.vault == nil {
+		return vault.Network{}, errInvalidToken
+	}
+	sharedID, err := verifyToken(s.shareSecret(), token, time.Now())
+	if err != nil {
+		return vault.Network{}, err
+	}
+	id, generation, found := strings.Cut(sharedID, "~")
+	if !found {
+		return vault.Network{}, errInvalidToken
+	}
+	n, err := s.vault.Get(id)
+	if err != nil || generation != strconv.Itoa(n.ShareGeneration) {
+		return vault.Network{}, errInvalidToken
+	}
+	return n, nil
+}
+
+// values injected into the share page template
+type sharePageData struct {
+	Name  string
+	SSID  string
+	Image string // link to the code, relative to the page
+}
+
+// shows code of shared network for staff to display, refreshed to pick up password changes
+func (s *server) sharePageHandler(w http.ResponseWriter, r *http.Request) {
+	token := r.PathValue("token")
+	n, err := s.sharedNetwork(token)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	buf := new(bytes.Buffer)
+	err = s.page.ExecuteTemplate(buf, "share.html", sharePageData{
+		Name:  n.Name,
+		SSID:  n.SSID,
+		Image: url.PathEscape(token) + "/qrcode.svg",
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	// token in the link must not be passed on by following links
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.Header().Set("X-Robots-Tag", "noindex")
+	_, _ = buf.WriteTo(w)
+}
+
+func (s *server) shareImageHandler(w http.ResponseWriter, r *http.Request) {
+	n, err := s.sharedNetwork(r.PathValue("token"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	form := n.Values()
+	form.Set("format", "svg")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	s.serveQRCode(w, r, form)
+}
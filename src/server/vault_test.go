@@ -0,0 +1,224 @@
+package server
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pasca-l/wifi-qrcode-generator/vault"
+)
+
+func newVaultServer(t *testing.T, adminToken string) *server {
+	t.Helper()
+	config := testConfig()
+	config.AdminToken = adminToken
+	config.VaultPath = filepath.Join(t.TempDir(), "vault.json")
+	config.VaultKey = base64.StdEncoding.EncodeToString(make([]byte, vault.KeySize))
+	return newTestServer(t, config)
+}
+
+func vaultRequest(handler http.Handler, method string, target string, body string, adminToken string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if adminToken != "" {
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+	}
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	return res
+}
+
+func TestNetworksHandlers(t *testing.T) {
+	s := newVaultServer(t, "admin")
+	handler := s.handler()
+
+	res := vaultRequest(handler, "POST", "/api/v1/networks", `{"name": "Guest", "payload": {"ssid": "guest", "password": "correct horse", "encryption": "WPA"}}`, "admin")
+	if res.Code != http.StatusCreated {
+		t.Fatalf("POST /api/v1/networks status = %d; expected %d: %s", res.Code, http.StatusCreated, res.Body.String())
+	}
+	if strings.Contains(res.Body.String(), "horse") {
+		t.Errorf("response = %s; expected not to contain password", res.Body.String())
+	}
+	var created networkResponse
+	err := json.NewDecoder(res.Body).Decode(&created)
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := "/api/v1/networks/" + created.ID
+
+	// password is kept when not given
+	res = vaultRequest(handler, "PUT", target, `{"name": "Lobby", "payload": {"ssid": "guest", "encryption": "WPA"}}`, "admin")
+	if res.Code != http.StatusOK {
+		t.Fatalf("PUT %s status = %d; expected %d: %s", target, res.Code, http.StatusOK, res.Body.String())
+	}
+	n, err := s.vault.Get(created.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n.Name != "Lobby" || n.Password != "correct horse" {
+		t.Errorf("updated network = %+v; expected name 'Lobby' and password kept", n)
+	}
+
+	testcases := []struct {
+		method     string
+		target     string
+		body       string
+		adminToken string
+		wantStatus int
+	}{
+		{method: "GET", target: "/api/v1/networks", adminToken: "admin", wantStatus: http.StatusOK},
+		{method: "GET", target: target, adminToken: "admin", wantStatus: http.StatusOK},
+		{method: "GET", target: "/api/v1/networks/unknown", adminToken: "admin", wantStatus: http.StatusNotFound},
+		{method: "GET", target: target, adminToken: "", wantStatus: http.StatusUnauthorized},
+		{method: "GET", target: target, adminToken: "wrong", wantStatus: http.StatusUnauthorized},
+		{method: "POST", target: "/api/v1/networks", body: `{"name": "Empty", "payload": {"ssid": ""}}`, adminToken: "admin", wantStatus: http.StatusUnprocessableEntity},
+		{method: "POST", target: target + "/shares", body: `{"ttl": -1}`, adminToken: "admin", wantStatus: http.StatusUnprocessableEntity},
+		{method: "DELETE", target: target, adminToken: "admin", wantStatus: http.StatusNoContent},
+		{method: "DELETE", target: target, adminToken: "admin", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range testcases {
+		t.Run("testing networks handlers", func(t *testing.T) {
+			res := vaultRequest(handler, tt.method, tt.target, tt.body, tt.adminToken)
+			if res.Code != tt.wantStatus {
+				t.Errorf("%s %s status = %d; expected %d: %s", tt.method, tt.target, res.Code, tt.wantStatus, res.Body.String())
+			}
+			if strings.Contains(res.Body.String(), "horse") {
+				t.Errorf("%s %s response = %s; expected not to contain password", tt.method, tt.target, res.Body.String())
+			}
+		})
+	}
+}
+
+func TestAdmin(t *testing.T) {
+	s := newVaultServer(t, "admin")
+	handler := s.handler()
+
+	testcases := []struct {
+		remoteAddr    string
+		authorization string
+		wantStatus    int
+	}{
+		{remoteAddr: "192.0.2.1:1234", authorization: "Bearer admin", wantStatus: http.StatusOK},
+		// loopback clients need the token as well, as they may be reverse proxies
+		{remoteAddr: "127.0.0.1:1234", wantStatus: http.StatusUnauthorized},
+		{remoteAddr: "[::1]:1234", authorization: "Bearer wrong", wantStatus: http.StatusUnauthorized},
+		{remoteAddr: "192.0.2.1:1234", authorization: "admin", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range testcases {
+		t.Run("testing server.admin()", func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/v1/networks", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			res := httptest.NewRecorder()
+			handler.ServeHTTP(res, req)
+			if res.Code != tt.wantStatus {
+				t.Errorf("GET /api/v1/networks from %s with %q status = %d; expected %d", tt.remoteAddr, tt.authorization, res.Code, tt.wantStatus)
+			}
+		})
+	}
+
+	// routes are not found without vault
+	s.vault = nil
+	res := vaultRequest(handler, "GET", "/api/v1/networks", "", "")
+	if res.Code != http.StatusNotFound {
+		t.Errorf("GET /api/v1/networks without vault status = %d; expected %d", res.Code, http.StatusNotFound)
+	}
+}
+
+func TestSharePageHandler(t *testing.T) {
+	s := newVaultServer(t, "admin")
+	handler := s.handler()
+
+	n, err := s.vault.Create(vault.Network{Name: "Guest", SSID: "guest", Password: "correct horse", Encryption: "WPA"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := vaultRequest(handler, "POST", "/api/v1/networks/"+n.ID+"/shares", `{"ttl": 3600}`, "admin")
+	if res.Code != http.StatusCreated {
+		t.Fatalf("POST shares status = %d; expected %d: %s", res.Code, http.StatusCreated, res.Body.String())
+	}
+	var share shareResponse
+	err = json.NewDecoder(res.Body).Decode(&share)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	page := vaultRequest(handler, "GET", share.Href, "", "")
+	if page.Code != http.StatusOK {
+		t.Fatalf("GET %s status = %d; expected %d", share.Href, page.Code, http.StatusOK)
+	}
+	if strings.Contains(page.Body.String(), "horse") {
+		t.Errorf("share page = %s; expected not to contain password", page.Body.String())
+	}
+	if !strings.Contains(page.Body.String(), "Guest") {
+		t.Errorf("share page = %s; expected to contain name 'Guest'", page.Body.String())
+	}
+
+	testcases := []struct {
+		target        string
+		wantStatus    int
+		wantMediaType string
+	}{
+		{target: share.Href + "/qrcode.svg", wantStatus: http.StatusOK, wantMediaType: "image/svg+xml"},
+		{target: sharePath + "invalid", wantStatus: http.StatusNotFound},
+		{target: sharePath + "invalid/qrcode.svg", wantStatus: http.StatusNotFound},
+		// signed ids without share generation are not issued
+		{target: sharePath + signToken(s.shareSecret(), n.ID, time.Now().Add(time.Hour)) + "/qrcode.svg", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range testcases {
+		t.Run("testing server.shareImageHandler()", func(t *testing.T) {
+			res := vaultRequest(handler, "GET", tt.target, "", "")
+			if res.Code != tt.wantStatus {
+				t.Errorf("GET %s status = %d; expected %d", tt.target, res.Code, tt.wantStatus)
+			}
+			if tt.wantMediaType != "" && res.Header().Get("Content-Type") != tt.wantMediaType {
+				t.Errorf("GET %s Content-Type = %s; expected %s", tt.target, res.Header().Get("Content-Type"), tt.wantMediaType)
+			}
+		})
+	}
+
+	// links stop working once revoked, even if the network is updated afterwards, while new ones work
+	res = vaultRequest(handler, "DELETE", "/api/v1/networks/"+n.ID+"/shares", "", "admin")
+	if res.Code != http.StatusNoContent {
+		t.Fatalf("DELETE shares status = %d; expected %d: %s", res.Code, http.StatusNoContent, res.Body.String())
+	}
+	res = vaultRequest(handler, "PUT", "/api/v1/networks/"+n.ID, `{"name": "Guest", "payload": {"ssid": "guest", "encryption": "WPA"}}`, "admin")
+	if res.Code != http.StatusOK {
+		t.Fatalf("PUT network status = %d; expected %d: %s", res.Code, http.StatusOK, res.Body.String())
+	}
+	res = vaultRequest(handler, "GET", share.Href, "", "")
+	if res.Code != http.StatusNotFound {
+		t.Errorf("GET %s after revocation status = %d; expected %d", share.Href, res.Code, http.StatusNotFound)
+	}
+	res = vaultRequest(handler, "POST", "/api/v1/networks/"+n.ID+"/shares", `{}`, "admin")
+	err = json.NewDecoder(res.Body).Decode(&share)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res = vaultRequest(handler, "GET", share.Href, "", "")
+	if res.Code != http.StatusOK {
+		t.Errorf("GET %s issued after revocation status = %d; expected %d", share.Href, res.Code, http.StatusOK)
+	}
+
+	// links stop working once network is deleted
+	err = s.vault.Delete(n.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res = vaultRequest(handler, "GET", share.Href, "", "")
+	if res.Code != http.StatusNotFound {
+		t.Errorf("GET %s after deletion status = %d; expected %d", share.Href, res.Code, http.StatusNotFound)
+	}
+}
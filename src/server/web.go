@@ -0,0 +1,125 @@
+package server
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"html/template"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/pasca-l/wifi-qrcode-generator/qrcode"
+)
+
+//go:embed web
+var webFS embed.FS
+
+// path of static assets, followed by their content hashed names
+const staticPath string = "/static/"
+
+type asset struct {
+	data []byte
+	hash string
+}
+
+// static assets keyed by content hashed names, such as "style.0123abcd.css",
+// so that they can be cached forever and still be replaced on upgrades
+type assets struct {
+	files map[string]asset
+	names map[string]string // hashed name of each original name
+}
+
+func loadAssets() (assets, error) {
+	a := assets{files: make(map[string]asset), names: make(map[string]string)}
+	err := fs.WalkDir(webFS, "web/static", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := webFS.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(data)
+		hash := hex.EncodeToString(sum[:4])
+		name := path.Base(p)
+		ext := path.Ext(name)
+		hashed := strings.TrimSuffix(name, ext) + "." + hash + ext
+		a.files[hashed] = asset{data: data, hash: hash}
+		a.names[name] = hashed
+		return nil
+	})
+	return a, err
+}
+
+// returns path of asset relative to the page, panicking on unknown names as templates are fixed
+func (a assets) path(name string) string {
+	hashed, ok := a.names[name]
+	if !ok {
+		panic("unknown asset: " + name)
+	}
+	return strings.TrimPrefix(staticPath, "/") + hashed
+}
+
+// encryption shown as a radio button of the form
+type encryptionOption struct {
+	Value qrcode.Encryption
+	Label string
+}
+
+// values injected into the page template
+type pageData struct {
+	Encryptions   []encryptionOption
+	EAPMethods    []qrcode.EAPMethod
+	Phase2Methods []qrcode.Phase2Method
+	ECLs          []string
+	DefaultECL    string
+}
+
+// parses pages, which are executed by file name
+func parsePage(a assets) (*template.Template, error) {
+	return template.New("index.html").Funcs(template.FuncMap{"asset": a.path}).ParseFS(webFS, "web/*.html")
+}
+
+func (s *server) indexHandler(w http.ResponseWriter, r *http.Request) {
+	data := pageData{
+		Encryptions: []encryptionOption{
+			{Value: qrcode.NoPass, Label: "open"},
+			{Value: qrcode.WEP, Label: "WEP"},
+			{Value: qrcode.WPA, Label: "WPAx"},
+			{Value: qrcode.WPA2EAP, Label: "WPA2-EAP"},
+		},
+		EAPMethods:    []qrcode.EAPMethod{qrcode.PEAP, qrcode.TTLS, qrcode.PWD, qrcode.SIM, qrcode.AKA, qrcode.AKAPrime},
+		Phase2Methods: []qrcode.Phase2Method{qrcode.NoPhase2, qrcode.MSCHAPV2Phase2, qrcode.MSCHAPPhase2, qrcode.PAPPhase2, qrcode.GTCPhase2},
+		ECLs:          []string{"L", "M", "Q", "H"},
+		DefaultECL:    s.config.DefaultECL.ToString(),
+	}
+
+	buf := new(bytes.Buffer)
+	err := s.page.ExecuteTemplate(buf, "index.html", data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	// page refers to assets by hash, so that it must be revalidated to pick up new ones
+	w.Header().Set("Cache-Control", "no-cache")
+	_, _ = buf.WriteTo(w)
+}
+
+func (s *server) staticHandler(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	a, ok := s.assets.files[name]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", mime.TypeByExtension(path.Ext(name)))
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	w.Header().Set("ETag", `"`+a.hash+`"`)
+	http.ServeContent(w, r, name, time.Time{}, bytes.NewReader(a.data))
+}
@@ -0,0 +1,64 @@
+// previews codes while the form is filled, posting it to data-post and replacing inner HTML of data-target
+// data-trigger lists events separated by commas, each as "event delay:<ms>ms" or "event from:<selector>"
+(function () {
+  "use strict";
+
+  function parseTriggers(spec) {
+    return spec.split(",").map(function (part) {
+      var tokens = part.trim().split(/\s+/);
+      var trigger = { event: tokens[0], delay: 0, from: null };
+      for (var i = 1; i < tokens.length; i++) {
+        if (tokens[i].indexOf("delay:") === 0) {
+          trigger.delay = parseInt(tokens[i].slice("delay:".length), 10) || 0;
+        } else if (tokens[i].indexOf("from:") === 0) {
+          // selectors may contain spaces, so that the rest of the part is taken
+          trigger.from = tokens.slice(i).join(" ").slice("from:".length);
+          break;
+        }
+      }
+      return trigger;
+    });
+  }
+
+  function bind(form) {
+    var target = document.querySelector(form.getAttribute("data-target")) || form;
+    var timer = null;
+    var controller = null;
+
+    function send() {
+      // responses of older requests must not replace newer ones
+      if (controller) {
+        controller.abort();
+      }
+      controller = new AbortController();
+      fetch(form.getAttribute("data-post"), {
+        method: "POST",
+        body: new URLSearchParams(new FormData(form)),
+        signal: controller.signal,
+      })
+        .then(function (res) {
+          return res.ok ? res.text() : null;
+        })
+        .then(function (html) {
+          if (html !== null) {
+            target.innerHTML = html;
+          }
+        })
+        .catch(function () {});
+    }
+
+    parseTriggers(form.getAttribute("data-trigger") || "submit").forEach(function (trigger) {
+      var sources = trigger.from ? document.querySelectorAll(trigger.from) : [form];
+      Array.prototype.forEach.call(sources, function (source) {
+        source.addEventListener(trigger.event, function () {
+          clearTimeout(timer);
+          timer = setTimeout(send, trigger.delay);
+        });
+      });
+    });
+  }
+
+  document.addEventListener("DOMContentLoaded", function () {
+    Array.prototype.forEach.call(document.querySelectorAll("form[data-post]"), bind);
+  });
+})();
@@ -0,0 +1,34 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+)
+
+func TestIndexHandler(t *testing.T) {
+	s := newTestServer(t, testConfig())
+	handler := s.handler()
+
+	page := httptest.NewRecorder()
+	handler.ServeHTTP(page, httptest.NewRequest("GET", "/", nil))
+	if page.Code != http.StatusOK {
+		t.Fatalf("GET / status = %d; expected %d", page.Code, http.StatusOK)
+	}
+
+	// every asset referred by the page is served with its hashed name
+	links := regexp.MustCompile(`(?:href|src)="(static/[^"]+)"`).FindAllStringSubmatch(page.Body.String(), -1)
+	if len(links) != len(s.assets.files) {
+		t.Errorf("page refers to %d assets; expected %d", len(links), len(s.assets.files))
+	}
+	for _, link := range links {
+		t.Run("testing staticHandler() for "+link[1], func(t *testing.T) {
+			res := httptest.NewRecorder()
+			handler.ServeHTTP(res, httptest.NewRequest("GET", "/"+link[1], nil))
+			if res.Code != http.StatusOK || res.Header().Get("ETag") == "" {
+				t.Errorf("GET /%s status = %d, ETag = %q", link[1], res.Code, res.Header().Get("ETag"))
+			}
+		})
+	}
+}
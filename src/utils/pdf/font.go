@@ -0,0 +1,133 @@
+package pdf
+
+import (
+	_ "embed"
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"strings"
+)
+
+// font embedded as subset into documents, so that text prints the same without fonts installed
+// named by PostScript name of its font program
+type Font string
+
+const (
+	Sans     Font = "DejaVuSans"
+	SansBold Font = "DejaVuSans-Bold"
+)
+
+// fonts in the order of resource names
+var fontList = []Font{Sans, SansBold}
+
+// DejaVu Sans, licensed under fonts/LICENSE
+var (
+	//go:embed fonts/DejaVuSans.ttf
+	sansProgram []byte
+	//go:embed fonts/DejaVuSans-Bold.ttf
+	sansBoldProgram []byte
+)
+
+var fontPrograms = map[Font]*trueType{
+	Sans:     mustParseTrueType(sansProgram),
+	SansBold: mustParseTrueType(sansBoldProgram),
+}
+
+func (f Font) resourceName() string {
+	switch f {
+	case SansBold:
+		return "F2"
+	default:
+		return "F1"
+	}
+}
+
+// character codes of WinAnsiEncoding, which are drawn as Latin-1 characters by encodeWinAnsi
+const (
+	firstChar byte = 0x20
+	lastChar  byte = 0xff
+)
+
+// writes simple TrueType font with subset of its program covering the given character codes
+// referenced: PDF Reference 1.4, Section 5.5.2 and 5.8
+func (f Font) write(ow *objectWriter, codes []byte) (int, error) {
+	tt := fontPrograms[f]
+	chars := make([]rune, 0, len(codes))
+	for _, c := range codes {
+		chars = append(chars, rune(c))
+	}
+	program := tt.subset(chars)
+	compressed, err := compress(program)
+	if err != nil {
+		return 0, err
+	}
+	programID := ow.add(streamWithDict(fmt.Sprintf("/Length1 %d /Filter /FlateDecode", len(program)), compressed))
+
+	// subsets are named with a tag of 6 uppercase letters, distinguishing different subsets of the same font
+	name := subsetTag(codes) + "+" + string(f)
+	descriptorID := ow.add(fmt.Sprintf(
+		"<< /Type /FontDescriptor /FontName /%s /Flags 32 /FontBBox [%d %d %d %d] /ItalicAngle 0 /Ascent %d /Descent %d /CapHeight %d /StemV %d /FontFile2 %d 0 R >>",
+		name, tt.scale(tt.bbox[0]), tt.scale(tt.bbox[1]), tt.scale(tt.bbox[2]), tt.scale(tt.bbox[3]),
+		tt.scale(tt.ascent), tt.scale(tt.descent), tt.scale(tt.capHeight()), stemV(tt.weight), programID,
+	))
+
+	widths := make([]string, 0, int(lastChar-firstChar)+1)
+	for c := int(firstChar); c <= int(lastChar); c++ {
+		widths = append(widths, fmt.Sprint(tt.width(rune(c))))
+	}
+	return ow.add(fmt.Sprintf(
+		"<< /Type /Font /Subtype /TrueType /BaseFont /%s /FirstChar %d /LastChar %d /Widths [%s] /Encoding /WinAnsiEncoding /FontDescriptor %d 0 R >>",
+		name, firstChar, lastChar, strings.Join(widths, " "), descriptorID,
+	)), nil
+}
+
+func subsetTag(codes []byte) string {
+	h := fnv.New32a()
+	h.Write(codes)
+	sum := h.Sum32()
+	tag := make([]byte, 6)
+	for i := range tag {
+		tag[i] = 'A' + byte(sum%26)
+		sum /= 26
+	}
+	return string(tag)
+}
+
+// estimates dominant vertical stem width from weight class, as TrueType fonts do not have it
+func stemV(weight int) int {
+	return 50 + weight*weight/4225 // 50 + (weight / 65)^2
+}
+
+// returns sorted character codes drawn in the font
+func usedCodes(used map[byte]bool) []byte {
+	codes := make([]byte, 0, len(used))
+	for c := range used {
+		codes = append(codes, c)
+	}
+	slices.Sort(codes)
+	return codes
+}
+
+// width of text in points when drawn with the given font size
+func TextWidth(font Font, size float64, text string) float64 {
+	tt := fontPrograms[font]
+	total := 0
+	for _, b := range []byte(encodeWinAnsi(text)) {
+		total += tt.width(rune(b))
+	}
+	return float64(total) * size / 1000
+}
+
+// converts text into WinAnsiEncoding, replacing unsupported characters
+// Latin-1 characters map directly, except for the range reserved for C1 controls
+func encodeWinAnsi(text string) string {
+	encoded := make([]byte, 0, len(text))
+	for _, r := range text {
+		if r < 0x20 || (r >= 0x7f && r < 0xa0) || r > 0xff {
+			encoded = append(encoded, '?')
+			continue
+		}
+		encoded = append(encoded, byte(r))
+	}
+	return string(encoded)
+}
@@ -0,0 +1,93 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+)
+
+// raster image, which can be drawn on multiple pages while stored once
+type Image struct {
+	name   string
+	width  int
+	height int
+	rgb    []byte // compressed 8 bit RGB samples
+	alpha  []byte // compressed 8 bit alpha samples, nil if opaque
+}
+
+func (d *Document) AddImage(img image.Image) (*Image, error) {
+	bounds := img.Bounds()
+	rgb := make([]byte, 0, bounds.Dx()*bounds.Dy()*3)
+	alpha := make([]byte, 0, bounds.Dx()*bounds.Dy())
+	opaque := true
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, a := img.At(x, y).RGBA()
+			// un-premultiply alpha, as PDF expects straight color with soft mask
+			if a > 0 && a < 0xffff {
+				r, g, b = r*0xffff/a, g*0xffff/a, b*0xffff/a
+			}
+			rgb = append(rgb, byte(r>>8), byte(g>>8), byte(b>>8))
+			alpha = append(alpha, byte(a>>8))
+			opaque = opaque && a == 0xffff
+		}
+	}
+
+	compressedRGB, err := compress(rgb)
+	if err != nil {
+		return nil, err
+	}
+	im := &Image{
+		name:   fmt.Sprintf("Im%d", len(d.images)+1),
+		width:  bounds.Dx(),
+		height: bounds.Dy(),
+		rgb:    compressedRGB,
+	}
+	if !opaque {
+		im.alpha, err = compress(alpha)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	d.images = append(d.images, im)
+	return im, nil
+}
+
+// draws image scaled into the given rectangle, with origin at lower left corner
+func (p *Page) DrawImage(im *Image, x, y, w, h float64) {
+	p.images[im] = true
+	fmt.Fprintf(&p.content, "q %s 0 0 %s %s %s cm /%s Do Q\n", num(w), num(h), num(x), num(y), im.name)
+}
+
+// writes image as XObject, returning its object number
+func (im *Image) write(ow *objectWriter) int {
+	smask := ""
+	if im.alpha != nil {
+		maskID := ow.add(streamWithDict(fmt.Sprintf(
+			"/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceGray /BitsPerComponent 8 /Filter /FlateDecode",
+			im.width, im.height,
+		), im.alpha))
+		smask = fmt.Sprintf(" /SMask %d 0 R", maskID)
+	}
+
+	return ow.add(streamWithDict(fmt.Sprintf(
+		"/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /FlateDecode%s",
+		im.width, im.height, smask,
+	), im.rgb))
+}
+
+func compress(data []byte) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	zw := zlib.NewWriter(buf)
+	_, err := zw.Write(data)
+	if err != nil {
+		return nil, err
+	}
+	err = zw.Close()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,334 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"image/color"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// minimal PDF 1.4 writer, only supporting what is needed to draw QR codes
+// referenced: https://opensource.adobe.com/dc-acrobat-sdk-docs/pdfstandards/pdfreference1.4.pdf
+type Document struct {
+	pages  []*Page
+	images []*Image
+}
+
+type Page struct {
+	Width    float64 // in points, 1/72 inch
+	Height   float64 // in points, 1/72 inch
+	TrimBox  *Box    // final page size after trimming, if differs from media size
+	BleedBox *Box    // area to which contents are clipped when printed, if differs from media size
+	content  bytes.Buffer
+	fonts    map[Font]map[byte]bool // character codes drawn in each font
+	images   map[*Image]bool
+	shadings []Shading
+}
+
+// smooth color transition between 2 colors, extended beyond both ends
+type Shading struct {
+	Radial bool
+	Coords []float64 // x0 y0 x1 y1 for axial shading, or x0 y0 r0 x1 y1 r1 for radial shading
+	From   color.Color
+	To     color.Color
+}
+
+// rectangle in points, with origin at lower left corner
+type Box struct {
+	X      float64
+	Y      float64
+	Width  float64
+	Height float64
+}
+
+func NewDocument() *Document {
+	return &Document{}
+}
+
+func (d *Document) AddPage(width float64, height float64) *Page {
+	page := &Page{
+		Width:  width,
+		Height: height,
+		fonts:  make(map[Font]map[byte]bool),
+		images: make(map[*Image]bool),
+	}
+	d.pages = append(d.pages, page)
+	return page
+}
+
+// sets non-stroking color used by Fill
+func (p *Page) SetFillColor(c color.Color) {
+	r, g, b := rgb(c)
+	fmt.Fprintf(&p.content, "%s %s %s rg\n", num(r), num(g), num(b))
+}
+
+// appends a rectangle to the current path, with origin at lower left corner
+func (p *Page) Rect(x, y, w, h float64) {
+	fmt.Fprintf(&p.content, "%s %s %s %s re\n", num(x), num(y), num(w), num(h))
+}
+
+// fills the current path with non-zero winding rule
+func (p *Page) Fill() {
+	p.content.WriteString("f\n")
+}
+
+// sets stroking color used by Stroke
+func (p *Page) SetStrokeColor(c color.Color) {
+	r, g, b := rgb(c)
+	fmt.Fprintf(&p.content, "%s %s %s RG\n", num(r), num(g), num(b))
+}
+
+func (p *Page) SetLineWidth(w float64) {
+	fmt.Fprintf(&p.content, "%s w\n", num(w))
+}
+
+// appends a straight line to the current path
+func (p *Page) Line(x1, y1, x2, y2 float64) {
+	fmt.Fprintf(&p.content, "%s %s m %s %s l\n", num(x1), num(y1), num(x2), num(y2))
+}
+
+// saves graphics state, such as colors and transformation, to be restored later
+func (p *Page) SaveState() {
+	p.content.WriteString("q\n")
+}
+
+func (p *Page) RestoreState() {
+	p.content.WriteString("Q\n")
+}
+
+// concatenates matrix [a b c d e f] to the current transformation matrix
+func (p *Page) Transform(a, b, c, d, e, f float64) {
+	fmt.Fprintf(&p.content, "%s %s %s %s %s %s cm\n", num(a), num(b), num(c), num(d), num(e), num(f))
+}
+
+// intersects clipping area with the current path, and ends the path without painting
+func (p *Page) Clip() {
+	p.content.WriteString("W n\n")
+}
+
+// paints shading over the clipping area
+func (p *Page) Shade(s Shading) {
+	fmt.Fprintf(&p.content, "/Sh%d sh\n", len(p.shadings))
+	p.shadings = append(p.shadings, s)
+}
+
+// strokes the current path
+func (p *Page) Stroke() {
+	p.content.WriteString("S\n")
+}
+
+// draws text with its baseline starting from the given position, using fill color
+func (p *Page) Text(x, y float64, font Font, size float64, text string) {
+	encoded := encodeWinAnsi(text)
+	if p.fonts[font] == nil {
+		p.fonts[font] = make(map[byte]bool)
+	}
+	for i := range len(encoded) {
+		p.fonts[font][encoded[i]] = true
+	}
+	fmt.Fprintf(&p.content, "BT /%s %s Tf %s %s Td (%s) Tj ET\n",
+		font.resourceName(), num(size), num(x), num(y), escapeString(encoded),
+	)
+}
+
+func (d *Document) Write(w io.Writer) error {
+	if len(d.pages) == 0 {
+		return fmt.Errorf("document must have at least 1 page")
+	}
+
+	ow := newObjectWriter()
+	catalogID := ow.reserve()
+	pagesID := ow.reserve()
+
+	// fonts are embedded once for all pages, covering characters drawn on any of them
+	fontCodes := make(map[Font]map[byte]bool)
+	for _, page := range d.pages {
+		for font, codes := range page.fonts {
+			if fontCodes[font] == nil {
+				fontCodes[font] = make(map[byte]bool)
+			}
+			for c := range codes {
+				fontCodes[font][c] = true
+			}
+		}
+	}
+
+	fontIDs := make(map[Font]int)
+	imageIDs := make(map[*Image]int)
+	pageIDs := make([]int, 0, len(d.pages))
+	for _, page := range d.pages {
+		fonts := new(bytes.Buffer)
+		for _, font := range fontList {
+			if page.fonts[font] == nil {
+				continue
+			}
+			if _, exists := fontIDs[font]; !exists {
+				id, err := font.write(ow, usedCodes(fontCodes[font]))
+				if err != nil {
+					return err
+				}
+				fontIDs[font] = id
+			}
+			fmt.Fprintf(fonts, "/%s %d 0 R ", font.resourceName(), fontIDs[font])
+		}
+		xObjects := new(bytes.Buffer)
+		for _, im := range d.images {
+			if !page.images[im] {
+				continue
+			}
+			if _, exists := imageIDs[im]; !exists {
+				imageIDs[im] = im.write(ow)
+			}
+			fmt.Fprintf(xObjects, "/%s %d 0 R ", im.name, imageIDs[im])
+		}
+		shadings := new(bytes.Buffer)
+		for i, sh := range page.shadings {
+			fmt.Fprintf(shadings, "/Sh%d %s ", i, sh.object())
+		}
+
+		contentID := ow.add(stream(page.content.Bytes()))
+		pageIDs = append(pageIDs, ow.add(fmt.Sprintf(
+			"<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %s %s]%s /Contents %d 0 R /Resources << /Font << %s>> /XObject << %s>> /Shading << %s>> >> >>",
+			pagesID, num(page.Width), num(page.Height), page.boxes(), contentID, fonts.String(), xObjects.String(), shadings.String(),
+		)))
+	}
+
+	ow.set(catalogID, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesID))
+	ow.set(pagesID, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", refs(pageIDs), len(pageIDs)))
+
+	return ow.write(w, catalogID)
+}
+
+func (p *Page) boxes() string {
+	boxes := ""
+	if p.TrimBox != nil {
+		boxes += " /TrimBox " + p.TrimBox.array()
+	}
+	if p.BleedBox != nil {
+		boxes += " /BleedBox " + p.BleedBox.array()
+	}
+	return boxes
+}
+
+func (b Box) array() string {
+	return fmt.Sprintf("[%s %s %s %s]", num(b.X), num(b.Y), num(b.X+b.Width), num(b.Y+b.Height))
+}
+
+// shading dictionary with exponential interpolation function, written directly in resources
+func (s Shading) object() string {
+	shadingType := 2
+	if s.Radial {
+		shadingType = 3
+	}
+	coords := make([]string, 0, len(s.Coords))
+	for _, c := range s.Coords {
+		coords = append(coords, num(c))
+	}
+	r0, g0, b0 := rgb(s.From)
+	r1, g1, b1 := rgb(s.To)
+	return fmt.Sprintf(
+		"<< /ShadingType %d /ColorSpace /DeviceRGB /Coords [%s] /Function << /FunctionType 2 /Domain [0 1] /C0 [%s %s %s] /C1 [%s %s %s] /N 1 >> /Extend [true true] >>",
+		shadingType, strings.Join(coords, " "), num(r0), num(g0), num(b0), num(r1), num(g1), num(b1),
+	)
+}
+
+type objectWriter struct {
+	objects []string
+}
+
+func newObjectWriter() *objectWriter {
+	return &objectWriter{}
+}
+
+// reserves an object number, to be set later for forward references
+func (ow *objectWriter) reserve() int {
+	ow.objects = append(ow.objects, "")
+	return len(ow.objects)
+}
+
+func (ow *objectWriter) set(id int, obj string) {
+	ow.objects[id-1] = obj
+}
+
+func (ow *objectWriter) add(obj string) int {
+	id := ow.reserve()
+	ow.set(id, obj)
+	return id
+}
+
+func (ow *objectWriter) write(w io.Writer, rootID int) error {
+	buf := new(bytes.Buffer)
+	// binary comment marks the file as containing binary data
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	offsets := make([]int, 0, len(ow.objects))
+	for i, obj := range ow.objects {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+
+	xrefOffset := buf.Len()
+	fmt.Fprintf(buf, "xref\n0 %d\n0000000000 65535 f \n", len(ow.objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(buf, "trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(ow.objects)+1, rootID, xrefOffset)
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func stream(data []byte) string {
+	return streamWithDict("", data)
+}
+
+// stream with additional dictionary entries, such as filters
+func streamWithDict(dict string, data []byte) string {
+	if dict != "" {
+		dict += " "
+	}
+	return fmt.Sprintf("<< %s/Length %d >>\nstream\n%s\nendstream", dict, len(data), data)
+}
+
+// escapes characters with special meaning in literal strings
+func escapeString(s string) string {
+	buf := new(bytes.Buffer)
+	for i := range len(s) {
+		switch s[i] {
+		case '(', ')', '\\':
+			buf.WriteByte('\\')
+		}
+		buf.WriteByte(s[i])
+	}
+	return buf.String()
+}
+
+func refs(ids []int) string {
+	buf := new(bytes.Buffer)
+	for i, id := range ids {
+		if i > 0 {
+			buf.WriteString(" ")
+		}
+		fmt.Fprintf(buf, "%d 0 R", id)
+	}
+	return buf.String()
+}
+
+// formats number with up to 4 decimals, as PDF does not accept exponents
+func num(f float64) string {
+	s := strconv.FormatFloat(f, 'f', 4, 64)
+	s = strings.TrimRight(s, "0")
+	s = strings.TrimSuffix(s, ".")
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}
+
+// converts color into PDF color components, ranging from 0 to 1
+func rgb(c color.Color) (float64, float64, float64) {
+	r, g, b, _ := c.RGBA()
+	return float64(r) / 0xffff, float64(g) / 0xffff, float64(b) / 0xffff
+}
@@ -0,0 +1,106 @@
+package pdf
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNum(t *testing.T) {
+	testcases := []struct {
+		f    float64
+		want string
+	}{
+		{f: 12, want: "12"},
+		{f: 0.1 + 0.2, want: "0.3"},
+		{f: 5.66929, want: "5.6693"},
+		{f: -0.00001, want: "0"},
+	}
+
+	for _, tt := range testcases {
+		t.Run("testing num()", func(t *testing.T) {
+			if got := num(tt.f); got != tt.want {
+				t.Errorf("num(%v) = %v; expected %v", tt.f, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEscapeString(t *testing.T) {
+	testcases := []struct {
+		s    string
+		want string
+	}{
+		{s: "Guest (5G)", want: `Guest \(5G\)`},
+		{s: `back\slash`, want: `back\\slash`},
+	}
+
+	for _, tt := range testcases {
+		t.Run("testing escapeString()", func(t *testing.T) {
+			if got := escapeString(tt.s); got != tt.want {
+				t.Errorf("escapeString(%v) = %v; expected %v", tt.s, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTextWidth(t *testing.T) {
+	testcases := []struct {
+		font Font
+		size float64
+		text string
+		want float64
+	}{
+		{font: Sans, size: 10, text: "Wi-Fi", want: 24.77},
+		{font: SansBold, size: 10, text: "Wi-Fi", want: 28.85},
+	}
+
+	for _, tt := range testcases {
+		t.Run("testing TextWidth()", func(t *testing.T) {
+			if got := TextWidth(tt.font, tt.size, tt.text); got < tt.want-0.001 || got > tt.want+0.001 {
+				t.Errorf("TextWidth(%v) = %v; expected %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDocumentWrite(t *testing.T) {
+	testcases := []struct {
+		pages    int
+		contains []string
+		wantErr  error
+	}{
+		{
+			pages:    0,
+			contains: []string{},
+			wantErr:  errors.New("document must have at least 1 page"),
+		},
+		{
+			pages:    2,
+			contains: []string{"%PDF-1.4", "/Count 2", "+DejaVuSans /FirstChar 32", "/FontFile2 ", "%%EOF"},
+			wantErr:  nil,
+		},
+	}
+
+	for _, tt := range testcases {
+		t.Run("testing Document.Write()", func(t *testing.T) {
+			doc := NewDocument()
+			for range tt.pages {
+				page := doc.AddPage(100, 100)
+				page.Text(10, 10, Sans, 12, "SSID")
+			}
+
+			buf := new(bytes.Buffer)
+			err := doc.Write(buf)
+			if err != nil && err.Error() != tt.wantErr.Error() {
+				t.Errorf("Document.Write() error = '%v'; expected '%v'", err, tt.wantErr)
+			}
+			for _, s := range tt.contains {
+				if !strings.Contains(buf.String(), s) {
+					t.Errorf("Document.Write() output does not contain %v", s)
+				}
+			}
+		})
+	}
+}
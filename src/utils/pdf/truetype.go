@@ -0,0 +1,408 @@
+package pdf
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+	"slices"
+)
+
+// TrueType font program, read only as far as needed to measure text and embed subsets
+// referenced: https://learn.microsoft.com/en-us/typography/opentype/spec/otff
+type trueType struct {
+	tables     map[string][]byte
+	unitsPerEm int
+	bbox       [4]int // xMin, yMin, xMax, yMax in font units
+	ascent     int
+	descent    int
+	weight     int // weight class, such as 400 for regular and 700 for bold
+	numGlyphs  int
+	longLoca   bool // loca holds 32 bit offsets, instead of 16 bit offsets divided by 2
+	numMetrics int  // glyphs with their own advance width, where the rest share the last one
+	cmap       []byte
+}
+
+// tables kept in embedded subsets, which are required by PDF for TrueType font programs, and OS/2 for metrics
+// referenced: PDF Reference 1.4, Section 5.8
+var subsetTables = []string{"OS/2", "cmap", "cvt ", "fpgm", "glyf", "head", "hhea", "hmtx", "loca", "maxp", "prep"}
+
+func parseTrueType(data []byte) (*trueType, error) {
+	if len(data) < 12 {
+		return nil, fmt.Errorf("font is shorter than its header")
+	}
+	numTables := int(binary.BigEndian.Uint16(data[4:]))
+	tables := make(map[string][]byte, numTables)
+	for i := range numTables {
+		record := 12 + 16*i
+		if record+16 > len(data) {
+			return nil, fmt.Errorf("font is shorter than its table directory")
+		}
+		offset := int(binary.BigEndian.Uint32(data[record+8:]))
+		length := int(binary.BigEndian.Uint32(data[record+12:]))
+		if offset+length > len(data) {
+			return nil, fmt.Errorf("table %s is out of font", data[record:record+4])
+		}
+		tables[string(data[record:record+4])] = data[offset : offset+length]
+	}
+	for _, tag := range []string{"cmap", "glyf", "head", "hhea", "hmtx", "loca", "maxp", "OS/2"} {
+		if _, exists := tables[tag]; !exists {
+			return nil, fmt.Errorf("font has no %s table", tag)
+		}
+	}
+
+	head, hhea, maxp, os2 := tables["head"], tables["hhea"], tables["maxp"], tables["OS/2"]
+	if len(head) < 54 || len(hhea) < 36 || len(maxp) < 6 || len(os2) < 6 {
+		return nil, fmt.Errorf("font has truncated tables")
+	}
+	tt := &trueType{
+		tables:     tables,
+		unitsPerEm: int(binary.BigEndian.Uint16(head[18:])),
+		bbox: [4]int{
+			int(int16(binary.BigEndian.Uint16(head[36:]))), //nolint:gosec // signed fields
+			int(int16(binary.BigEndian.Uint16(head[38:]))), //nolint:gosec // signed fields
+			int(int16(binary.BigEndian.Uint16(head[40:]))), //nolint:gosec // signed fields
+			int(int16(binary.BigEndian.Uint16(head[42:]))), //nolint:gosec // signed fields
+		},
+		longLoca:   binary.BigEndian.Uint16(head[50:]) == 1,
+		ascent:     int(int16(binary.BigEndian.Uint16(hhea[4:]))), //nolint:gosec // signed field
+		descent:    int(int16(binary.BigEndian.Uint16(hhea[6:]))), //nolint:gosec // signed field
+		numMetrics: int(binary.BigEndian.Uint16(hhea[34:])),
+		numGlyphs:  int(binary.BigEndian.Uint16(maxp[4:])),
+		weight:     int(binary.BigEndian.Uint16(os2[4:])),
+	}
+	if tt.unitsPerEm == 0 || tt.numMetrics == 0 || len(tables["hmtx"]) < 4*tt.numMetrics+2*(tt.numGlyphs-tt.numMetrics) {
+		return nil, fmt.Errorf("font has invalid metrics")
+	}
+	locaSize := 2
+	if tt.longLoca {
+		locaSize = 4
+	}
+	if len(tables["loca"]) < locaSize*(tt.numGlyphs+1) {
+		return nil, fmt.Errorf("font has truncated loca table")
+	}
+
+	// encoding of Unicode BMP for Windows, which maps characters of WinAnsiEncoding
+	cmap := tables["cmap"]
+	if len(cmap) < 4 {
+		return nil, fmt.Errorf("font has truncated cmap table")
+	}
+	for i := range int(binary.BigEndian.Uint16(cmap[2:])) {
+		record := 4 + 8*i
+		if record+8 > len(cmap) {
+			break
+		}
+		platform, encoding := binary.BigEndian.Uint16(cmap[record:]), binary.BigEndian.Uint16(cmap[record+2:])
+		offset := int(binary.BigEndian.Uint32(cmap[record+4:]))
+		if platform == 3 && encoding == 1 && offset+14 <= len(cmap) && binary.BigEndian.Uint16(cmap[offset:]) == 4 {
+			tt.cmap = cmap[offset:]
+		}
+	}
+	if tt.cmap == nil {
+		return nil, fmt.Errorf("font has no Unicode BMP cmap of format 4")
+	}
+	return tt, nil
+}
+
+func mustParseTrueType(data []byte) *trueType {
+	tt, err := parseTrueType(data)
+	if err != nil {
+		panic(err)
+	}
+	return tt
+}
+
+// returns glyph of character by cmap of format 4, or 0 for missing glyph
+func (tt *trueType) glyph(r rune) int {
+	if r < 0 || r > 0xffff {
+		return 0
+	}
+	c := int(r)
+	segCount := int(binary.BigEndian.Uint16(tt.cmap[6:])) / 2
+	endCodes := 14
+	startCodes := endCodes + 2*segCount + 2 // after reserved padding
+	idDeltas := startCodes + 2*segCount
+	idRangeOffsets := idDeltas + 2*segCount
+	if idRangeOffsets+2*segCount > len(tt.cmap) {
+		return 0
+	}
+	for i := range segCount {
+		if int(binary.BigEndian.Uint16(tt.cmap[endCodes+2*i:])) < c {
+			continue
+		}
+		start := int(binary.BigEndian.Uint16(tt.cmap[startCodes+2*i:]))
+		if start > c {
+			return 0
+		}
+		delta := int(binary.BigEndian.Uint16(tt.cmap[idDeltas+2*i:]))
+		rangeOffset := int(binary.BigEndian.Uint16(tt.cmap[idRangeOffsets+2*i:]))
+		if rangeOffset == 0 {
+			return (c + delta) & 0xffff
+		}
+		// offset is relative to the position of itself in idRangeOffset array
+		idx := idRangeOffsets + 2*i + rangeOffset + 2*(c-start)
+		if idx+2 > len(tt.cmap) {
+			return 0
+		}
+		g := int(binary.BigEndian.Uint16(tt.cmap[idx:]))
+		if g == 0 {
+			return 0
+		}
+		return (g + delta) & 0xffff
+	}
+	return 0
+}
+
+// returns advance width of glyph in font units
+func (tt *trueType) advance(g int) int {
+	g = min(g, tt.numMetrics-1)
+	return int(binary.BigEndian.Uint16(tt.tables["hmtx"][4*g:]))
+}
+
+// returns advance width of character in 1/1000 of font size
+func (tt *trueType) width(r rune) int {
+	return tt.advance(tt.glyph(r)) * 1000 / tt.unitsPerEm
+}
+
+// scales font units to 1/1000 of font size, as used by font descriptors
+func (tt *trueType) scale(v int) int {
+	return v * 1000 / tt.unitsPerEm
+}
+
+// returns data of glyph, which is empty for glyphs without outline such as space
+func (tt *trueType) glyphData(g int) []byte {
+	if g < 0 || g >= tt.numGlyphs {
+		return nil
+	}
+	loca, glyf := tt.tables["loca"], tt.tables["glyf"]
+	var start, end int
+	if tt.longLoca {
+		start, end = int(binary.BigEndian.Uint32(loca[4*g:])), int(binary.BigEndian.Uint32(loca[4*g+4:]))
+	} else {
+		start, end = 2*int(binary.BigEndian.Uint16(loca[2*g:])), 2*int(binary.BigEndian.Uint16(loca[2*g+2:]))
+	}
+	if start >= end || end > len(glyf) {
+		return nil
+	}
+	return glyf[start:end]
+}
+
+// returns height of capital letters in font units, from outline of H
+// as OS/2 tables before version 2 do not have it
+func (tt *trueType) capHeight() int {
+	data := tt.glyphData(tt.glyph('H'))
+	if len(data) < 10 {
+		return tt.ascent
+	}
+	return int(int16(binary.BigEndian.Uint16(data[8:]))) //nolint:gosec // signed field
+}
+
+// flags of composite glyph components
+const (
+	argsAreWords   uint16 = 0x0001
+	haveScale      uint16 = 0x0008
+	moreComponents uint16 = 0x0020
+	haveXYScale    uint16 = 0x0040
+	haveTwoByTwo   uint16 = 0x0080
+)
+
+// returns glyphs referenced as components by composite glyph
+func (tt *trueType) components(g int) []int {
+	data := tt.glyphData(g)
+	// composite glyphs have negative number of contours
+	if len(data) < 10 || int16(binary.BigEndian.Uint16(data)) >= 0 { //nolint:gosec // signed field
+		return nil
+	}
+	var components []int
+	for pos := 10; pos+4 <= len(data); {
+		flags := binary.BigEndian.Uint16(data[pos:])
+		components = append(components, int(binary.BigEndian.Uint16(data[pos+2:])))
+		pos += 4
+		if flags&argsAreWords != 0 {
+			pos += 4
+		} else {
+			pos += 2
+		}
+		switch {
+		case flags&haveScale != 0:
+			pos += 2
+		case flags&haveXYScale != 0:
+			pos += 4
+		case flags&haveTwoByTwo != 0:
+			pos += 8
+		}
+		if flags&moreComponents == 0 {
+			break
+		}
+	}
+	return components
+}
+
+// returns font program only keeping outlines of the given characters and the missing glyph,
+// where glyph numbers are kept so that other glyphs are left empty
+func (tt *trueType) subset(chars []rune) []byte {
+	used := map[int]bool{0: true}
+	queue := make([]int, 0, len(chars))
+	for _, c := range chars {
+		queue = append(queue, tt.glyph(c))
+	}
+	for len(queue) > 0 {
+		g := queue[0]
+		queue = queue[1:]
+		if used[g] {
+			continue
+		}
+		used[g] = true
+		queue = append(queue, tt.components(g)...)
+	}
+
+	// outlines of used glyphs, with offsets in the format of the original loca table
+	glyf := make([]byte, 0)
+	loca := make([]byte, 0)
+	appendOffset := func() {
+		if tt.longLoca {
+			loca = binary.BigEndian.AppendUint32(loca, uint32(len(glyf))) //nolint:gosec // bounded by original glyf
+		} else {
+			loca = binary.BigEndian.AppendUint16(loca, uint16(len(glyf)/2)) //nolint:gosec // bounded by original glyf
+		}
+	}
+	// metrics of unused glyphs are cleared, so that compression removes them
+	hmtx := make([]byte, len(tt.tables["hmtx"]))
+	for g := range tt.numGlyphs {
+		appendOffset()
+		if !used[g] {
+			continue
+		}
+		glyf = append(glyf, tt.glyphData(g)...)
+		// glyphs are aligned to 4 bytes, which also keeps offsets of short loca even
+		for len(glyf)%4 != 0 {
+			glyf = append(glyf, 0)
+		}
+		if g < tt.numMetrics {
+			copy(hmtx[4*g:4*g+4], tt.tables["hmtx"][4*g:])
+		} else {
+			pos := 4*tt.numMetrics + 2*(g-tt.numMetrics)
+			copy(hmtx[pos:pos+2], tt.tables["hmtx"][pos:])
+		}
+	}
+	appendOffset()
+
+	tables := map[string][]byte{
+		"cmap": tt.subsetCMap(chars),
+		"glyf": glyf,
+		"hmtx": hmtx,
+		"loca": loca,
+		"head": slices.Clone(tt.tables["head"]),
+	}
+	for _, tag := range subsetTables {
+		if _, exists := tables[tag]; !exists && tt.tables[tag] != nil {
+			tables[tag] = tt.tables[tag]
+		}
+	}
+	return writeTrueType(tables)
+}
+
+// returns cmap table only mapping the given characters, by a segment for each of them
+func (tt *trueType) subsetCMap(chars []rune) []byte {
+	codes := make([]int, 0, len(chars)+1)
+	for _, c := range chars {
+		if c >= 0 && c < 0xffff {
+			codes = append(codes, int(c))
+		}
+	}
+	slices.Sort(codes)
+	codes = slices.Compact(codes)
+	codes = append(codes, 0xffff) // last segment must end with 0xFFFF
+
+	segCount := len(codes)
+	entrySelector := bits.Len(uint(segCount)) - 1
+	searchRange := 2 << entrySelector
+	sub := make([]byte, 0, 16+8*segCount)
+	sub = binary.BigEndian.AppendUint16(sub, 4)                              // format
+	sub = binary.BigEndian.AppendUint16(sub, 0)                              // length, set below
+	sub = binary.BigEndian.AppendUint16(sub, 0)                              // language
+	sub = binary.BigEndian.AppendUint16(sub, uint16(2*segCount))             //nolint:gosec // bounded by BMP
+	sub = binary.BigEndian.AppendUint16(sub, uint16(searchRange))            //nolint:gosec // bounded by BMP
+	sub = binary.BigEndian.AppendUint16(sub, uint16(entrySelector))          //nolint:gosec // bounded by BMP
+	sub = binary.BigEndian.AppendUint16(sub, uint16(2*segCount-searchRange)) //nolint:gosec // bounded by BMP
+	for _, c := range codes {
+		sub = binary.BigEndian.AppendUint16(sub, uint16(c)) //nolint:gosec // bounded by BMP
+	}
+	sub = binary.BigEndian.AppendUint16(sub, 0) // reserved padding
+	for _, c := range codes {
+		sub = binary.BigEndian.AppendUint16(sub, uint16(c)) //nolint:gosec // bounded by BMP
+	}
+	for _, c := range codes {
+		g := 0
+		if c != 0xffff {
+			g = tt.glyph(rune(c))
+		}
+		// glyph is character plus delta modulo 65536
+		sub = binary.BigEndian.AppendUint16(sub, uint16((g-c)&0xffff)) //nolint:gosec // masked
+	}
+	for range codes {
+		sub = binary.BigEndian.AppendUint16(sub, 0) // range offset
+	}
+	binary.BigEndian.PutUint16(sub[2:], uint16(len(sub))) //nolint:gosec // bounded by BMP
+
+	cmap := make([]byte, 0, 12+len(sub))
+	cmap = binary.BigEndian.AppendUint16(cmap, 0) // version
+	cmap = binary.BigEndian.AppendUint16(cmap, 1) // number of encoding records
+	cmap = binary.BigEndian.AppendUint16(cmap, 3) // Windows platform
+	cmap = binary.BigEndian.AppendUint16(cmap, 1) // Unicode BMP encoding
+	cmap = binary.BigEndian.AppendUint32(cmap, 12)
+	return append(cmap, sub...)
+}
+
+// sum of font data as big endian 32 bit integers, padded with zeros
+func checksum(data []byte) uint32 {
+	var sum uint32
+	for i := 0; i < len(data); i += 4 {
+		var word [4]byte
+		copy(word[:], data[i:])
+		sum += binary.BigEndian.Uint32(word[:])
+	}
+	return sum
+}
+
+// writes font program of tables in the order of their tags, adjusting checksum of the whole font in head table
+func writeTrueType(tables map[string][]byte) []byte {
+	tags := make([]string, 0, len(tables))
+	for tag := range tables {
+		tags = append(tags, tag)
+	}
+	slices.Sort(tags)
+
+	numTables := len(tags)
+	entrySelector := bits.Len(uint(numTables)) - 1
+	searchRange := 16 << entrySelector
+	font := make([]byte, 0)
+	font = binary.BigEndian.AppendUint32(font, 0x00010000)                       // TrueType outlines
+	font = binary.BigEndian.AppendUint16(font, uint16(numTables))                //nolint:gosec // few tables
+	font = binary.BigEndian.AppendUint16(font, uint16(searchRange))              //nolint:gosec // few tables
+	font = binary.BigEndian.AppendUint16(font, uint16(entrySelector))            //nolint:gosec // few tables
+	font = binary.BigEndian.AppendUint16(font, uint16(16*numTables-searchRange)) //nolint:gosec // few tables
+
+	offset := 12 + 16*numTables
+	headOffset := 0
+	for _, tag := range tags {
+		data := tables[tag]
+		if tag == "head" {
+			binary.BigEndian.PutUint32(data[8:], 0) // checksum adjustment is excluded from checksums
+			headOffset = offset
+		}
+		font = append(font, tag...)
+		font = binary.BigEndian.AppendUint32(font, checksum(data))
+		font = binary.BigEndian.AppendUint32(font, uint32(offset))    //nolint:gosec // bounded by original font
+		font = binary.BigEndian.AppendUint32(font, uint32(len(data))) //nolint:gosec // bounded by original font
+		offset += (len(data) + 3) &^ 3
+	}
+	for _, tag := range tags {
+		font = append(font, tables[tag]...)
+		for len(font)%4 != 0 {
+			font = append(font, 0)
+		}
+	}
+
+	binary.BigEndian.PutUint32(font[headOffset+8:], 0xb1b0afba-checksum(font))
+	return font
+}
@@ -0,0 +1,49 @@
+package pdf
+
+import (
+	"testing"
+)
+
+func TestTrueTypeSubset(t *testing.T) {
+	tt := fontPrograms[Sans]
+	// é is composed of e and acute accent
+	chars := []rune("Wi-Fi é")
+	sub := tt.subset(chars)
+
+	if got := checksum(sub); got != 0xb1b0afba {
+		t.Errorf("checksum(subset) = %#x; expected %#x", got, 0xb1b0afba)
+	}
+	got, err := parseTrueType(sub)
+	if err != nil {
+		t.Fatalf("parseTrueType(subset) error = '%v'", err)
+	}
+	if got.numGlyphs != tt.numGlyphs {
+		t.Errorf("subset has %d glyphs; expected %d", got.numGlyphs, tt.numGlyphs)
+	}
+
+	for _, c := range chars {
+		g := tt.glyph(c)
+		if got.glyph(c) != g {
+			t.Errorf("subset maps %q to glyph %d; expected %d", c, got.glyph(c), g)
+		}
+		if got.advance(g) != tt.advance(g) {
+			t.Errorf("subset has advance %d of %q; expected %d", got.advance(g), c, tt.advance(g))
+		}
+		if string(got.glyphData(g)) != string(tt.glyphData(g)) {
+			t.Errorf("subset does not keep outline of %q", c)
+		}
+	}
+	components := tt.components(tt.glyph('é'))
+	if len(components) == 0 {
+		t.Fatalf("glyph of é has no components")
+	}
+	for _, g := range components {
+		if len(got.glyphData(g)) == 0 {
+			t.Errorf("subset does not keep component glyph %d of é", g)
+		}
+	}
+
+	if got.glyph('x') != 0 || len(got.glyphData(tt.glyph('x'))) != 0 {
+		t.Errorf("subset keeps x, which is not used")
+	}
+}
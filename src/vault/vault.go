@@ -0,0 +1,294 @@
+package vault
+
+import (
+	"cmp"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pasca-l/wifi-qrcode-generator/qrcode"
+)
+
+var ErrNotFound = errors.New("network does not exist")
+
+// saved network, with fields named after the form
+type Network struct {
+	ID         string    `json:"id"`
+	Name       string    `json:"name"` // label shown to staff, such as "Guest"
+	SSID       string    `json:"ssid"`
+	Password   string    `json:"password"`
+	Encryption string    `json:"encryption"`
+	Hidden     bool      `json:"hidden"`
+	PSK        bool      `json:"psk,omitempty"` // encodes PSK derived from password
+	EAP        *EAP      `json:"eap,omitempty"`
+	UpdatedAt  time.Time `json:"updated_at"`
+
+	// password is replaced by the next one on schedule
+	Rotate       bool      `json:"rotate"`
+	NextPassword string    `json:"next_password,omitempty"`
+	RotatedAt    time.Time `json:"rotated_at,omitzero"`
+
+	// signed into share links, so that links of earlier generations are revoked
+	ShareGeneration int `json:"share_generation,omitempty"`
+}
+
+type EAP struct {
+	Method            string `json:"method"`
+	Phase2            string `json:"phase2"`
+	Identity          string `json:"identity"`
+	AnonymousIdentity string `json:"anonymous_identity"`
+}
+
+// converts network into parameters of the form
+func (n Network) Values() url.Values {
+	params := url.Values{
+		"ssid":       {n.SSID},
+		"password":   {n.Password},
+		"encryption": {n.Encryption},
+		"hidden":     {strconv.FormatBool(n.Hidden)},
+		"psk":        {strconv.FormatBool(n.PSK)},
+	}
+	if n.EAP != nil {
+		params.Set("eap_method", n.EAP.Method)
+		params.Set("phase2", n.EAP.Phase2)
+		params.Set("identity", n.EAP.Identity)
+		params.Set("anonymous_identity", n.EAP.AnonymousIdentity)
+	}
+	return params
+}
+
+func (n Network) Spec() (qrcode.WifiSpec, error) {
+	return qrcode.NewWifiSpec(n.Values())
+}
+
+// size of keys, selecting AES-256
+const KeySize int = 32
+
+// decodes base64 encoded key, such as generated by `openssl rand -base64 32`
+func ParseKey(s string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(s)
+	if err != nil || len(key) != KeySize {
+		return nil, fmt.Errorf("vault key must be %d bytes encoded in base64", KeySize)
+	}
+	return key, nil
+}
+
+// version of file layout, also bound to ciphertext as additional data
+const fileVersion int = 1
+
+// file holding networks as JSON sealed by AES-GCM, with a nonce chosen on every write
+type file struct {
+	Version    int    `json:"version"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// networks kept in memory, and written to an encrypted file on every change
+type Vault struct {
+	path string
+	aead cipher.AEAD
+
+	mu       sync.Mutex
+	networks map[string]Network
+}
+
+// opens vault file, which is created on first write if it does not exist
+func Open(path string, key []byte) (*Vault, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	v := &Vault{path: path, aead: aead, networks: make(map[string]Network)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return v, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var f file
+	err = json.Unmarshal(data, &f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: cannot decode vault: %w", path, err)
+	}
+	if f.Version != fileVersion {
+		return nil, fmt.Errorf("%s: unexpected vault version: %d", path, f.Version)
+	}
+	if len(f.Nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("%s: unexpected nonce size: %d", path, len(f.Nonce))
+	}
+	plaintext, err := aead.Open(nil, f.Nonce, f.Ciphertext, additionalData())
+	if err != nil {
+		return nil, fmt.Errorf("%s: cannot decrypt vault, as key is wrong or file is altered", path)
+	}
+	var networks []Network
+	err = json.Unmarshal(plaintext, &networks)
+	if err != nil {
+		return nil, fmt.Errorf("%s: cannot decode networks: %w", path, err)
+	}
+	for _, n := range networks {
+		v.networks[n.ID] = n
+	}
+	return v, nil
+}
+
+func additionalData() []byte {
+	return []byte("wifi-qrcode-generator vault " + strconv.Itoa(fileVersion))
+}
+
+// writes all networks to a temporary file, which replaces the vault file at once
+func (v *Vault) save() error {
+	networks := slices.SortedFunc(maps.Values(v.networks), func(a, b Network) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+	plaintext, err := json.Marshal(networks)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, v.aead.NonceSize())
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(file{
+		Version:    fileVersion,
+		Nonce:      nonce,
+		Ciphertext: v.aead.Seal(nil, nonce, plaintext, additionalData()),
+	})
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(v.path), filepath.Base(v.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(data)
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+	err = tmp.Close()
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), v.path)
+}
+
+// returns networks sorted by name
+func (v *Vault) List() []Network {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return slices.SortedFunc(maps.Values(v.networks), func(a, b Network) int {
+		return cmp.Or(cmp.Compare(a.Name, b.Name), cmp.Compare(a.ID, b.ID))
+	})
+}
+
+func (v *Vault) Get(id string) (Network, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	n, ok := v.networks[id]
+	if !ok {
+		return Network{}, ErrNotFound
+	}
+	return n, nil
+}
+
+// saves network under a new random identifier
+func (v *Vault) Create(n Network) (Network, error) {
+	b := make([]byte, 12)
+	_, err := rand.Read(b)
+	if err != nil {
+		return Network{}, err
+	}
+	n.ID = base64.RawURLEncoding.EncodeToString(b)
+	return v.put(n, false)
+}
+
+// replaces network of the identifier
+func (v *Vault) Update(id string, n Network) (Network, error) {
+	n.ID = id
+	return v.put(n, true)
+}
+
+func (v *Vault) put(n Network, exists bool) (Network, error) {
+	_, err := n.Spec()
+	if err != nil {
+		return Network{}, err
+	}
+	n.UpdatedAt = time.Now().UTC().Truncate(time.Second)
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	old, ok := v.networks[n.ID]
+	if ok != exists {
+		return Network{}, ErrNotFound
+	}
+	// generation is only advanced by RevokeShares, so that revocations are never undone
+	n.ShareGeneration = old.ShareGeneration
+	v.networks[n.ID] = n
+	err = v.save()
+	if err != nil {
+		// keep memory consistent with file
+		if exists {
+			v.networks[n.ID] = old
+		} else {
+			delete(v.networks, n.ID)
+		}
+		return Network{}, err
+	}
+	return n, nil
+}
+
+// advances share generation of network, revoking share links issued so far
+func (v *Vault) RevokeShares(id string) (Network, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	old, ok := v.networks[id]
+	if !ok {
+		return Network{}, ErrNotFound
+	}
+	n := old
+	n.ShareGeneration++
+	v.networks[id] = n
+	err := v.save()
+	if err != nil {
+		v.networks[id] = old
+		return Network{}, err
+	}
+	return n, nil
+}
+
+func (v *Vault) Delete(id string) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	old, ok := v.networks[id]
+	if !ok {
+		return ErrNotFound
+	}
+	delete(v.networks, id)
+	err := v.save()
+	if err != nil {
+		v.networks[id] = old
+		return err
+	}
+	return nil
+}
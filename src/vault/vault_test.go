@@ -0,0 +1,103 @@
+package vault
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestVault(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vault.json")
+	key := bytes.Repeat([]byte{1}, KeySize)
+	v, err := Open(path, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	guest, err := v.Create(Network{Name: "Guest", SSID: "guest", Password: "correct horse", Encryption: "WPA"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	staff, err := v.Create(Network{Name: "Staff", SSID: "staff", Encryption: "WPA2-EAP", EAP: &EAP{Method: "PEAP", Phase2: "MSCHAPV2"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	guest, err = v.Update(guest.ID, Network{Name: "Guest", SSID: "guest", Password: "battery staple", Encryption: "WPA"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("battery")) || bytes.Contains(data, []byte("guest")) {
+		t.Errorf("vault file = %s; expected to be encrypted", data)
+	}
+
+	reopened, err := Open(path, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := reopened.List(); !reflect.DeepEqual(got, []Network{guest, staff}) {
+		t.Errorf("Vault.List() = %v; expected %v", got, []Network{guest, staff})
+	}
+
+	testcases := []struct {
+		name    string
+		do      func() error
+		wantErr error
+	}{
+		{name: "Open() with wrong key", do: func() error {
+			_, err := Open(path, bytes.Repeat([]byte{2}, KeySize))
+			return err
+		}, wantErr: errors.New(path + ": cannot decrypt vault, as key is wrong or file is altered")},
+		{name: "Vault.Create() with invalid network", do: func() error {
+			_, err := v.Create(Network{SSID: "guest", Encryption: "WPA3"})
+			return err
+		}, wantErr: errors.New("cannot convert value 'WPA3' to type Encryption")},
+		{name: "Vault.Update() of missing network", do: func() error {
+			_, err := v.Update("missing", guest)
+			return err
+		}, wantErr: ErrNotFound},
+		{name: "Vault.Delete()", do: func() error {
+			return v.Delete(staff.ID)
+		}, wantErr: nil},
+		{name: "Vault.Get() of deleted network", do: func() error {
+			_, err := v.Get(staff.ID)
+			return err
+		}, wantErr: ErrNotFound},
+	}
+
+	for _, tt := range testcases {
+		t.Run("testing "+tt.name, func(t *testing.T) {
+			err := tt.do()
+			if (err == nil) != (tt.wantErr == nil) || (err != nil && err.Error() != tt.wantErr.Error()) {
+				t.Errorf("%s error = '%v'; expected '%v'", tt.name, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	testcases := []struct {
+		src     string
+		wantErr error
+	}{
+		{src: "AQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQE=", wantErr: nil},
+		{src: "AQEBAQ==", wantErr: errors.New("vault key must be 32 bytes encoded in base64")},
+		{src: "not base64", wantErr: errors.New("vault key must be 32 bytes encoded in base64")},
+	}
+
+	for _, tt := range testcases {
+		t.Run("testing ParseKey()", func(t *testing.T) {
+			_, err := ParseKey(tt.src)
+			if (err == nil) != (tt.wantErr == nil) || (err != nil && err.Error() != tt.wantErr.Error()) {
+				t.Errorf("ParseKey() error = '%v'; expected '%v'", err, tt.wantErr)
+			}
+		})
+	}
+}